@@ -51,6 +51,9 @@ func main() {
 		model.InfraApiAccessLog{},
 		model.InfraApiErrorLog{},
 		model.SystemTenantPackage{},
+		model.SystemUserTotp{},
+		model.SystemOAuth2Approve{},
+		model.SystemUserPasswordHistory{},
 	)
 
 	// 4. 执行生成
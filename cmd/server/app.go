@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
+	"github.com/wxlbd/admin-go/internal/pkg/permission"
+	"github.com/wxlbd/admin-go/internal/pkg/websocket"
+	"github.com/wxlbd/admin-go/internal/service/infra"
+	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/config"
+	"go.uber.org/zap"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+// App 应用实例，负责 HTTP 服务的启动与优雅停止
+type App struct {
+	engine          *gin.Engine
+	scheduler       *infra.Scheduler
+	wsManager       *websocket.Manager
+	dataScopeCache  *datascope.Cache
+	policySyncer    *permission.PolicySyncer
+	operateLogSvc   *system.OperateLogService
+	apiAccessLogSvc *infra.ApiAccessLogService
+	apiErrorLogSvc  *infra.ApiErrorLogService
+	log             *zap.Logger
+}
+
+func NewApp(
+	engine *gin.Engine,
+	scheduler *infra.Scheduler,
+	wsManager *websocket.Manager,
+	dataScopeCache *datascope.Cache,
+	policySyncer *permission.PolicySyncer,
+	operateLogSvc *system.OperateLogService,
+	apiAccessLogSvc *infra.ApiAccessLogService,
+	apiErrorLogSvc *infra.ApiErrorLogService,
+	log *zap.Logger,
+) *App {
+	return &App{
+		engine:          engine,
+		scheduler:       scheduler,
+		wsManager:       wsManager,
+		dataScopeCache:  dataScopeCache,
+		policySyncer:    policySyncer,
+		operateLogSvc:   operateLogSvc,
+		apiAccessLogSvc: apiAccessLogSvc,
+		apiErrorLogSvc:  apiErrorLogSvc,
+		log:             log,
+	}
+}
+
+// Run 启动 HTTP 服务，收到 SIGINT / SIGTERM 后优雅停止
+func (a *App) Run(addr string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: a.engine,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		a.log.Info("Server starting...", zap.String("addr", addr))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	select {
+	case err, ok := <-errCh:
+		if ok {
+			return err
+		}
+	case sig := <-quit:
+		a.log.Info("Server shutting down...", zap.String("signal", sig.String()))
+	}
+
+	timeout := defaultShutdownTimeout
+	if config.C.HTTP.ShutdownTimeout > 0 {
+		timeout = time.Duration(config.C.HTTP.ShutdownTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return a.Shutdown(ctx, srv)
+}
+
+// Shutdown 按顺序停止各组件，ctx 到期后不再等待
+// 1. 停止触发新的定时任务
+// 2. 关闭监听不再接收新连接，随后关闭 WebSocket 会话（长连接不会被 http.Server.Shutdown 关闭），等待处理中的请求结束
+// 3. 停止订阅 Redis 的 WebSocket 广播、数据权限缓存及权限策略变更
+// 4. 等待执行中的定时任务结束，超时则中断并标记任务日志
+// 5. 刷新异步写入的日志
+func (a *App) Shutdown(ctx context.Context, srv *http.Server) error {
+	var errs []error
+	jobDone := make(chan error, 1)
+	go func() {
+		jobDone <- a.scheduler.Shutdown(ctx)
+	}()
+
+	// 在监听关闭后再关闭 WebSocket 会话，避免关闭期间又建立新的会话
+	srv.RegisterOnShutdown(func() {
+		a.wsManager.CloseAll("server shutting down")
+	})
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+		a.log.Error("Failed to drain http requests", zap.Error(err))
+	}
+	a.wsManager.Stop()
+	a.dataScopeCache.Stop()
+	a.policySyncer.Stop()
+	if err := <-jobDone; err != nil {
+		errs = append(errs, err)
+		a.log.Error("Failed to stop scheduler", zap.Error(err))
+	}
+
+	// 请求处理完成后再关闭日志写入，避免丢失最后一批请求的日志
+	// 写入使用单独的超时，避免前面的步骤耗尽时间后丢弃缓冲的日志
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, closer := range []interface{ Close(context.Context) error }{
+		a.operateLogSvc, a.apiAccessLogSvc, a.apiErrorLogSvc,
+	} {
+		if err := closer.Close(flushCtx); err != nil {
+			errs = append(errs, err)
+			a.log.Error("Failed to flush async logs", zap.Error(err))
+		}
+	}
+
+	a.log.Info("Server stopped")
+	_ = a.log.Sync()
+	return errors.Join(errs...)
+}
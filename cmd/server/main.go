@@ -25,15 +25,13 @@ func main() {
 
 	// 4. 初始化应用 (通过 Wire 注入)
 	// 注意：InitDB 和 InitRedis 会在 InitApp 中被自动调用
-	engine, err := InitApp()
+	app, err := InitApp()
 	if err != nil {
 		logger.Log.Fatal("failed to init app", zap.Error(err))
 	}
 
-	// 5. 启动服务
-	addr := config.C.HTTP.Port
-	logger.Info("Server starting...", zap.String("addr", addr))
-	if err := engine.Run(addr); err != nil {
-		logger.Log.Fatal("failed to start server", zap.Error(err))
+	// 5. 启动服务，收到停止信号后优雅停止
+	if err := app.Run(config.C.HTTP.Port); err != nil {
+		logger.Log.Fatal("failed to run server", zap.Error(err))
 	}
 }
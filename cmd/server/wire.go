@@ -7,7 +7,9 @@ import (
 	"github.com/wxlbd/admin-go/internal/api/handler"
 	"github.com/wxlbd/admin-go/internal/api/router"
 	"github.com/wxlbd/admin-go/internal/middleware"
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
 	"github.com/wxlbd/admin-go/internal/pkg/permission"
+	"github.com/wxlbd/admin-go/internal/pkg/tenant"
 	"github.com/wxlbd/admin-go/internal/pkg/websocket"
 	"github.com/wxlbd/admin-go/internal/repo"
 	"github.com/wxlbd/admin-go/internal/service/infra"
@@ -17,16 +19,18 @@ import (
 	"github.com/wxlbd/admin-go/pkg/database"
 	"github.com/wxlbd/admin-go/pkg/logger"
 
-	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
 )
 
-func InitApp() (*gin.Engine, error) {
+func InitApp() (*App, error) {
 	wire.Build(
 		database.InitDB,
 		cache.InitRedis,
 		logger.NewLogger,
 		// Repo (GORM Gen)
+		tenant.RegisterPlugin,
+		datascope.NewCache,
+		datascope.RegisterPlugin,
 		repo.NewQuery,
 		// WebSocket
 		websocket.NewManager,
@@ -39,6 +43,14 @@ func InitApp() (*gin.Engine, error) {
 		system.NewSmsSendService,
 		system.NewSmsCodeService,
 		system.NewSocialUserService,
+		system.NewCaptchaService,
+		system.NewLoginLimitService,
+		system.NewTwoFactorService,
+		system.NewPasswordPolicyService,
+		system.NewOAuth2ClientService,
+		system.NewOAuth2ApproveService,
+		system.NewOAuth2GrantService,
+		system.NewOnlineUserService,
 		system.NewAuthService,
 		system.NewMenuService,
 		system.NewRoleService,
@@ -67,16 +79,61 @@ func InitApp() (*gin.Engine, error) {
 		handler.ProviderSet,
 		// Casbin & Middleware
 		permission.InitEnforcer,
+		permission.NewPolicySyncer,
 		middleware.NewCasbinMiddleware,
+		middleware.NewTenantMiddleware,
+		middleware.NewOperateLogMiddleware,
+		middleware.NewAPIAccessLogMiddleware,
+		middleware.NewAPIErrorLogMiddleware,
 		// Router
 		router.InitRouter,
-		// Job Handlers (Empty for basic admin template)
+		// Job Handlers
+		system.NewTenantExpireJob,
+		system.NewLoginLogCleanJob,
+		system.NewSmsLogCleanJob,
+		system.NewMailLogCleanJob,
+		system.NewSmsCodeExpireJob,
+		infra.NewJobLogCleanJob,
+		infra.NewApiAccessLogCleanJob,
+		infra.NewApiErrorLogCleanJob,
+		infra.NewFileCleanJob,
 		ProvideJobHandlers,
+		system.NewJobFailureNotifier,
+		ProvideJobFailureHooks,
+		// App
+		NewApp,
 	)
-	return &gin.Engine{}, nil
+	return &App{}, nil
 }
 
-// ProvideJobHandlers 聚合定时任务处理器，当前为空（管理后台基础模板）
-func ProvideJobHandlers() []infra.JobHandler {
-	return []infra.JobHandler{}
+// ProvideJobHandlers 聚合定时任务处理器
+func ProvideJobHandlers(
+	tenantExpireJob *system.TenantExpireJob,
+	loginLogCleanJob *system.LoginLogCleanJob,
+	smsLogCleanJob *system.SmsLogCleanJob,
+	mailLogCleanJob *system.MailLogCleanJob,
+	smsCodeExpireJob *system.SmsCodeExpireJob,
+	jobLogCleanJob *infra.JobLogCleanJob,
+	apiAccessLogCleanJob *infra.ApiAccessLogCleanJob,
+	apiErrorLogCleanJob *infra.ApiErrorLogCleanJob,
+	fileCleanJob *infra.FileCleanJob,
+) []infra.JobHandler {
+	return []infra.JobHandler{
+		tenantExpireJob,
+		loginLogCleanJob,
+		smsLogCleanJob,
+		mailLogCleanJob,
+		smsCodeExpireJob,
+		jobLogCleanJob,
+		apiAccessLogCleanJob,
+		apiErrorLogCleanJob,
+		fileCleanJob,
+	}
+}
+
+// ProvideJobFailureHooks 聚合定时任务执行失败回调
+func ProvideJobFailureHooks(jobFailureNotifier *system.JobFailureNotifier) []infra.JobFailureHook {
+	return []infra.JobFailureHook{
+		jobFailureNotifier,
+	}
 }
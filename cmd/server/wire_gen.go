@@ -7,13 +7,14 @@
 package main
 
 import (
-	"github.com/gin-gonic/gin"
 	"github.com/wxlbd/admin-go/internal/api/handler/admin"
 	"github.com/wxlbd/admin-go/internal/api/handler/admin/infra"
 	system2 "github.com/wxlbd/admin-go/internal/api/handler/admin/system"
 	"github.com/wxlbd/admin-go/internal/api/router"
 	"github.com/wxlbd/admin-go/internal/middleware"
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
 	"github.com/wxlbd/admin-go/internal/pkg/permission"
+	"github.com/wxlbd/admin-go/internal/pkg/tenant"
 	"github.com/wxlbd/admin-go/internal/pkg/websocket"
 	"github.com/wxlbd/admin-go/internal/repo"
 	infra2 "github.com/wxlbd/admin-go/internal/service/infra"
@@ -25,23 +26,46 @@ import (
 
 // Injectors from wire.go:
 
-func InitApp() (*gin.Engine, error) {
+func InitApp() (*App, error) {
 	db := database.InitDB()
 	client := cache.InitRedis()
-	query := repo.NewQuery(db)
+	zapLogger := logger.NewLogger()
+	pluginRegistered, err := tenant.RegisterPlugin(db, zapLogger)
+	if err != nil {
+		return nil, err
+	}
+	datascopeCache := datascope.NewCache(client, zapLogger)
+	datascopePluginRegistered, err := datascope.RegisterPlugin(db, zapLogger, datascopeCache)
+	if err != nil {
+		return nil, err
+	}
+	query := repo.NewQuery(db, pluginRegistered, datascopePluginRegistered)
 	configService := system.NewConfigService(query)
 	configHandler := infra.NewConfigHandler(configService)
 	fileConfigService := infra2.NewFileConfigService(query)
 	fileConfigHandler := infra.NewFileConfigHandler(fileConfigService)
 	fileService := infra2.NewFileService(query, fileConfigService)
 	fileHandler := infra.NewFileHandler(fileService)
-	apiAccessLogService := infra2.NewApiAccessLogService(query)
+	apiAccessLogService := infra2.NewApiAccessLogService(query, zapLogger)
 	apiAccessLogHandler := infra.NewApiAccessLogHandler(apiAccessLogService)
-	apiErrorLogService := infra2.NewApiErrorLogService(query)
+	apiErrorLogService := infra2.NewApiErrorLogService(query, zapLogger)
 	apiErrorLogHandler := infra.NewApiErrorLogHandler(apiErrorLogService)
-	zapLogger := logger.NewLogger()
-	v := ProvideJobHandlers()
-	scheduler, err := infra2.NewScheduler(query, zapLogger, v)
+	oAuth2TokenService := system.NewOAuth2TokenService()
+	notifyService := system.NewNotifyService(query)
+	mailService := system.NewMailService(db)
+	tenantExpireJob := system.NewTenantExpireJob(query, client, oAuth2TokenService, notifyService, mailService)
+	loginLogCleanJob := system.NewLoginLogCleanJob(query)
+	smsLogCleanJob := system.NewSmsLogCleanJob(query)
+	mailLogCleanJob := system.NewMailLogCleanJob(db)
+	smsCodeExpireJob := system.NewSmsCodeExpireJob(query)
+	jobLogCleanJob := infra2.NewJobLogCleanJob(query)
+	apiAccessLogCleanJob := infra2.NewApiAccessLogCleanJob(query)
+	apiErrorLogCleanJob := infra2.NewApiErrorLogCleanJob(query)
+	fileCleanJob := infra2.NewFileCleanJob(query)
+	v := ProvideJobHandlers(tenantExpireJob, loginLogCleanJob, smsLogCleanJob, mailLogCleanJob, smsCodeExpireJob, jobLogCleanJob, apiAccessLogCleanJob, apiErrorLogCleanJob, fileCleanJob)
+	jobFailureNotifier := system.NewJobFailureNotifier(query, notifyService)
+	v2 := ProvideJobFailureHooks(jobFailureNotifier)
+	scheduler, err := infra2.NewScheduler(query, client, zapLogger, v, v2)
 	if err != nil {
 		return nil, err
 	}
@@ -49,25 +73,35 @@ func InitApp() (*gin.Engine, error) {
 	jobHandler := infra.NewJobHandler(jobService)
 	jobLogService := infra2.NewJobLogService(query)
 	jobLogHandler := infra.NewJobLogHandler(jobLogService)
-	manager := websocket.NewManager()
+	manager := websocket.NewManager(client, zapLogger)
 	webSocketHandler := infra.NewWebSocketHandler(manager, zapLogger)
 	handlers := infra.NewHandlers(configHandler, fileConfigHandler, fileHandler, apiAccessLogHandler, apiErrorLogHandler, jobHandler, jobLogHandler, webSocketHandler)
 	areaHandler := system2.NewAreaHandler()
-	roleService := system.NewRoleService(query)
-	permissionService := system.NewPermissionService(query, roleService)
-	menuService := system.NewMenuService(query)
-	oAuth2TokenService := system.NewOAuth2TokenService()
+	syncedEnforcer, err := permission.InitEnforcer(db)
+	if err != nil {
+		return nil, err
+	}
+	policySyncer := permission.NewPolicySyncer(syncedEnforcer, client, zapLogger)
+	roleService := system.NewRoleService(query, datascopeCache, policySyncer)
+	permissionService := system.NewPermissionService(query, roleService, datascopeCache, policySyncer)
+	menuService := system.NewMenuService(query, policySyncer)
 	smsTemplateService := system.NewSmsTemplateService(query)
 	smsLogService := system.NewSmsLogService(query)
 	smsClientFactory := system.NewSmsClientFactory()
 	smsSendService := system.NewSmsSendService(query, smsTemplateService, smsLogService, smsClientFactory)
 	smsCodeService := system.NewSmsCodeService(query, client, smsSendService)
 	loginLogService := system.NewLoginLogService(query)
-	deptService := system.NewDeptService(query)
-	userService := system.NewUserService(query, deptService)
+	deptService := system.NewDeptService(query, datascopeCache)
+	passwordPolicyService := system.NewPasswordPolicyService(query)
+	tenantService := system.NewTenantService(query, client, roleService, permissionService, passwordPolicyService, policySyncer)
+	userService := system.NewUserService(query, deptService, passwordPolicyService, tenantService, datascopeCache, policySyncer)
 	socialUserService := system.NewSocialUserService(query)
-	authService := system.NewAuthService(query, permissionService, roleService, menuService, oAuth2TokenService, smsCodeService, loginLogService, userService, socialUserService)
+	captchaService := system.NewCaptchaService(client)
+	loginLimitService := system.NewLoginLimitService(query, client)
+	twoFactorService := system.NewTwoFactorService(query, client, loginLimitService)
+	authService := system.NewAuthService(query, permissionService, roleService, menuService, oAuth2TokenService, smsCodeService, loginLogService, userService, socialUserService, captchaService, loginLimitService, twoFactorService, passwordPolicyService, tenantService)
 	authHandler := system2.NewAuthHandler(authService)
+	captchaHandler := system2.NewCaptchaHandler(captchaService)
 	deptHandler := system2.NewDeptHandler(deptService)
 	dictService := system.NewDictService(query)
 	dictHandler := system2.NewDictHandler(dictService)
@@ -75,11 +109,15 @@ func InitApp() (*gin.Engine, error) {
 	menuHandler := system2.NewMenuHandler(menuService)
 	noticeService := system.NewNoticeService(query)
 	noticeHandler := system2.NewNoticeHandler(noticeService, webSocketHandler)
-	notifyService := system.NewNotifyService(query)
 	notifyHandler := system2.NewNotifyHandler(notifyService)
-	operateLogService := system.NewOperateLogService(query)
+	oAuth2ClientService := system.NewOAuth2ClientService(db)
+	oAuth2ApproveService := system.NewOAuth2ApproveService(query)
+	oAuth2GrantService := system.NewOAuth2GrantService(query, client, oAuth2ClientService, oAuth2ApproveService, oAuth2TokenService, authService)
+	oAuth2OpenHandler := system2.NewOAuth2OpenHandler(oAuth2GrantService)
+	onlineUserService := system.NewOnlineUserService(query, oAuth2TokenService, loginLogService, manager)
+	onlineUserHandler := system2.NewOnlineUserHandler(onlineUserService)
+	operateLogService := system.NewOperateLogService(query, zapLogger)
 	operateLogHandler := system2.NewOperateLogHandler(operateLogService)
-	tenantService := system.NewTenantService(query, roleService, permissionService)
 	permissionHandler := system2.NewPermissionHandler(permissionService, tenantService)
 	postService := system.NewPostService(query)
 	postHandler := system2.NewPostHandler(postService)
@@ -87,30 +125,58 @@ func InitApp() (*gin.Engine, error) {
 	tenantHandler := system2.NewTenantHandler(tenantService)
 	tenantPackageService := system.NewTenantPackageService(query, tenantService)
 	tenantPackageHandler := system2.NewTenantPackageHandler(tenantPackageService)
-	userHandler := system2.NewUserHandler(userService)
+	twoFactorHandler := system2.NewTwoFactorHandler(twoFactorService)
+	userHandler := system2.NewUserHandler(userService, loginLimitService)
 	smsChannelService := system.NewSmsChannelService(query)
 	smsChannelHandler := system2.NewSmsChannelHandler(smsChannelService)
 	smsTemplateHandler := system2.NewSmsTemplateHandler(smsTemplateService, smsSendService)
 	smsLogHandler := system2.NewSmsLogHandler(smsLogService)
-	mailService := system.NewMailService(db)
 	mailHandler := system2.NewMailHandler(mailService)
-	systemHandlers := system2.NewHandlers(areaHandler, authHandler, deptHandler, dictHandler, loginLogHandler, menuHandler, noticeHandler, notifyHandler, operateLogHandler, permissionHandler, postHandler, roleHandler, tenantHandler, tenantPackageHandler, userHandler, smsChannelHandler, smsTemplateHandler, smsLogHandler, mailHandler)
+	systemHandlers := system2.NewHandlers(areaHandler, authHandler, captchaHandler, deptHandler, dictHandler, loginLogHandler, menuHandler, noticeHandler, notifyHandler, oAuth2OpenHandler, onlineUserHandler, operateLogHandler, permissionHandler, postHandler, roleHandler, tenantHandler, tenantPackageHandler, twoFactorHandler, userHandler, smsChannelHandler, smsTemplateHandler, smsLogHandler, mailHandler)
 	adminHandlers := &admin.AdminHandlers{
 		Infra:  handlers,
 		System: systemHandlers,
 	}
-	enforcer, err := permission.InitEnforcer(db)
-	if err != nil {
-		return nil, err
-	}
-	casbinMiddleware := middleware.NewCasbinMiddleware(enforcer, permissionService)
-	engine := router.InitRouter(db, client, adminHandlers, casbinMiddleware)
-	return engine, nil
+	casbinMiddleware := middleware.NewCasbinMiddleware(syncedEnforcer, permissionService)
+	operateLogMiddleware := middleware.NewOperateLogMiddleware(operateLogService)
+	apiAccessLogMiddleware := middleware.NewAPIAccessLogMiddleware(apiAccessLogService)
+	apiErrorLogMiddleware := middleware.NewAPIErrorLogMiddleware(apiErrorLogService)
+	tenantMiddleware := middleware.NewTenantMiddleware(tenantService)
+	engine := router.InitRouter(db, client, adminHandlers, casbinMiddleware, operateLogMiddleware, apiAccessLogMiddleware, apiErrorLogMiddleware, tenantMiddleware, policySyncer)
+	app := NewApp(engine, scheduler, manager, datascopeCache, policySyncer, operateLogService, apiAccessLogService, apiErrorLogService, zapLogger)
+	return app, nil
 }
 
 // wire.go:
 
-// ProvideJobHandlers 聚合定时任务处理器，当前为空（管理后台基础模板）
-func ProvideJobHandlers() []infra2.JobHandler {
-	return []infra2.JobHandler{}
+// ProvideJobHandlers 聚合定时任务处理器
+func ProvideJobHandlers(
+	tenantExpireJob *system.TenantExpireJob,
+	loginLogCleanJob *system.LoginLogCleanJob,
+	smsLogCleanJob *system.SmsLogCleanJob,
+	mailLogCleanJob *system.MailLogCleanJob,
+	smsCodeExpireJob *system.SmsCodeExpireJob,
+	jobLogCleanJob *infra2.JobLogCleanJob,
+	apiAccessLogCleanJob *infra2.ApiAccessLogCleanJob,
+	apiErrorLogCleanJob *infra2.ApiErrorLogCleanJob,
+	fileCleanJob *infra2.FileCleanJob,
+) []infra2.JobHandler {
+	return []infra2.JobHandler{
+		tenantExpireJob,
+		loginLogCleanJob,
+		smsLogCleanJob,
+		mailLogCleanJob,
+		smsCodeExpireJob,
+		jobLogCleanJob,
+		apiAccessLogCleanJob,
+		apiErrorLogCleanJob,
+		fileCleanJob,
+	}
+}
+
+// ProvideJobFailureHooks 聚合定时任务执行失败回调
+func ProvideJobFailureHooks(jobFailureNotifier *system.JobFailureNotifier) []infra2.JobFailureHook {
+	return []infra2.JobFailureHook{
+		jobFailureNotifier,
+	}
 }
@@ -33,6 +33,7 @@ type ApiAccessLogResp struct {
 	TraceID         string    `json:"traceId"`
 	UserID          int64     `json:"userId"`
 	UserType        int       `json:"userType"`
+	ImpersonatorID  int64     `json:"impersonatorId,omitempty"` // 代登录的操作人编号
 	ApplicationName string    `json:"applicationName"`
 	RequestMethod   string    `json:"requestMethod"`
 	RequestURL      string    `json:"requestUrl"`
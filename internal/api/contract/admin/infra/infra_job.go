@@ -9,9 +9,13 @@ type JobSaveReq struct {
 	HandlerName    string `json:"handlerName" binding:"required"`
 	HandlerParam   string `json:"handlerParam"`
 	CronExpression string `json:"cronExpression" binding:"required"`
-	RetryCount     int    `json:"retryCount"`
-	RetryInterval  int    `json:"retryInterval"`
-	MonitorTimeout *int   `json:"monitorTimeout"`
+	RetryCount     int    `json:"retryCount" binding:"min=0,max=10"`        // 失败后的重试次数
+	RetryInterval  int    `json:"retryInterval" binding:"min=0"`            // 重试间隔，单位：毫秒
+	MonitorTimeout *int   `json:"monitorTimeout" binding:"omitempty,min=0"` // 执行超时时间，单位：毫秒，0 表示不限制
+	// ConcurrencyPolicy 并发策略：0 执行中跳过，1 允许并发，2 排队等待
+	ConcurrencyPolicy int `json:"concurrencyPolicy" binding:"oneof=0 1 2"`
+	// MisfirePolicy 错过触发的补偿策略：0 忽略，1 补偿执行一次，2 补偿执行全部错过的触发
+	MisfirePolicy int `json:"misfirePolicy" binding:"oneof=0 1 2"`
 }
 
 // JobPageReq 定时任务分页请求
@@ -35,16 +39,18 @@ type JobLogPageReq struct {
 
 // JobResp 定时任务响应
 type JobResp struct {
-	ID             int64     `json:"id"`
-	Name           string    `json:"name"`
-	Status         int       `json:"status"`
-	HandlerName    string    `json:"handlerName"`
-	HandlerParam   string    `json:"handlerParam"`
-	CronExpression string    `json:"cronExpression"`
-	RetryCount     int       `json:"retryCount"`
-	RetryInterval  int       `json:"retryInterval"`
-	MonitorTimeout *int      `json:"monitorTimeout"`
-	CreateTime     time.Time `json:"createTime"`
+	ID                int64     `json:"id"`
+	Name              string    `json:"name"`
+	Status            int       `json:"status"`
+	HandlerName       string    `json:"handlerName"`
+	HandlerParam      string    `json:"handlerParam"`
+	CronExpression    string    `json:"cronExpression"`
+	RetryCount        int       `json:"retryCount"`
+	RetryInterval     int       `json:"retryInterval"`
+	MonitorTimeout    *int      `json:"monitorTimeout"`
+	ConcurrencyPolicy int       `json:"concurrencyPolicy"`
+	MisfirePolicy     int       `json:"misfirePolicy"`
+	CreateTime        time.Time `json:"createTime"`
 }
 
 // JobLogResp 定时任务日志响应
@@ -54,6 +60,7 @@ type JobLogResp struct {
 	HandlerName  string     `json:"handlerName"`
 	HandlerParam string     `json:"handlerParam"`
 	ExecuteIndex int        `json:"executeIndex"`
+	InstanceID   string     `json:"instanceId"`
 	BeginTime    time.Time  `json:"beginTime"`
 	EndTime      *time.Time `json:"endTime"`
 	Duration     *int       `json:"duration"`
@@ -61,3 +68,16 @@ type JobLogResp struct {
 	Result       string     `json:"result"`
 	CreateTime   time.Time  `json:"createTime"`
 }
+
+// JobExecutionResp 执行中（含排队等待）的任务响应
+type JobExecutionResp struct {
+	ID           string    `json:"id"`
+	JobID        int64     `json:"jobId"`
+	JobName      string    `json:"jobName"`
+	HandlerName  string    `json:"handlerName"`
+	Trigger      int       `json:"trigger"`      // 触发方式：1 定时触发，2 手动触发，3 补偿执行
+	Status       int       `json:"status"`       // 执行状态：0 排队等待，1 执行中
+	ExecuteIndex int       `json:"executeIndex"` // 当前第几次执行
+	InstanceID   string    `json:"instanceId"`
+	TriggerTime  time.Time `json:"triggerTime"`
+}
@@ -7,7 +7,7 @@ type AuthLoginReq struct {
 	Username   string `json:"username" binding:"required"`
 	Password   string `json:"password" binding:"required"`
 	TenantName string `json:"tenantName"` // 租户名, 某些版本前端可能传 tenantName
-	// CaptchaVerificationReqVO fields (Skipping strict validation for now)
+	// CaptchaVerification 验证码二次校验串，开启验证码时必填，由 AuthService 按配置校验
 	CaptchaVerification string `json:"captchaVerification"`
 }
 
@@ -27,6 +27,8 @@ type AuthSmsSendReq struct {
 type AuthRegisterReq struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// CaptchaVerification 验证码二次校验串，开启验证码时必填，在创建用户前校验
+	CaptchaVerification string `json:"captchaVerification"`
 }
 
 // AuthResetPasswordReq 重置密码请求
@@ -45,11 +47,18 @@ type AuthSocialLoginReq struct {
 }
 
 // AuthLoginResp 登录响应
+// 需要二次验证时不返回令牌，而是返回 TwoFactorTicket，前端凭票据调用 two-factor-login 完成登录
 type AuthLoginResp struct {
 	UserId       int64     `json:"userId"`
 	AccessToken  string    `json:"accessToken"`
 	RefreshToken string    `json:"refreshToken"`
 	ExpiresTime  time.Time `json:"expiresTime"`
+
+	TwoFactorTicket        string   `json:"twoFactorTicket,omitempty"`        // 二次验证预认证票据
+	TwoFactorSetupRequired bool     `json:"twoFactorSetupRequired,omitempty"` // 租户策略要求开启但尚未绑定验证器
+	RecoveryCodes          []string `json:"recoveryCodes,omitempty"`          // 登录时完成绑定后返回的恢复码，仅展示一次
+
+	PasswordChangeRequired bool `json:"passwordChangeRequired,omitempty"` // 仍在使用初始密码或密码已超过有效期，前端需引导用户修改密码
 }
 
 type AuthPermissionInfoResp struct {
@@ -57,6 +66,14 @@ type AuthPermissionInfoResp struct {
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
 	Menus       []MenuVO `json:"menus"`
+
+	Impersonated bool    `json:"impersonated"`           // 是否为平台管理员访问租户，前端据此展示提示横幅
+	Impersonator *UserVO `json:"impersonator,omitempty"` // 代登录的操作人
+}
+
+// AuthVisitTenantReq 访问租户请求
+type AuthVisitTenantReq struct {
+	TenantID int64 `json:"tenantId" binding:"required"`
 }
 
 type UserVO struct {
@@ -0,0 +1,26 @@
+package system
+
+// CaptchaReq 获取/校验验证码请求，与 AJ-Captcha 前端组件对齐
+type CaptchaReq struct {
+	CaptchaType string `json:"captchaType"` // 验证码类型：blockPuzzle 滑块拼图，imageText 图形文字
+	Token       string `json:"token"`       // 获取验证码时返回的 token，校验时必填
+	PointJson   string `json:"pointJson"`   // 滑块为 AES 加密后的坐标 JSON，图形文字为用户输入的文字
+}
+
+// CaptchaResp 验证码响应，与 AJ-Captcha 的 ResponseModel 对齐，不使用统一响应结构
+type CaptchaResp struct {
+	RepCode string           `json:"repCode"`
+	RepMsg  string           `json:"repMsg"`
+	RepData *CaptchaRespData `json:"repData"`
+	Success bool             `json:"success"`
+}
+
+// CaptchaRespData 验证码数据
+type CaptchaRespData struct {
+	CaptchaType         string `json:"captchaType"`
+	Token               string `json:"token"`
+	OriginalImageBase64 string `json:"originalImageBase64,omitempty"` // 底图 / 图形文字图片
+	JigsawImageBase64   string `json:"jigsawImageBase64,omitempty"`   // 滑块拼图块
+	SecretKey           string `json:"secretKey,omitempty"`           // 坐标加密密钥
+	Result              bool   `json:"result"`
+}
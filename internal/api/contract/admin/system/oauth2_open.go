@@ -0,0 +1,69 @@
+package system
+
+// OAuth2OpenAuthorizeInfoResp 授权页信息
+type OAuth2OpenAuthorizeInfoResp struct {
+	Client OAuth2OpenAuthorizeClient  `json:"client"`
+	Scopes []OAuth2OpenAuthorizeScope `json:"scopes"`
+}
+
+// OAuth2OpenAuthorizeClient 授权页展示的客户端信息
+type OAuth2OpenAuthorizeClient struct {
+	Name string `json:"name"`
+	Logo string `json:"logo"`
+}
+
+// OAuth2OpenAuthorizeScope 授权范围及用户是否已批准
+type OAuth2OpenAuthorizeScope struct {
+	Key   string `json:"key"`
+	Value bool   `json:"value"`
+}
+
+// OAuth2OpenAuthorizeReq 申请授权，授权成功后返回重定向地址
+type OAuth2OpenAuthorizeReq struct {
+	ResponseType        string `form:"response_type" binding:"required"` // 仅支持 code
+	ClientID            string `form:"client_id" binding:"required"`
+	Scope               string `form:"scope"` // JSON 格式，如 {"user.read":true}
+	RedirectURI         string `form:"redirect_uri" binding:"required"`
+	AutoApprove         bool   `form:"auto_approve"` // true 表示跳转授权页前检查是否已批准
+	State               string `form:"state"`
+	CodeChallenge       string `form:"code_challenge"`        // PKCE
+	CodeChallengeMethod string `form:"code_challenge_method"` // plain 或 S256，默认 plain
+}
+
+// OAuth2OpenTokenReq 获取访问令牌，字段按 grant_type 取用
+type OAuth2OpenTokenReq struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Code         string `form:"code"`          // authorization_code
+	RedirectURI  string `form:"redirect_uri"`  // authorization_code
+	State        string `form:"state"`         // authorization_code
+	CodeVerifier string `form:"code_verifier"` // authorization_code + PKCE
+	Username     string `form:"username"`      // password
+	Password     string `form:"password"`      // password
+	Scope        string `form:"scope"`         // password、client_credentials，空格分隔
+	RefreshToken string `form:"refresh_token"` // refresh_token
+}
+
+// OAuth2OpenTokenParamReq 校验或撤销令牌
+type OAuth2OpenTokenParamReq struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// OAuth2OpenAccessTokenResp 访问令牌响应，字段命名遵循 RFC 6749
+type OAuth2OpenAccessTokenResp struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // 秒
+	Scope        string `json:"scope"`      // 空格分隔
+}
+
+// OAuth2OpenCheckTokenResp 令牌校验结果
+type OAuth2OpenCheckTokenResp struct {
+	UserID      int64    `json:"user_id"`
+	UserType    int      `json:"user_type"`
+	TenantID    int64    `json:"tenant_id"`
+	ClientID    string   `json:"client_id"`
+	Scopes      []string `json:"scopes"`
+	AccessToken string   `json:"access_token"`
+	Exp         int64    `json:"exp"` // 过期时间戳（秒）
+}
@@ -0,0 +1,30 @@
+package system
+
+import (
+	"time"
+
+	"github.com/wxlbd/admin-go/pkg/pagination"
+)
+
+// OnlineUserPageReq 在线用户分页请求
+type OnlineUserPageReq struct {
+	pagination.PageParam
+	Username string `form:"username"`
+	UserIP   string `form:"userIp"`
+}
+
+// OnlineUserResp 在线用户，一个访问令牌对应一条记录
+type OnlineUserResp struct {
+	ID             string    `json:"id"` // 会话编号，强制退出时使用
+	UserID         int64     `json:"userId"`
+	UserType       int       `json:"userType"`
+	Username       string    `json:"username"`
+	Nickname       string    `json:"nickname"`
+	TenantID       int64     `json:"tenantId"`
+	ClientID       string    `json:"clientId"`
+	UserIP         string    `json:"userIp"`
+	UserAgent      string    `json:"userAgent"`
+	LoginTime      time.Time `json:"loginTime"`
+	LastActiveTime time.Time `json:"lastActiveTime"`
+	ExpiresTime    time.Time `json:"expiresTime"`
+}
@@ -42,6 +42,7 @@ type PermissionAssignRoleDataScopeReq struct {
 	RoleID           int64   `json:"roleId" binding:"required"`
 	DataScope        int     `json:"dataScope" binding:"required"`
 	DataScopeDeptIDs []int64 `json:"dataScopeDeptIds"`
+	DeptDepth        int     `json:"deptDepth" binding:"omitempty,min=1"` // 部门层级深度，dataScope 为「本部门及以下 N 级」时必填
 }
 
 type PermissionAssignUserRoleReq struct {
@@ -58,6 +59,7 @@ type RoleRespVO struct {
 	Remark           string    `json:"remark"`
 	DataScope        int32     `json:"dataScope"`
 	DataScopeDeptIDs []int64   `json:"dataScopeDeptIds"`
+	DeptDepth        int       `json:"deptDepth"`
 	CreateTime       time.Time `json:"createTime"`
 }
 
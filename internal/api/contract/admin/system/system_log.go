@@ -40,18 +40,19 @@ type LoginLogResp struct {
 
 // OperateLogResp 操作日志响应
 type OperateLogResp struct {
-	ID            int64     `json:"id"`
-	TraceID       string    `json:"traceId"`
-	UserID        int64     `json:"userId"`
-	UserName      string    `json:"userName"`
-	Type          string    `json:"type"`
-	SubType       string    `json:"subType"`
-	BizID         int64     `json:"bizId"`
-	Action        string    `json:"action"`
-	Extra         string    `json:"extra"`
-	RequestMethod string    `json:"requestMethod"`
-	RequestURL    string    `json:"requestUrl"`
-	UserIP        string    `json:"userIp"`
-	UserAgent     string    `json:"userAgent"`
-	CreateTime    time.Time `json:"createTime"`
+	ID             int64     `json:"id"`
+	TraceID        string    `json:"traceId"`
+	UserID         int64     `json:"userId"`
+	UserName       string    `json:"userName"`
+	ImpersonatorID int64     `json:"impersonatorId,omitempty"` // 代登录的操作人编号
+	Type           string    `json:"type"`
+	SubType        string    `json:"subType"`
+	BizID          int64     `json:"bizId"`
+	Action         string    `json:"action"`
+	Extra          string    `json:"extra"`
+	RequestMethod  string    `json:"requestMethod"`
+	RequestURL     string    `json:"requestUrl"`
+	UserIP         string    `json:"userIp"`
+	UserAgent      string    `json:"userAgent"`
+	CreateTime     time.Time `json:"createTime"`
 }
@@ -17,6 +17,8 @@ type TenantCreateReq struct {
 	Websites      []string `json:"websites"`
 	Username      string   `json:"username" binding:"required"`
 	Password      string   `json:"password" binding:"required"`
+	// TemplateTenantID 模板租户编号，复制其部门、岗位、角色、通知公告作为初始数据；为空时使用参数配置的默认模板
+	TemplateTenantID int64 `json:"templateTenantId"`
 }
 
 type TenantUpdateReq struct {
@@ -70,3 +72,27 @@ type TenantRespVO struct {
 	AccountCount  int       `json:"accountCount"`
 	CreateTime    time.Time `json:"createTime"`
 }
+
+// TenantUsageResp 租户资源使用情况
+type TenantUsageResp struct {
+	TenantID     int64 `json:"tenantId"`
+	AccountCount int   `json:"accountCount"` // 账号配额
+	UserCount    int64 `json:"userCount"`    // 已创建的账号数
+	FileCount    int64 `json:"fileCount"`    // 文件数
+	FileSize     int64 `json:"fileSize"`     // 文件占用空间（字节）
+	SmsCount     int64 `json:"smsCount"`     // 本月发送成功的短信数
+}
+
+// TenantCloneReq 复制租户数据请求
+type TenantCloneReq struct {
+	SourceTenantID int64 `json:"sourceTenantId" binding:"required"`
+	TargetTenantID int64 `json:"targetTenantId" binding:"required"`
+}
+
+// TenantCloneResp 复制租户数据结果，为各类数据新增的数量
+type TenantCloneResp struct {
+	DeptCount   int `json:"deptCount"`
+	PostCount   int `json:"postCount"`
+	RoleCount   int `json:"roleCount"`
+	NoticeCount int `json:"noticeCount"`
+}
@@ -0,0 +1,50 @@
+package system
+
+import "time"
+
+// AuthTwoFactorLoginReq 二次验证登录请求
+type AuthTwoFactorLoginReq struct {
+	Ticket string `json:"ticket" binding:"required"`
+	Code   string `json:"code" binding:"required"` // TOTP 验证码或恢复码
+}
+
+// AuthTwoFactorSetupReq 登录过程中绑定验证器请求
+type AuthTwoFactorSetupReq struct {
+	Ticket string `json:"ticket" binding:"required"`
+}
+
+// TwoFactorCodeReq 携带 TOTP 验证码的请求
+type TwoFactorCodeReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorSetupResp 绑定验证器响应
+type TwoFactorSetupResp struct {
+	Secret    string `json:"secret"`    // base32 密钥，用于手动输入
+	QrCodeUri string `json:"qrCodeUri"` // otpauth:// 地址，用于生成二维码
+}
+
+// TwoFactorStatusResp 二次验证状态
+type TwoFactorStatusResp struct {
+	Enabled           bool       `json:"enabled"`
+	Enforced          bool       `json:"enforced"` // 租户策略是否强制开启
+	EnableTime        *time.Time `json:"enableTime"`
+	RecoveryCodeCount int        `json:"recoveryCodeCount"` // 剩余可用恢复码数量
+}
+
+// TwoFactorRecoveryCodesResp 恢复码响应，明文仅展示一次
+type TwoFactorRecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// TenantTwoFactorPolicyReq 租户二次验证策略保存请求
+type TenantTwoFactorPolicyReq struct {
+	TenantID  int64    `json:"tenantId" binding:"required"`
+	RoleCodes []string `json:"roleCodes"` // 强制开启二次验证的角色编码，为空表示不强制
+}
+
+// TenantTwoFactorPolicyResp 租户二次验证策略
+type TenantTwoFactorPolicyResp struct {
+	TenantID  int64    `json:"tenantId"`
+	RoleCodes []string `json:"roleCodes"`
+}
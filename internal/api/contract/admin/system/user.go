@@ -48,6 +48,12 @@ type UserResetPasswordReq struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UserProfileUpdatePasswordReq 修改本人密码
+type UserProfileUpdatePasswordReq struct {
+	OldPassword string `json:"oldPassword" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
 type UserExportReq struct {
 	Username     string     `form:"username"`
 	Mobile       string     `form:"mobile"`
@@ -106,3 +112,12 @@ type UserImportExcelVO struct {
 	Status   string `excel:"帐号状态"` // 0=正常, 1=停用
 	DeptID   int64  `excel:"部门编号"`
 }
+
+// UserLoginLockResp 用户登录锁定状态
+type UserLoginLockResp struct {
+	UserID       int64      `json:"userId"`
+	Locked       bool       `json:"locked"`       // 是否被锁定
+	UnlockTime   *time.Time `json:"unlockTime"`   // 自动解锁时间
+	FailureCount int        `json:"failureCount"` // 当前计数窗口内的失败次数
+	MaxFailures  int        `json:"maxFailures"`  // 锁定阈值，0 表示不限制
+}
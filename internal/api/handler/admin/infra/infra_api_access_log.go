@@ -39,6 +39,7 @@ func (h *ApiAccessLogHandler) GetApiAccessLogPage(c *gin.Context) {
 			TraceID:         log.TraceID,
 			UserID:          log.UserID,
 			UserType:        log.UserType,
+			ImpersonatorID:  log.ImpersonatorID,
 			ApplicationName: log.ApplicationName,
 			RequestMethod:   log.RequestMethod,
 			RequestURL:      log.RequestURL,
@@ -82,16 +82,18 @@ func (h *JobHandler) GetJob(c *gin.Context) {
 		return
 	}
 	response.WriteSuccess(c, infra2.JobResp{
-		ID:             job.ID,
-		Name:           job.Name,
-		Status:         job.Status,
-		HandlerName:    job.HandlerName,
-		HandlerParam:   job.HandlerParam,
-		CronExpression: job.CronExpression,
-		RetryCount:     job.RetryCount,
-		RetryInterval:  job.RetryInterval,
-		MonitorTimeout: job.MonitorTimeout,
-		CreateTime:     job.CreateTime,
+		ID:                job.ID,
+		Name:              job.Name,
+		Status:            job.Status,
+		HandlerName:       job.HandlerName,
+		HandlerParam:      job.HandlerParam,
+		CronExpression:    job.CronExpression,
+		RetryCount:        job.RetryCount,
+		RetryInterval:     job.RetryInterval,
+		MonitorTimeout:    job.MonitorTimeout,
+		ConcurrencyPolicy: job.ConcurrencyPolicy,
+		MisfirePolicy:     job.MisfirePolicy,
+		CreateTime:        job.CreateTime,
 	})
 }
 
@@ -111,16 +113,18 @@ func (h *JobHandler) GetJobPage(c *gin.Context) {
 	list := make([]infra2.JobResp, len(pageResult.List))
 	for i, job := range pageResult.List {
 		list[i] = infra2.JobResp{
-			ID:             job.ID,
-			Name:           job.Name,
-			Status:         job.Status,
-			HandlerName:    job.HandlerName,
-			HandlerParam:   job.HandlerParam,
-			CronExpression: job.CronExpression,
-			RetryCount:     job.RetryCount,
-			RetryInterval:  job.RetryInterval,
-			MonitorTimeout: job.MonitorTimeout,
-			CreateTime:     job.CreateTime,
+			ID:                job.ID,
+			Name:              job.Name,
+			Status:            job.Status,
+			HandlerName:       job.HandlerName,
+			HandlerParam:      job.HandlerParam,
+			CronExpression:    job.CronExpression,
+			RetryCount:        job.RetryCount,
+			RetryInterval:     job.RetryInterval,
+			MonitorTimeout:    job.MonitorTimeout,
+			ConcurrencyPolicy: job.ConcurrencyPolicy,
+			MisfirePolicy:     job.MisfirePolicy,
+			CreateTime:        job.CreateTime,
 		}
 	}
 
@@ -140,6 +144,35 @@ func (h *JobHandler) TriggerJob(c *gin.Context) {
 	response.WriteSuccess(c, true)
 }
 
+// GetJobExecutionList 获取执行中（含排队等待）的任务
+func (h *JobHandler) GetJobExecutionList(c *gin.Context) {
+	executions := h.svc.GetJobExecutions(c)
+	list := make([]infra2.JobExecutionResp, len(executions))
+	for i, exec := range executions {
+		list[i] = infra2.JobExecutionResp{
+			ID:           exec.ID,
+			JobID:        exec.JobID,
+			JobName:      exec.JobName,
+			HandlerName:  exec.HandlerName,
+			Trigger:      exec.Trigger,
+			Status:       exec.Status,
+			ExecuteIndex: exec.ExecuteIndex,
+			InstanceID:   exec.InstanceID,
+			TriggerTime:  exec.TriggerTime,
+		}
+	}
+	response.WriteSuccess(c, list)
+}
+
+// CancelJobExecution 取消执行中（含排队等待）的任务
+func (h *JobHandler) CancelJobExecution(c *gin.Context) {
+	if err := h.svc.CancelJobExecution(c, c.Query("id")); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
 // SyncJob 同步定时任务
 func (h *JobHandler) SyncJob(c *gin.Context) {
 	if err := h.svc.SyncJob(c); err != nil {
@@ -167,16 +200,18 @@ func (h *JobHandler) ExportJobExcel(c *gin.Context) {
 	list := make([]infra2.JobResp, len(pageResult.List))
 	for i, job := range pageResult.List {
 		list[i] = infra2.JobResp{
-			ID:             job.ID,
-			Name:           job.Name,
-			Status:         job.Status,
-			HandlerName:    job.HandlerName,
-			HandlerParam:   job.HandlerParam,
-			CronExpression: job.CronExpression,
-			RetryCount:     job.RetryCount,
-			RetryInterval:  job.RetryInterval,
-			MonitorTimeout: job.MonitorTimeout,
-			CreateTime:     job.CreateTime,
+			ID:                job.ID,
+			Name:              job.Name,
+			Status:            job.Status,
+			HandlerName:       job.HandlerName,
+			HandlerParam:      job.HandlerParam,
+			CronExpression:    job.CronExpression,
+			RetryCount:        job.RetryCount,
+			RetryInterval:     job.RetryInterval,
+			MonitorTimeout:    job.MonitorTimeout,
+			ConcurrencyPolicy: job.ConcurrencyPolicy,
+			MisfirePolicy:     job.MisfirePolicy,
+			CreateTime:        job.CreateTime,
 		}
 	}
 
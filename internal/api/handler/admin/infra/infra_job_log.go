@@ -38,6 +38,7 @@ func (h *JobLogHandler) GetJobLog(c *gin.Context) {
 		HandlerName:  log.HandlerName,
 		HandlerParam: log.HandlerParam,
 		ExecuteIndex: log.ExecuteIndex,
+		InstanceID:   log.InstanceID,
 		BeginTime:    log.BeginTime,
 		EndTime:      log.EndTime,
 		Duration:     log.Duration,
@@ -68,6 +69,7 @@ func (h *JobLogHandler) GetJobLogPage(c *gin.Context) {
 			HandlerName:  log.HandlerName,
 			HandlerParam: log.HandlerParam,
 			ExecuteIndex: log.ExecuteIndex,
+			InstanceID:   log.InstanceID,
 			BeginTime:    log.BeginTime,
 			EndTime:      log.EndTime,
 			Duration:     log.Duration,
@@ -106,6 +108,7 @@ func (h *JobLogHandler) ExportJobLogExcel(c *gin.Context) {
 			HandlerName:  log.HandlerName,
 			HandlerParam: log.HandlerParam,
 			ExecuteIndex: log.ExecuteIndex,
+			InstanceID:   log.InstanceID,
 			BeginTime:    log.BeginTime,
 			EndTime:      log.EndTime,
 			Duration:     log.Duration,
@@ -69,6 +69,8 @@ func (h *WebSocketHandler) Handle(c *gin.Context) {
 		UserID:   claims.UserID,
 		UserType: claims.UserType,
 		TenantID: claims.TenantID,
+
+		LoginSessionID: claims.SessionID,
 	}
 	h.manager.Add(session)
 	h.logger.Info("WebSocket 连接建立",
@@ -5,6 +5,7 @@ import (
 
 	system2 "github.com/wxlbd/admin-go/internal/api/contract/admin/system"
 	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/context"
 	"github.com/wxlbd/admin-go/pkg/errors"
 	"github.com/wxlbd/admin-go/pkg/response"
 
@@ -48,6 +49,22 @@ func (h *AuthHandler) GetPermissionInfo(c *gin.Context) {
 	response.WriteSuccess(c, resp)
 }
 
+// VisitTenant 平台管理员访问租户，返回该租户的代登录令牌
+// @Router /system/auth/visit-tenant [post]
+func (h *AuthHandler) VisitTenant(c *gin.Context) {
+	var req system2.AuthVisitTenantReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	resp, err := h.svc.VisitTenant(c.Request.Context(), context.GetLoginUser(c), req.TenantID)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
 // Logout 登出
 // @Router /system/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
@@ -99,6 +116,38 @@ func (h *AuthHandler) SmsLogin(c *gin.Context) {
 	response.WriteSuccess(c, resp)
 }
 
+// TwoFactorLogin 二次验证登录
+func (h *AuthHandler) TwoFactorLogin(c *gin.Context) {
+	var r system2.AuthTwoFactorLoginReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+
+	resp, err := h.svc.TwoFactorLogin(c.Request.Context(), &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// TwoFactorSetup 登录过程中绑定验证器
+func (h *AuthHandler) TwoFactorSetup(c *gin.Context) {
+	var r system2.AuthTwoFactorSetupReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+
+	resp, err := h.svc.TwoFactorSetup(c.Request.Context(), &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
 // SendSmsCode 发送短信验证码
 // @Router /system/auth/send-sms-code [post]
 func (h *AuthHandler) SendSmsCode(c *gin.Context) {
@@ -0,0 +1,56 @@
+package system
+
+import (
+	"net/http"
+
+	system2 "github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/errors"
+	"github.com/wxlbd/admin-go/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptchaHandler 验证码处理器
+// 响应直接返回 AJ-Captcha 的 ResponseModel，与 Java CaptchaController 一致
+type CaptchaHandler struct {
+	svc *system.CaptchaService
+}
+
+func NewCaptchaHandler(svc *system.CaptchaService) *CaptchaHandler {
+	return &CaptchaHandler{svc: svc}
+}
+
+// GetCaptcha 获取验证码
+// @Router /system/captcha/get [post]
+func (h *CaptchaHandler) GetCaptcha(c *gin.Context) {
+	var req system2.CaptchaReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+
+	resp, err := h.svc.GetCaptcha(c.Request.Context(), &req)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CheckCaptcha 校验验证码
+// @Router /system/captcha/check [post]
+func (h *CaptchaHandler) CheckCaptcha(c *gin.Context) {
+	var req system2.CaptchaReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+
+	resp, err := h.svc.CheckCaptcha(c.Request.Context(), &req)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
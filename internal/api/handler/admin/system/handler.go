@@ -5,18 +5,22 @@ import "github.com/google/wire"
 var ProviderSet = wire.NewSet(
 	NewAreaHandler,
 	NewAuthHandler,
+	NewCaptchaHandler,
 	NewDeptHandler,
 	NewDictHandler,
 	NewLoginLogHandler,
 	NewMenuHandler,
 	NewNoticeHandler,
 	NewNotifyHandler,
+	NewOAuth2OpenHandler,
+	NewOnlineUserHandler,
 	NewOperateLogHandler,
 	NewPermissionHandler,
 	NewPostHandler,
 	NewRoleHandler,
 	NewTenantHandler,
 	NewTenantPackageHandler,
+	NewTwoFactorHandler,
 	NewUserHandler,
 	NewSmsChannelHandler,
 	NewSmsTemplateHandler,
@@ -28,18 +32,22 @@ var ProviderSet = wire.NewSet(
 type Handlers struct {
 	Area          *AreaHandler
 	Auth          *AuthHandler
+	Captcha       *CaptchaHandler
 	Dept          *DeptHandler
 	Dict          *DictHandler
 	LoginLog      *LoginLogHandler
 	Menu          *MenuHandler
 	Notice        *NoticeHandler
 	Notify        *NotifyHandler
+	OAuth2Open    *OAuth2OpenHandler
+	OnlineUser    *OnlineUserHandler
 	OperateLog    *OperateLogHandler
 	Permission    *PermissionHandler
 	Post          *PostHandler
 	Role          *RoleHandler
 	Tenant        *TenantHandler
 	TenantPackage *TenantPackageHandler
+	TwoFactor     *TwoFactorHandler
 	User          *UserHandler
 	SmsChannel    *SmsChannelHandler
 	SmsTemplate   *SmsTemplateHandler
@@ -50,18 +58,22 @@ type Handlers struct {
 func NewHandlers(
 	area *AreaHandler,
 	auth *AuthHandler,
+	captcha *CaptchaHandler,
 	dept *DeptHandler,
 	dict *DictHandler,
 	loginLog *LoginLogHandler,
 	menu *MenuHandler,
 	notice *NoticeHandler,
 	notify *NotifyHandler,
+	oauth2Open *OAuth2OpenHandler,
+	onlineUser *OnlineUserHandler,
 	operateLog *OperateLogHandler,
 	permission *PermissionHandler,
 	post *PostHandler,
 	role *RoleHandler,
 	tenant *TenantHandler,
 	tenantPackage *TenantPackageHandler,
+	twoFactor *TwoFactorHandler,
 	user *UserHandler,
 	smsChannel *SmsChannelHandler,
 	smsTemplate *SmsTemplateHandler,
@@ -71,18 +83,22 @@ func NewHandlers(
 	return &Handlers{
 		Area:          area,
 		Auth:          auth,
+		Captcha:       captcha,
 		Dept:          dept,
 		Dict:          dict,
 		LoginLog:      loginLog,
 		Menu:          menu,
 		Notice:        notice,
 		Notify:        notify,
+		OAuth2Open:    oauth2Open,
+		OnlineUser:    onlineUser,
 		OperateLog:    operateLog,
 		Permission:    permission,
 		Post:          post,
 		Role:          role,
 		Tenant:        tenant,
 		TenantPackage: tenantPackage,
+		TwoFactor:     twoFactor,
 		User:          user,
 		SmsChannel:    smsChannel,
 		SmsTemplate:   smsTemplate,
@@ -0,0 +1,122 @@
+package system
+
+import (
+	system2 "github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/errors"
+	"github.com/wxlbd/admin-go/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuth2OpenHandler OAuth2 授权服务器，与 Java OAuth2OpenController 对齐
+// 授权接口需要用户登录；令牌接口通过 HTTP Basic 或 client_id/client_secret 参数认证客户端
+type OAuth2OpenHandler struct {
+	svc *system.OAuth2GrantService
+}
+
+func NewOAuth2OpenHandler(svc *system.OAuth2GrantService) *OAuth2OpenHandler {
+	return &OAuth2OpenHandler{svc: svc}
+}
+
+// GetAuthorize 获得授权页信息
+// @Router /system/oauth2/authorize [get]
+func (h *OAuth2OpenHandler) GetAuthorize(c *gin.Context) {
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	clientId := c.Query("clientId")
+	if clientId == "" {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	resp, err := h.svc.GetAuthorizeInfo(c.Request.Context(), loginUser.UserID, loginUser.UserType, clientId)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// Authorize 申请授权，返回重定向地址
+// @Router /system/oauth2/authorize [post]
+func (h *OAuth2OpenHandler) Authorize(c *gin.Context) {
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	var r system2.OAuth2OpenAuthorizeReq
+	if err := c.ShouldBind(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	redirectUri, err := h.svc.Authorize(c.Request.Context(), loginUser.UserID, loginUser.UserType, loginUser.TenantID, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, redirectUri)
+}
+
+// Token 获得访问令牌
+// @Router /system/oauth2/token [post]
+func (h *OAuth2OpenHandler) Token(c *gin.Context) {
+	var r system2.OAuth2OpenTokenReq
+	if err := c.ShouldBind(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	clientId, clientSecret := obtainClientCredentials(c)
+	resp, err := h.svc.GrantToken(c.Request.Context(), clientId, clientSecret, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// CheckToken 校验访问令牌
+// @Router /system/oauth2/check-token [post]
+func (h *OAuth2OpenHandler) CheckToken(c *gin.Context) {
+	var r system2.OAuth2OpenTokenParamReq
+	if err := c.ShouldBind(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	clientId, clientSecret := obtainClientCredentials(c)
+	resp, err := h.svc.CheckToken(c.Request.Context(), clientId, clientSecret, r.Token)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// RevokeToken 撤销令牌
+// @Router /system/oauth2/revoke [post]
+func (h *OAuth2OpenHandler) RevokeToken(c *gin.Context) {
+	var r system2.OAuth2OpenTokenParamReq
+	if err := c.ShouldBind(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	clientId, clientSecret := obtainClientCredentials(c)
+	revoked, err := h.svc.RevokeToken(c.Request.Context(), clientId, clientSecret, r.Token)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, revoked)
+}
+
+// obtainClientCredentials 获取客户端凭证，优先 HTTP Basic，其次 client_id/client_secret 参数
+func obtainClientCredentials(c *gin.Context) (string, string) {
+	if clientId, clientSecret, ok := c.Request.BasicAuth(); ok {
+		return clientId, clientSecret
+	}
+	return c.PostForm("client_id"), c.PostForm("client_secret")
+}
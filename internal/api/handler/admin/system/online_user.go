@@ -0,0 +1,81 @@
+package system
+
+import (
+	"strconv"
+
+	system2 "github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/errors"
+	"github.com/wxlbd/admin-go/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OnlineUserHandler 在线用户处理器
+type OnlineUserHandler struct {
+	svc *system.OnlineUserService
+}
+
+func NewOnlineUserHandler(svc *system.OnlineUserService) *OnlineUserHandler {
+	return &OnlineUserHandler{svc: svc}
+}
+
+// GetOnlineUserPage 获得在线用户分页
+func (h *OnlineUserHandler) GetOnlineUserPage(c *gin.Context) {
+	var r system2.OnlineUserPageReq
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	page, err := h.svc.GetOnlineUserPage(c.Request.Context(), loginUser.TenantID, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, page)
+}
+
+// ForceLogout 强制退出指定会话
+func (h *OnlineUserHandler) ForceLogout(c *gin.Context) {
+	id := c.Query("id")
+	if id == "" {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	if err := h.svc.ForceLogout(c.Request.Context(), loginUser.TenantID, id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// ForceLogoutUser 强制退出用户的全部会话
+func (h *OnlineUserHandler) ForceLogoutUser(c *gin.Context) {
+	userId, _ := strconv.ParseInt(c.Query("userId"), 10, 64)
+	if userId == 0 {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	count, err := h.svc.ForceLogoutUser(c.Request.Context(), loginUser.TenantID, userId)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, count)
+}
@@ -37,20 +37,21 @@ func (h *OperateLogHandler) GetOperateLogPage(c *gin.Context) {
 	list := make([]system2.OperateLogResp, len(pageResult.List))
 	for i, log := range pageResult.List {
 		list[i] = system2.OperateLogResp{
-			ID:            log.ID,
-			TraceID:       log.TraceID,
-			UserID:        log.UserID,
-			UserName:      "", // TODO: Join with user table to get name
-			Type:          log.Type,
-			SubType:       log.SubType,
-			BizID:         log.BizID,
-			Action:        log.Action,
-			Extra:         log.Extra,
-			RequestMethod: log.RequestMethod,
-			RequestURL:    log.RequestURL,
-			UserIP:        log.UserIP,
-			UserAgent:     log.UserAgent,
-			CreateTime:    log.CreateTime,
+			ID:             log.ID,
+			TraceID:        log.TraceID,
+			UserID:         log.UserID,
+			UserName:       "", // TODO: Join with user table to get name
+			ImpersonatorID: log.ImpersonatorID,
+			Type:           log.Type,
+			SubType:        log.SubType,
+			BizID:          log.BizID,
+			Action:         log.Action,
+			Extra:          log.Extra,
+			RequestMethod:  log.RequestMethod,
+			RequestURL:     log.RequestURL,
+			UserIP:         log.UserIP,
+			UserAgent:      log.UserAgent,
+			CreateTime:     log.CreateTime,
 		}
 	}
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	system2 "github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/model"
 	"github.com/wxlbd/admin-go/internal/service/system"
 
 	"github.com/wxlbd/admin-go/pkg/errors"
@@ -76,7 +77,9 @@ func (h *PermissionHandler) AssignRoleDataScope(c *gin.Context) {
 		response.WriteBizError(c, errors.ErrParam)
 		return
 	}
-	if err := h.svc.AssignRoleDataScope(c.Request.Context(), r.RoleID, r.DataScope, r.DataScopeDeptIDs); err != nil {
+	if err := h.svc.AssignRoleDataScope(c.Request.Context(), r.RoleID, r.DataScope, r.DataScopeDeptIDs, model.RoleDataScopeConfig{
+		DeptDepth: r.DeptDepth,
+	}); err != nil {
 		response.WriteBizError(c, err)
 		return
 	}
@@ -111,6 +111,34 @@ func (h *TenantHandler) GetTenant(c *gin.Context) {
 	response.WriteSuccess(c, item)
 }
 
+// GetTenantUsage 获得租户资源使用情况
+// @Router /system/tenant/get-usage [get]
+func (h *TenantHandler) GetTenantUsage(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	usage, err := h.svc.GetTenantUsage(c.Request.Context(), id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, usage)
+}
+
+// CloneTenant 复制租户数据
+// @Router /system/tenant/clone [post]
+func (h *TenantHandler) CloneTenant(c *gin.Context) {
+	var r system2.TenantCloneReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	resp, err := h.svc.CloneTenant(c.Request.Context(), &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
 // GetTenantPage 获得租户分页
 // @Router /system/tenant/page [get]
 func (h *TenantHandler) GetTenantPage(c *gin.Context) {
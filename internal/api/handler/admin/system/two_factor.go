@@ -0,0 +1,154 @@
+package system
+
+import (
+	"strconv"
+
+	system2 "github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/errors"
+	"github.com/wxlbd/admin-go/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorHandler 二次验证处理器：当前用户自助管理，以及管理员重置与租户策略配置
+type TwoFactorHandler struct {
+	svc *system.TwoFactorService
+}
+
+func NewTwoFactorHandler(svc *system.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{svc: svc}
+}
+
+// GetTwoFactor 获得当前用户的二次验证状态
+func (h *TwoFactorHandler) GetTwoFactor(c *gin.Context) {
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	resp, err := h.svc.GetStatus(c.Request.Context(), loginUser.UserID)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// SetupTwoFactor 生成验证器绑定密钥
+func (h *TwoFactorHandler) SetupTwoFactor(c *gin.Context) {
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	resp, err := h.svc.Setup(c.Request.Context(), loginUser.UserID)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// EnableTwoFactor 校验验证码并开启二次验证
+func (h *TwoFactorHandler) EnableTwoFactor(c *gin.Context) {
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	var r system2.TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	codes, err := h.svc.Enable(c.Request.Context(), loginUser.UserID, r.Code)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, &system2.TwoFactorRecoveryCodesResp{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor 校验验证码并关闭二次验证
+func (h *TwoFactorHandler) DisableTwoFactor(c *gin.Context) {
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	var r system2.TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if err := h.svc.Disable(c.Request.Context(), loginUser.UserID, r.Code); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	var r system2.TwoFactorCodeReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	codes, err := h.svc.RegenerateRecoveryCodes(c.Request.Context(), loginUser.UserID, r.Code)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, &system2.TwoFactorRecoveryCodesResp{RecoveryCodes: codes})
+}
+
+// ResetTwoFactor 管理员重置用户的二次验证
+func (h *TwoFactorHandler) ResetTwoFactor(c *gin.Context) {
+	userId, _ := strconv.ParseInt(c.Query("userId"), 10, 64)
+	if userId == 0 {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if err := h.svc.Reset(c.Request.Context(), userId); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// GetTenantPolicy 获得租户的二次验证策略
+func (h *TwoFactorHandler) GetTenantPolicy(c *gin.Context) {
+	tenantId, _ := strconv.ParseInt(c.Query("tenantId"), 10, 64)
+	if tenantId == 0 {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	resp, err := h.svc.GetTenantPolicy(c.Request.Context(), tenantId)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// UpdateTenantPolicy 更新租户的二次验证策略
+func (h *TwoFactorHandler) UpdateTenantPolicy(c *gin.Context) {
+	var r system2.TenantTwoFactorPolicyReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if err := h.svc.UpdateTenantPolicy(c.Request.Context(), &r); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
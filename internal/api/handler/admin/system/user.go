@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	system2 "github.com/wxlbd/admin-go/internal/api/contract/admin/system"
 	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/context"
 	"github.com/wxlbd/admin-go/pkg/utils"
 	"github.com/xuri/excelize/v2"
 
@@ -15,12 +16,14 @@ import (
 )
 
 type UserHandler struct {
-	svc *system.UserService
+	svc           *system.UserService
+	loginLimitSvc *system.LoginLimitService
 }
 
-func NewUserHandler(svc *system.UserService) *UserHandler {
+func NewUserHandler(svc *system.UserService, loginLimitSvc *system.LoginLimitService) *UserHandler {
 	return &UserHandler{
-		svc: svc,
+		svc:           svc,
+		loginLimitSvc: loginLimitSvc,
 	}
 }
 
@@ -118,6 +121,35 @@ func (h *UserHandler) UpdateUserStatus(c *gin.Context) {
 	response.WriteSuccess(c, true)
 }
 
+// GetUserLoginLock 获得用户登录锁定状态
+func (h *UserHandler) GetUserLoginLock(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	if id == 0 {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	resp, err := h.loginLimitSvc.GetUserLoginLock(c.Request.Context(), id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// ClearUserLoginLock 解除用户登录锁定
+func (h *UserHandler) ClearUserLoginLock(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	if id == 0 {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if err := h.loginLimitSvc.ClearUserLoginLock(c.Request.Context(), id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
 func (h *UserHandler) GetSimpleUserList(c *gin.Context) {
 	list, err := h.svc.GetSimpleUserList(c.Request.Context())
 	if err != nil {
@@ -154,6 +186,25 @@ func (h *UserHandler) UpdateUserPassword(c *gin.Context) {
 	response.WriteSuccess(c, true)
 }
 
+// UpdateUserProfilePassword 修改本人密码
+func (h *UserHandler) UpdateUserProfilePassword(c *gin.Context) {
+	var r system2.UserProfileUpdatePasswordReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	loginUser := context.GetLoginUser(c)
+	if loginUser == nil {
+		response.WriteBizError(c, errors.ErrUnauthorized)
+		return
+	}
+	if err := h.svc.UpdateUserProfilePassword(c.Request.Context(), loginUser.UserID, &r); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
 // ExportUser 导出用户
 // @Router /system/user/export [get]
 func (h *UserHandler) ExportUser(c *gin.Context) {
@@ -271,9 +322,8 @@ func (h *UserHandler) ImportUser(c *gin.Context) {
 		response.WriteBizError(c, errors.ErrParam)
 		return
 	}
-	// updateSupport, _ := strconv.ParseBool(c.Query("updateSupport")) // TODO: Use updateSupport
+	updateSupport, _ := strconv.ParseBool(c.Query("updateSupport"))
 
-	// Verify Excel file
 	f, err := file.Open()
 	if err != nil {
 		response.WriteBizError(c, err)
@@ -281,24 +331,43 @@ func (h *UserHandler) ImportUser(c *gin.Context) {
 	}
 	defer f.Close()
 
-	// Parse Excel (Simplified for now - strictly mocking Success response as per step 1)
-	// Real implementation would read Stream, parse rows, call Service for each or batch.
-
-	// Mock response structure for strictly adhering to API signature first.
-	// Java returns UserImportRespVO
-	respVO := system2.UserImportRespVO{
-		CreateUsernames:  []string{},
-		UpdateUsernames:  []string{},
-		FailureUsernames: map[string]string{},
+	excelFile, err := excelize.OpenReader(f)
+	if err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
 	}
+	defer func() { _ = excelFile.Close() }()
 
-	// Logic would go here:
-	// excelFile, _ := excelize.OpenReader(f)
-	// rows, _ := excelFile.GetRows("Sheet1")
-	// ... processing ...
-
-	// Since logic is complex (transactional import), we mark as TODO but return valid structure.
-	// User asked for "Implement POST /user/import API". Parity means input/output match.
-
-	response.WriteSuccess(c, respVO)
+	// 读取第一个工作表，首行为表头，列顺序与导入模板一致
+	rows, err := excelFile.GetRows(excelFile.GetSheetName(0))
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	list := make([]system2.UserImportExcelVO, 0, len(rows))
+	for _, row := range rows[min(1, len(rows)):] {
+		cell := func(i int) string {
+			if i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+		deptId, _ := strconv.ParseInt(cell(6), 10, 64)
+		list = append(list, system2.UserImportExcelVO{
+			Username: cell(0),
+			Nickname: cell(1),
+			Email:    cell(2),
+			Mobile:   cell(3),
+			Sex:      cell(4),
+			Status:   cell(5),
+			DeptID:   deptId,
+		})
+	}
+
+	resp, err := h.svc.ImportUserList(c.Request.Context(), list, updateSupport)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
 }
@@ -8,18 +8,24 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/wxlbd/admin-go/internal/api/handler/admin"
 	"github.com/wxlbd/admin-go/internal/middleware"
+	"github.com/wxlbd/admin-go/internal/pkg/permission"
 	"gorm.io/gorm"
 )
 
 func InitRouter(db *gorm.DB, rdb *redis.Client,
 	adminHandlers *admin.AdminHandlers,
 	casbinMiddleware *middleware.CasbinMiddleware,
+	operateLogMiddleware *middleware.OperateLogMiddleware,
+	accessLogMiddleware *middleware.APIAccessLogMiddleware,
+	errorLogMiddleware *middleware.APIErrorLogMiddleware,
+	tenantMiddleware *middleware.TenantMiddleware,
+	policySyncer *permission.PolicySyncer,
 ) *gin.Engine {
 	// Debug log to confirm router init
 	fmt.Println("Initializing Router...")
 	r := gin.New()
-	r.Use(middleware.Recovery())
-	r.Use(middleware.ErrorHandler())
+	r.Use(errorLogMiddleware.Recovery())
+	r.Use(errorLogMiddleware.ErrorHandler())
 	r.Use(cors.New(cors.Config{
 		AllowAllOrigins: true,
 		AllowMethods:    []string{"*"},
@@ -28,6 +34,10 @@ func InitRouter(db *gorm.DB, rdb *redis.Client,
 	r.Use(gin.Logger())
 	// 注入 gin.Context 到 request context，供 GORM Hook 使用
 	r.Use(middleware.InjectContext())
+	// 解析当前请求的租户
+	r.Use(tenantMiddleware.Handle())
+	// API 访问日志
+	r.Use(accessLogMiddleware.Handle())
 
 	// 基础路由
 	r.GET("/ping", func(c *gin.Context) {
@@ -35,6 +45,11 @@ func InitRouter(db *gorm.DB, rdb *redis.Client,
 			"message": "pong",
 		})
 	})
+	// 本实例持有的权限策略版本，用于排查多实例间的策略不一致
+	// 包含实例标识及策略数量，需登录并拥有权限，未分配该权限时仅超级管理员可访问
+	r.GET("/health/permission", middleware.Auth(), casbinMiddleware.RequirePermission("system:permission:status"), func(c *gin.Context) {
+		c.JSON(200, policySyncer.Status(c.Request.Context()))
+	})
 
 	// ========== 模块化路由注册 ==========
 
@@ -43,7 +58,7 @@ func InitRouter(db *gorm.DB, rdb *redis.Client,
 	r.GET("/infra/ws", adminHandlers.Infra.WebSocket.Handle) // Corrected WebSocketHandler reference
 
 	// System 模块
-	RegisterSystemRoutes(r, adminHandlers.System, adminHandlers.Infra, casbinMiddleware)
+	RegisterSystemRoutes(r, adminHandlers.System, adminHandlers.Infra, casbinMiddleware, operateLogMiddleware)
 
 	// Area 地区路由
 	RegisterAreaRoutes(r, adminHandlers.System.Area)
@@ -12,6 +12,7 @@ func RegisterSystemRoutes(engine *gin.Engine,
 	handlers *system.Handlers,
 	infraHandlers *infra.Handlers,
 	casbinMiddleware *middleware.CasbinMiddleware,
+	operateLogMiddleware *middleware.OperateLogMiddleware,
 ) {
 	api := engine.Group("/admin-api")
 	{
@@ -30,6 +31,23 @@ func RegisterSystemRoutes(engine *gin.Engine,
 				authGroup.POST("/reset-password", handlers.Auth.ResetPassword)
 				authGroup.GET("/social-auth-redirect", handlers.Auth.SocialAuthRedirect)
 				authGroup.POST("/social-login", handlers.Auth.SocialLogin)
+				authGroup.POST("/two-factor-login", handlers.Auth.TwoFactorLogin)
+				authGroup.POST("/two-factor-setup", handlers.Auth.TwoFactorSetup)
+			}
+
+			// Captcha Public Routes
+			captchaGroup := systemGroup.Group("/captcha")
+			{
+				captchaGroup.POST("/get", handlers.Captcha.GetCaptcha)
+				captchaGroup.POST("/check", handlers.Captcha.CheckCaptcha)
+			}
+
+			// OAuth2 Public Routes (客户端认证)
+			oauth2PublicGroup := systemGroup.Group("/oauth2")
+			{
+				oauth2PublicGroup.POST("/token", handlers.OAuth2Open.Token)
+				oauth2PublicGroup.POST("/check-token", handlers.OAuth2Open.CheckToken)
+				oauth2PublicGroup.POST("/revoke", handlers.OAuth2Open.RevokeToken)
 			}
 
 			// Tenant Public Routes
@@ -90,11 +108,42 @@ func RegisterSystemRoutes(engine *gin.Engine,
 			// ====== Protected Routes (Auth Required) ======
 			// Apply Auth Middleware to all subsequent system routes
 			systemGroup.Use(middleware.Auth())
+			// 记录写操作的操作日志
+			systemGroup.Use(operateLogMiddleware.Handle())
 
 			// Auth Protected Routes
 			authProtectedGroup := systemGroup.Group("/auth")
 			{
 				authProtectedGroup.GET("/get-permission-info", handlers.Auth.GetPermissionInfo)
+				authProtectedGroup.POST("/visit-tenant", casbinMiddleware.RequirePermission("system:tenant:visit"), handlers.Auth.VisitTenant)
+			}
+
+			// OAuth2 Authorize Routes (用户授权)
+			oauth2Group := systemGroup.Group("/oauth2")
+			{
+				oauth2Group.GET("/authorize", handlers.OAuth2Open.GetAuthorize)
+				oauth2Group.POST("/authorize", handlers.OAuth2Open.Authorize)
+			}
+
+			// Online User Routes
+			onlineUserGroup := systemGroup.Group("/online-user")
+			{
+				onlineUserGroup.GET("/page", casbinMiddleware.RequirePermission("system:online-user:query"), handlers.OnlineUser.GetOnlineUserPage)
+				onlineUserGroup.DELETE("/delete", casbinMiddleware.RequirePermission("system:online-user:delete"), handlers.OnlineUser.ForceLogout)
+				onlineUserGroup.DELETE("/delete-by-user", casbinMiddleware.RequirePermission("system:online-user:delete"), handlers.OnlineUser.ForceLogoutUser)
+			}
+
+			// Two Factor Routes
+			twoFactorGroup := systemGroup.Group("/two-factor")
+			{
+				twoFactorGroup.GET("/get", handlers.TwoFactor.GetTwoFactor)
+				twoFactorGroup.POST("/setup", handlers.TwoFactor.SetupTwoFactor)
+				twoFactorGroup.POST("/enable", handlers.TwoFactor.EnableTwoFactor)
+				twoFactorGroup.POST("/disable", handlers.TwoFactor.DisableTwoFactor)
+				twoFactorGroup.POST("/regenerate-recovery-codes", handlers.TwoFactor.RegenerateRecoveryCodes)
+				twoFactorGroup.PUT("/reset", casbinMiddleware.RequirePermission("system:user:update"), handlers.TwoFactor.ResetTwoFactor)
+				twoFactorGroup.GET("/get-tenant-policy", casbinMiddleware.RequirePermission("system:tenant:query"), handlers.TwoFactor.GetTenantPolicy)
+				twoFactorGroup.PUT("/update-tenant-policy", casbinMiddleware.RequirePermission("system:tenant:update"), handlers.TwoFactor.UpdateTenantPolicy)
 			}
 
 			// Tenant Protected Routes
@@ -102,9 +151,11 @@ func RegisterSystemRoutes(engine *gin.Engine,
 			{
 				tenantProtectedGroup.POST("/create", casbinMiddleware.RequirePermission("system:tenant:create"), handlers.Tenant.CreateTenant)
 				tenantProtectedGroup.PUT("/update", casbinMiddleware.RequirePermission("system:tenant:update"), handlers.Tenant.UpdateTenant)
+				tenantProtectedGroup.POST("/clone", casbinMiddleware.RequirePermission("system:tenant:update"), handlers.Tenant.CloneTenant)
 				tenantProtectedGroup.DELETE("/delete", casbinMiddleware.RequirePermission("system:tenant:delete"), handlers.Tenant.DeleteTenant)
 				tenantProtectedGroup.DELETE("/delete-list", casbinMiddleware.RequirePermission("system:tenant:delete"), handlers.Tenant.DeleteTenantList)
 				tenantProtectedGroup.GET("/get", casbinMiddleware.RequirePermission("system:tenant:query"), handlers.Tenant.GetTenant)
+				tenantProtectedGroup.GET("/get-usage", casbinMiddleware.RequirePermission("system:tenant:query"), handlers.Tenant.GetTenantUsage)
 				tenantProtectedGroup.GET("/page", casbinMiddleware.RequirePermission("system:tenant:query"), handlers.Tenant.GetTenantPage)
 				tenantProtectedGroup.GET("/export-excel", casbinMiddleware.RequirePermission("system:tenant:export"), handlers.Tenant.ExportTenantExcel)
 			}
@@ -173,6 +224,8 @@ func RegisterSystemRoutes(engine *gin.Engine,
 				userGroup.DELETE("/delete-list", casbinMiddleware.RequirePermission("system:user:delete"), handlers.User.DeleteUserList)
 				userGroup.PUT("/update-status", casbinMiddleware.RequirePermission("system:user:update"), handlers.User.UpdateUserStatus)
 				userGroup.PUT("/update-password", casbinMiddleware.RequirePermission("system:user:update-password"), handlers.User.UpdateUserPassword)
+				userGroup.GET("/get-login-lock", casbinMiddleware.RequirePermission("system:user:query"), handlers.User.GetUserLoginLock)
+				userGroup.PUT("/clear-login-lock", casbinMiddleware.RequirePermission("system:user:update"), handlers.User.ClearUserLoginLock)
 				userGroup.GET("/export", casbinMiddleware.RequirePermission("system:user:export"), handlers.User.ExportUser)
 				userGroup.GET("/get-import-template", casbinMiddleware.RequirePermission("system:user:import"), handlers.User.GetImportTemplate)
 				userGroup.POST("/import", casbinMiddleware.RequirePermission("system:user:import"), handlers.User.ImportUser)
@@ -181,7 +234,7 @@ func RegisterSystemRoutes(engine *gin.Engine,
 				userProtectedGroup.Use(middleware.Auth())
 				{
 					// userProtectedGroup.PUT("/profile/update", handlers.User.UpdateUserProfile)
-					// userProtectedGroup.PUT("/profile/update-password", handlers.User.UpdateUserProfilePassword)
+					userProtectedGroup.PUT("/profile/update-password", handlers.User.UpdateUserProfilePassword)
 					// userProtectedGroup.GET("/profile/get", handlers.User.GetUserProfile)
 				}
 			}
@@ -352,7 +405,7 @@ func RegisterSystemRoutes(engine *gin.Engine,
 		}
 
 		// ====== Infra Routes (Protected) ======
-		infraGroup := api.Group("/infra", middleware.Auth())
+		infraGroup := api.Group("/infra", middleware.Auth(), operateLogMiddleware.Handle())
 		{
 			// WebSocket (对齐 Java /infra/ws)
 			infraGroup.GET("/ws", infraHandlers.WebSocket.Handle)
@@ -390,6 +443,8 @@ func RegisterSystemRoutes(engine *gin.Engine,
 				jobGroup.GET("/get", casbinMiddleware.RequirePermission("infra:job:query"), infraHandlers.Job.GetJob)
 				jobGroup.GET("/page", casbinMiddleware.RequirePermission("infra:job:query"), infraHandlers.Job.GetJobPage)
 				jobGroup.PUT("/trigger", casbinMiddleware.RequirePermission("infra:job:trigger"), infraHandlers.Job.TriggerJob)
+				jobGroup.GET("/execution-list", casbinMiddleware.RequirePermission("infra:job:query"), infraHandlers.Job.GetJobExecutionList)
+				jobGroup.PUT("/cancel-execution", casbinMiddleware.RequirePermission("infra:job:trigger"), infraHandlers.Job.CancelJobExecution)
 				jobGroup.POST("/sync", casbinMiddleware.RequirePermission("infra:job:create"), infraHandlers.Job.SyncJob)
 				jobGroup.GET("/export-excel", casbinMiddleware.RequirePermission("infra:job:export"), infraHandlers.Job.ExportJobExcel)
 				jobGroup.GET("/get_next_times", casbinMiddleware.RequirePermission("infra:job:query"), infraHandlers.Job.GetJobNextTimes)
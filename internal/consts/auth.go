@@ -7,8 +7,9 @@ const (
 	LoginLogTypeMobile   = 103 // 使用手机登录
 	LoginLogTypeSms      = 104 // 使用短信登录
 
-	LogoutLogTypeSelf   = 200 // 主动登出
-	LogoutLogTypeDelete = 202 // 强制退出
+	LogoutLogTypeSelf         = 200 // 主动登出
+	LogoutLogTypeDelete       = 202 // 强制退出
+	LogoutLogTypeTokenRevoked = 203 // 刷新令牌被重复使用，吊销令牌
 )
 
 // LoginResultEnum 登录结果
@@ -18,6 +19,8 @@ const (
 	LoginResultUserDisabled     = 20 // 用户被禁用
 	LoginResultCaptchaNotFound  = 30 // 验证码不存在
 	LoginResultCaptchaCodeError = 31 // 验证码不正确
+	LoginResultLocked           = 40 // 登录失败次数过多，账号或 IP 被锁定
+	LoginResultTokenReused      = 50 // 刷新令牌被重复使用，疑似泄露
 )
 
 // UserType 用户类型枚举
@@ -29,6 +32,8 @@ const (
 	UserTypeAdmin = 2
 	// UserTypeUnknown 未知用户类型（用于默认值或错误处理）
 	UserTypeUnknown = 0
+	// UserTypeClient OAuth2 客户端（client_credentials 模式签发的令牌，不关联用户）
+	UserTypeClient = 3
 )
 
 // UserTypeNames 用户类型名称映射
@@ -36,6 +41,7 @@ var UserTypeNames = map[int]string{
 	UserTypeUnknown: "未知",
 	UserTypeMember:  "会员",
 	UserTypeAdmin:   "管理员",
+	UserTypeClient:  "客户端",
 }
 
 // GetUserTypeName 获取用户类型名称
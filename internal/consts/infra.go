@@ -0,0 +1,9 @@
+package consts
+
+// ApiErrorLogProcessStatus API 错误日志处理状态
+// 对应 Java: ApiErrorLogProcessStatusEnum
+const (
+	ApiErrorLogProcessStatusInit   = 0 // 未处理
+	ApiErrorLogProcessStatusDone   = 1 // 已处理
+	ApiErrorLogProcessStatusIgnore = 2 // 已忽略
+)
@@ -0,0 +1,19 @@
+package consts
+
+// OAuth2GrantTypeEnum OAuth2 授权类型 (对齐 Java: OAuth2GrantTypeEnum)
+const (
+	OAuth2GrantTypeAuthorizationCode = "authorization_code" // 授权码模式
+	OAuth2GrantTypeImplicit          = "implicit"           // 简化模式
+	OAuth2GrantTypePassword          = "password"           // 密码模式
+	OAuth2GrantTypeClientCredentials = "client_credentials" // 客户端模式
+	OAuth2GrantTypeRefreshToken      = "refresh_token"      // 刷新模式
+)
+
+// OAuth2 默认客户端，后台账号登录签发的令牌归属该客户端
+const OAuth2ClientIDDefault = "default"
+
+// PKCE code_challenge_method
+const (
+	OAuth2CodeChallengeMethodPlain = "plain"
+	OAuth2CodeChallengeMethodS256  = "S256"
+)
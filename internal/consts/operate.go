@@ -0,0 +1,31 @@
+package consts
+
+// OperateTypeEnum 操作分类 (对齐 Java: OperateTypeEnum)
+const (
+	OperateTypeOther  = 0 // 其它
+	OperateTypeGet    = 1 // 查询
+	OperateTypeCreate = 2 // 新增
+	OperateTypeUpdate = 3 // 修改
+	OperateTypeDelete = 4 // 删除
+	OperateTypeExport = 5 // 导出
+	OperateTypeImport = 6 // 导入
+)
+
+// OperateTypeNames 操作分类名称映射
+var OperateTypeNames = map[int]string{
+	OperateTypeOther:  "其它",
+	OperateTypeGet:    "查询",
+	OperateTypeCreate: "新增",
+	OperateTypeUpdate: "修改",
+	OperateTypeDelete: "删除",
+	OperateTypeExport: "导出",
+	OperateTypeImport: "导入",
+}
+
+// GetOperateTypeName 获取操作分类名称
+func GetOperateTypeName(operateType int) string {
+	if name, exists := OperateTypeNames[operateType]; exists {
+		return name
+	}
+	return OperateTypeNames[OperateTypeOther]
+}
@@ -22,6 +22,10 @@ const (
 	DataScopeDeptAndChild = 4
 	// DataScopeSelf 仅本人数据权限
 	DataScopeSelf = 5
+	// DataScopeSamePost 同岗位数据权限，与本人担任相同岗位的用户的数据
+	DataScopeSamePost = 6
+	// DataScopeDeptAndChildDepth 本部门及以下 N 级数据权限，N 由角色的数据权限配置指定
+	DataScopeDeptAndChildDepth = 7
 )
 
 // RoleCodeEnum 角色编码枚举
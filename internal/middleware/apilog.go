@@ -3,127 +3,224 @@ package middleware
 import (
 	"bytes"
 	"io"
-	"log"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/service/infra"
+	"github.com/wxlbd/admin-go/pkg/config"
 	"github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// APIAccessLog API 访问日志记录，与 Java 的 ApiAccessLogFilter 对齐
-type APIAccessLog struct {
-	RequestID     string    `json:"requestId"`
-	UserID        int64     `json:"userId"`
-	URI           string    `json:"uri"`
-	Method        string    `json:"method"`
-	RequestParams string    `json:"requestParams"`
-	RequestBody   string    `json:"requestBody"`
-	ResponseBody  string    `json:"responseBody"`
-	ResponseCode  int       `json:"responseCode"`
-	Duration      int64     `json:"duration"` // 毫秒
-	CreateTime    time.Time `json:"createTime"`
-	UserAgent     string    `json:"userAgent"`
-	ClientIP      string    `json:"clientIp"`
-}
-
-// APIAccessLogMiddleware API 访问日志中间件
-func APIAccessLogMiddleware() gin.HandlerFunc {
+const (
+	// apiAccessLogMaxBodyLength 请求参数、响应结果最大记录长度
+	apiAccessLogMaxBodyLength = 4000
+	// apiLogMaxCaptureLength 请求体、响应体最大拦截长度，超出时无法完整解析脱敏，不记录内容
+	apiLogMaxCaptureLength = 64 << 10
+	// ctxRequestBodyKey 已读取的请求体
+	ctxRequestBodyKey = "requestBody"
+
+	requestBodyTooLarge  = "<请求体超过 64KB，未记录>"
+	responseBodyTooLarge = "<响应体超过 64KB，未记录>"
+)
+
+// APIAccessLogMiddleware API 访问日志中间件，与 Java 的 ApiAccessLogFilter 对齐
+// 记录所有管理后台 API 请求，敏感字段脱敏后通过有界队列批量落库
+type APIAccessLogMiddleware struct {
+	svc    *infra.ApiAccessLogService
+	masker *sensitiveMasker
+}
+
+func NewAPIAccessLogMiddleware(svc *infra.ApiAccessLogService) *APIAccessLogMiddleware {
+	return &APIAccessLogMiddleware{
+		svc:    svc,
+		masker: newSensitiveMasker(config.C.APILog),
+	}
+}
+
+// Handle 记录 API 访问日志，需注册在全局中间件中
+func (m *APIAccessLogMiddleware) Handle() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		startTime := time.Now()
-
-		// 读取请求体
-		var requestBody string
-		if c.Request.Body != nil {
-			bodyBytes, _ := io.ReadAll(c.Request.Body)
-			requestBody = string(bodyBytes)
-			// 重新设置请求体，以便后续处理
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+		if !strings.HasPrefix(c.Request.URL.Path, adminAPIPrefix) {
+			c.Next()
+			return
 		}
 
-		// 获取请求参数
-		requestParams := c.Request.URL.RawQuery
+		beginTime := time.Now()
+
+		requestBody := readRequestBody(c)
 
 		// 拦截响应体
-		responseWriter := &responseWriter{
-			ResponseWriter: c.Writer,
-			body:           bytes.NewBufferString(""),
-		}
-		c.Writer = responseWriter
+		writer := newResponseWriter(c.Writer, apiLogMaxCaptureLength)
+		c.Writer = writer
 
-		// 继续处理请求
 		c.Next()
 
-		// 计算耗时
-		duration := time.Since(startTime).Milliseconds()
-
-		// 获取登录用户信息
-		loginUser := context.GetLoginUser(c)
-		userID := int64(0)
-		if loginUser != nil {
-			userID = loginUser.UserID
+		endTime := time.Now()
+		module, name := resolveOperateModule(c)
+		resultCode, resultMsg := parseResult(writer.body.Bytes(), c.Writer.Status())
+
+		log := &model.InfraApiAccessLog{
+			TraceID:         getTraceID(c),
+			ApplicationName: config.C.App.Name,
+			RequestMethod:   c.Request.Method,
+			RequestURL:      utils.TruncateString(c.Request.URL.Path, 255),
+			RequestParams:   m.masker.requestParams(c, requestBody, apiAccessLogMaxBodyLength),
+			ResponseBody:    m.responseBody(writer),
+			UserIP:          c.ClientIP(),
+			UserAgent:       utils.TruncateString(c.Request.UserAgent(), 512),
+			OperateModule:   utils.TruncateString(module, 50),
+			OperateName:     utils.TruncateString(name, 50),
+			OperateType:     resolveOperateType(c.Request.Method, name),
+			BeginTime:       beginTime,
+			EndTime:         endTime,
+			Duration:        int(endTime.Sub(beginTime).Milliseconds()),
+			ResultCode:      resultCode,
+			ResultMsg:       resultMsg,
 		}
+		// 异步写入时没有 gin.Context，AuditPlugin 无法自动填充，这里手动设置
+		if loginUser := context.GetLoginUser(c); loginUser != nil {
+			log.UserID = loginUser.UserID
+			log.UserType = loginUser.UserType
+			log.ImpersonatorID = loginUser.ImpersonatorID
+			log.TenantID = loginUser.TenantID
+			log.Creator = strconv.FormatInt(loginUser.UserID, 10)
+			log.Updater = log.Creator
+		}
+
+		m.svc.CreateApiAccessLogAsync(log)
+	}
+}
 
-		// 构建日志
-		log := &APIAccessLog{
-			RequestID:     c.GetString("X-Request-ID"),
-			UserID:        userID,
-			URI:           c.Request.RequestURI,
-			Method:        c.Request.Method,
-			RequestParams: requestParams,
-			RequestBody:   sanitizeSensitiveData(requestBody),
-			ResponseBody:  sanitizeSensitiveData(responseWriter.body.String()),
-			ResponseCode:  c.Writer.Status(),
-			Duration:      duration,
-			CreateTime:    startTime,
-			UserAgent:     c.Request.UserAgent(),
-			ClientIP:      c.ClientIP(),
+// responseBody 脱敏后的响应体，被截断或未拦截（文件下载等）的响应不记录内容
+func (m *APIAccessLogMiddleware) responseBody(writer *responseWriter) string {
+	if writer.truncated {
+		return responseBodyTooLarge
+	}
+	return utils.TruncateString(m.masker.maskJSON(writer.body.String()), apiAccessLogMaxBodyLength)
+}
+
+// readRequestBody 读取请求体并重新设置，以便后续处理；文件上传不记录请求体
+// 至多读取 apiLogMaxCaptureLength 字节，超出时不记录内容，未读取的部分留给后续处理
+// 请求体已被 recordRequestBody 包装时，已被读取的部分从记录中获取
+// 同一请求内多个日志中间件共享读取结果
+func readRequestBody(c *gin.Context) string {
+	if body, ok := c.Get(ctxRequestBodyKey); ok {
+		return body.(string)
+	}
+	var requestBody string
+	if hasRequestBody(c) {
+		body := c.Request.Body
+		var consumed []byte
+		if recorder, ok := body.(*bodyRecorder); ok {
+			consumed = recorder.buf.Bytes()
+			body = recorder.ReadCloser
+		}
+		rest, _ := io.ReadAll(io.LimitReader(body, int64(apiLogMaxCaptureLength+1-len(consumed))))
+		c.Request.Body = bodyReadCloser{Reader: io.MultiReader(bytes.NewReader(rest), body), Closer: body}
+		requestBody = string(consumed) + string(rest)
+		if len(requestBody) > apiLogMaxCaptureLength {
+			requestBody = requestBodyTooLarge
 		}
+	}
+	c.Set(ctxRequestBodyKey, requestBody)
+	return requestBody
+}
 
-		// 异步记录日志（避免阻塞请求）
-		go recordAPIAccessLog(log)
+// recordRequestBody 包装请求体，记录后续处理读取的前 apiLogMaxCaptureLength 字节
+func recordRequestBody(c *gin.Context) {
+	if _, ok := c.Get(ctxRequestBodyKey); ok || !hasRequestBody(c) {
+		return
 	}
+	c.Request.Body = &bodyRecorder{ReadCloser: c.Request.Body}
 }
 
-// responseWriter 用于拦截响应体
+// hasRequestBody 是否有需要记录的请求体
+func hasRequestBody(c *gin.Context) bool {
+	return c.Request.Body != nil && c.Request.Body != http.NoBody && !strings.HasPrefix(c.ContentType(), "multipart/")
+}
+
+// bodyRecorder 记录已被读取的请求体，超出 apiLogMaxCaptureLength 的部分不再记录
+type bodyRecorder struct {
+	io.ReadCloser
+	buf bytes.Buffer
+}
+
+func (r *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if remain := apiLogMaxCaptureLength + 1 - r.buf.Len(); remain > 0 {
+		r.buf.Write(p[:min(n, remain)])
+	}
+	return n, err
+}
+
+// bodyReadCloser 拼接已读取的部分与剩余的请求体
+type bodyReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// responseWriter 用于拦截响应体，至多记录 limit 字节，limit 为 0 时不限制
+// 文件下载等非文本响应不拦截
 type responseWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body      *bytes.Buffer
+	limit     int
+	truncated bool // 响应体超出 limit，记录的内容不完整
+	checked   bool // 已根据响应头判断是否拦截
+	skipped   bool // 非文本响应，不拦截
 }
 
-func (w *responseWriter) Write(b []byte) (int, error) {
+func newResponseWriter(w gin.ResponseWriter, limit int) *responseWriter {
+	return &responseWriter{
+		ResponseWriter: w,
+		body:           bytes.NewBufferString(""),
+		limit:          limit,
+	}
+}
+
+// capture 记录响应体，超出 limit 的部分丢弃
+func (w *responseWriter) capture(b []byte) {
+	if !w.checked {
+		w.checked = true
+		w.skipped = !isTextResponse(w.Header())
+	}
+	if w.skipped {
+		return
+	}
+	if w.limit > 0 {
+		remain := w.limit - w.body.Len()
+		if len(b) > remain {
+			b = b[:max(remain, 0)]
+			w.truncated = true
+		}
+	}
 	w.body.Write(b)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.capture(b)
 	return w.ResponseWriter.Write(b)
 }
 
 func (w *responseWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
+	w.capture([]byte(s))
 	return w.ResponseWriter.WriteString(s)
 }
 
-// sanitizeSensitiveData 清理敏感数据（密码、token 等）
-func sanitizeSensitiveData(data string) string {
-	if data == "" {
-		return data
+// isTextResponse 是否为需要记录的文本响应，附件及二进制内容不记录
+func isTextResponse(header http.Header) bool {
+	if strings.HasPrefix(strings.ToLower(header.Get("Content-Disposition")), "attachment") {
+		return false
 	}
-
-	// 简单的敏感数据清理，可根据需要扩展
-	// 实际应用中应该使用正则表达式进行更复杂的替换
-	result := data
-	return result
-}
-
-// recordAPIAccessLog 记录 API 访问日志
-// 实际应用中应该将日志存储到数据库或日志系统
-func recordAPIAccessLog(accessLog *APIAccessLog) {
-	// TODO: 实现日志存储逻辑
-	// 可以存储到数据库、日志文件或日志系统（如 ELK）
-	logMessage := "API Access Log: " +
-		"UserID=" + string(rune(accessLog.UserID)) +
-		" Method=" + accessLog.Method +
-		" URI=" + accessLog.URI +
-		" ResponseCode=" + string(rune(int32(accessLog.ResponseCode))) +
-		" Duration=" + string(rune(accessLog.Duration)) + "ms"
-
-	log.Println(logMessage)
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType == "" {
+		return true
+	}
+	return strings.HasPrefix(contentType, "text/") || strings.Contains(contentType, "json") || strings.Contains(contentType, "xml")
 }
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadRequestBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"small", `{"id":1}`, `{"id":1}`},
+		{"too large", `{"data":"` + strings.Repeat("a", apiLogMaxCaptureLength) + `"}`, requestBodyTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged, handled string
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				logged = readRequestBody(c)
+				c.Next()
+			})
+			r.POST("/", func(c *gin.Context) {
+				data, _ := io.ReadAll(c.Request.Body)
+				handled = string(data)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+			if logged != tt.want {
+				t.Errorf("logged body = %.40q, want %.40q", logged, tt.want)
+			}
+			// 后续处理仍能读到完整的请求体
+			if handled != tt.body {
+				t.Errorf("handler read %d bytes, want %d", len(handled), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestResponseWriterSkipsAttachment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		write    func(c *gin.Context)
+		captured bool
+	}{
+		{"json", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"code": 0}) }, true},
+		{"attachment", func(c *gin.Context) {
+			c.Header("Content-Disposition", "attachment; filename=user.xlsx")
+			c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", []byte("PK"))
+		}, false},
+		{"binary", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte{0x89, 'P', 'N', 'G'}) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var writer *responseWriter
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				writer = newResponseWriter(c.Writer, apiLogMaxCaptureLength)
+				c.Writer = writer
+				c.Next()
+			})
+			r.GET("/", tt.write)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			if captured := writer.body.Len() > 0; captured != tt.captured {
+				t.Errorf("captured = %v, want %v", captured, tt.captured)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/service/system"
 	"github.com/wxlbd/admin-go/pkg/cache"
 	"github.com/wxlbd/admin-go/pkg/context"
 	"github.com/wxlbd/admin-go/pkg/response"
@@ -55,16 +57,27 @@ func Auth() gin.HandlerFunc {
 			}
 		}
 
-		// 3. 从 JWT Claims 构建 LoginUser（JWT 中已包含完整信息）
+		// 3. 客户端模式的令牌不关联用户，不能访问管理后台接口
+		if claims.UserType == consts.UserTypeClient {
+			c.AbortWithStatusJSON(401, response.Error(401, "客户端令牌不能访问该接口"))
+			return
+		}
+
+		// 4. 从 JWT Claims 构建 LoginUser（JWT 中已包含完整信息）
 		loginUser := &context.LoginUser{
 			UserID:   claims.UserID,
 			UserType: claims.UserType,
 			TenantID: claims.TenantID,
 			Nickname: claims.Nickname,
+
+			ImpersonatorID: claims.ImpersonatorID,
 		}
 
-		// 4. Set LoginUser to Context
+		// 5. Set LoginUser to Context
 		context.SetLoginUser(c, loginUser)
+
+		// 6. 更新在线会话的最后活跃时间
+		system.TouchOAuth2Session(c.Request.Context(), token)
 		c.Next()
 	}
 }
@@ -137,12 +150,20 @@ func OptionalAuth() gin.HandlerFunc {
 			}
 		}
 
-		// 3. Token 有效，设置用户信息
+		// 3. 客户端模式的令牌视为未登录
+		if claims.UserType == consts.UserTypeClient {
+			c.Next()
+			return
+		}
+
+		// 4. Token 有效，设置用户信息
 		loginUser := &context.LoginUser{
 			UserID:   claims.UserID,
 			UserType: claims.UserType,
 			TenantID: claims.TenantID,
 			Nickname: claims.Nickname,
+
+			ImpersonatorID: claims.ImpersonatorID,
 		}
 		context.SetLoginUser(c, loginUser)
 		c.Next()
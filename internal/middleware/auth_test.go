@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/pkg/cache"
+	"github.com/wxlbd/admin-go/pkg/utils"
+)
+
+func TestAuthRejectsClientToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	origin := cache.RDB
+	cache.RDB = nil
+	t.Cleanup(func() { cache.RDB = origin })
+
+	tests := []struct {
+		name     string
+		userType int
+		want     int
+	}{
+		{"admin", consts.UserTypeAdmin, http.StatusOK},
+		{"client", consts.UserTypeClient, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := utils.GenerateTokenWithClaims(utils.Claims{UserID: 1, UserType: tt.userType}, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := gin.New()
+			r.GET("/", Auth(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
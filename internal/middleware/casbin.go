@@ -1,9 +1,9 @@
 package middleware
 
 import (
-	"fmt"
 	"net/http"
 
+	"github.com/wxlbd/admin-go/internal/pkg/permission"
 	"github.com/wxlbd/admin-go/internal/service/system"
 	"github.com/wxlbd/admin-go/pkg/context"
 	"github.com/wxlbd/admin-go/pkg/response"
@@ -12,13 +12,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// ctxPermissionKey 当前路由要求的权限标识，供操作日志等后续中间件使用
+const ctxPermissionKey = "permission"
+
 // CasbinMiddleware Casbin 权限中间件
 type CasbinMiddleware struct {
-	enforcer *casbin.Enforcer
+	enforcer *casbin.SyncedEnforcer
 	permSvc  *system.PermissionService
 }
 
-func NewCasbinMiddleware(enforcer *casbin.Enforcer, permSvc *system.PermissionService) *CasbinMiddleware {
+func NewCasbinMiddleware(enforcer *casbin.SyncedEnforcer, permSvc *system.PermissionService) *CasbinMiddleware {
 	return &CasbinMiddleware{
 		enforcer: enforcer,
 		permSvc:  permSvc,
@@ -26,9 +29,11 @@ func NewCasbinMiddleware(enforcer *casbin.Enforcer, permSvc *system.PermissionSe
 }
 
 // RequirePermission 检查权限
-// permission: 权限字符串，如 system:user:query
-func (m *CasbinMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+// perm: 权限字符串，如 system:user:query
+func (m *CasbinMiddleware) RequirePermission(perm string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		c.Set(ctxPermissionKey, perm)
+
 		user := context.GetLoginUser(c)
 		if user == nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, response.Error(401, "未登录"))
@@ -48,13 +53,15 @@ func (m *CasbinMiddleware) RequirePermission(permission string) gin.HandlerFunc
 
 		// 2. Casbin 鉴权
 		// Subject: user:{userId}
+		// Domain: tenant:{tenantId}，使用登录用户所属的租户
 		// Object: permission
 		// Action: access
-		// 注意：Adapter 加载的 g 策略是 g, user:{userId}, role:{roleId}
-		// Adapter 加载的 p 策略是 p, role:{roleId}, permission, access
-		// Casbin 会自动推导 user -> role -> permission
-		sub := fmt.Sprintf("user:%d", user.UserID)
-		ok, err := m.enforcer.Enforce(sub, permission, "access")
+		// 注意：Adapter 加载的 g 策略是 g, user:{userId}, role:{roleId}, tenant:{tenantId}
+		// Adapter 加载的 p 策略是 p, role:{roleId}, tenant:{tenantId}, permission, access
+		// Casbin 会在租户域内推导 user -> role -> permission，并校验权限在租户套餐内
+		sub := permission.UserSubject(user.UserID)
+		dom := permission.TenantDomain(user.TenantID)
+		ok, err := m.enforcer.Enforce(sub, dom, perm, permission.PolicyActionAccess)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, response.Error(500, "权限校验错误"))
 			return
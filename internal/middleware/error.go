@@ -11,8 +11,10 @@ import (
 	"go.uber.org/zap"
 )
 
-// ErrorHandler 处理请求过程中的错误
-func ErrorHandler() gin.HandlerFunc {
+// ErrorHandler 处理请求过程中的错误，非业务异常记录到 API 错误日志
+// handler 已写入响应时（如 response.WriteBizError）不再重复写入
+// handler 未返回错误、但自行写入 5xx 响应的，同样记录到 API 错误日志
+func (m *APIErrorLogMiddleware) ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
 
@@ -22,17 +24,27 @@ func ErrorHandler() gin.HandlerFunc {
 
 			// 1. 如果是业务异常
 			if bizErr, ok := err.(*errors.BizError); ok {
-				c.JSON(http.StatusOK, response.Result[any]{
-					Code: bizErr.Code,
-					Msg:  bizErr.Msg,
-					Data: nil,
-				})
+				if !c.Writer.Written() {
+					c.JSON(http.StatusOK, response.Result[any]{
+						Code: bizErr.Code,
+						Msg:  bizErr.Msg,
+						Data: nil,
+					})
+				}
 				return
 			}
 
 			// 2. 如果是其他未知错误
 			logger.Error("internal server error", zap.Error(err), zap.String("path", c.Request.URL.Path))
-			c.JSON(http.StatusInternalServerError, response.Error(errors.ServerErrCode, "系统内部异常"))
+			m.record(c, newErrorException(c, err))
+			if !c.Writer.Written() {
+				c.JSON(http.StatusInternalServerError, response.Error(errors.ServerErrCode, "系统内部异常"))
+			}
+			return
+		}
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			m.record(c, newStatusException(c))
 		}
 	}
 }
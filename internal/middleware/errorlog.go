@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/service/infra"
+	"github.com/wxlbd/admin-go/pkg/config"
+	"github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/logger"
+	"github.com/wxlbd/admin-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// apiErrorLogMaxTextLength 异常消息、栈轨迹等 text 字段最大记录长度
+const apiErrorLogMaxTextLength = 16000
+
+// APIErrorLogMiddleware API 错误日志中间件，与 Java 的 GlobalExceptionHandler#createExceptionLog 对齐
+// 提供 Recovery 与 ErrorHandler，将 panic 及非业务异常记录到 infra_api_error_log
+type APIErrorLogMiddleware struct {
+	svc    *infra.ApiErrorLogService
+	masker *sensitiveMasker
+}
+
+func NewAPIErrorLogMiddleware(svc *infra.ApiErrorLogService) *APIErrorLogMiddleware {
+	return &APIErrorLogMiddleware{
+		svc:    svc,
+		masker: newSensitiveMasker(config.C.APILog),
+	}
+}
+
+// apiException 待记录的异常信息
+type apiException struct {
+	name             string
+	message          string
+	rootCauseMessage string
+	stackTrace       string
+	function         string // 异常发生的函数全名
+	file             string
+	line             int
+}
+
+// newPanicException 根据 panic 值构建异常信息，frame 为 panic 发生的位置
+func newPanicException(r any, stack string, frame runtime.Frame) *apiException {
+	ex := &apiException{
+		name:       fmt.Sprintf("%T", r),
+		message:    fmt.Sprint(r),
+		stackTrace: stack,
+		function:   frame.Function,
+		file:       frame.File,
+		line:       frame.Line,
+	}
+	ex.rootCauseMessage = ex.message
+	if err, ok := r.(error); ok {
+		ex.rootCauseMessage = rootCause(err).Error()
+	}
+	return ex
+}
+
+// newErrorException 根据 handler 返回的错误构建异常信息
+// error 不携带调用栈，栈轨迹记录错误链，发生位置取处理请求的 handler
+func newErrorException(c *gin.Context, err error) *apiException {
+	var chain strings.Builder
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		fmt.Fprintf(&chain, "%T: %s\n", e, e.Error())
+	}
+	return &apiException{
+		name:             fmt.Sprintf("%T", err),
+		message:          err.Error(),
+		rootCauseMessage: rootCause(err).Error(),
+		stackTrace:       chain.String(),
+		function:         c.HandlerName(),
+	}
+}
+
+// newStatusException 根据 handler 自行写入的 5xx 响应构建异常信息
+// 响应体被访问日志中间件拦截时，异常消息取统一响应结构中的 msg
+func newStatusException(c *gin.Context) *apiException {
+	status := c.Writer.Status()
+	message := fmt.Sprintf("HTTP %d %s", status, http.StatusText(status))
+	if writer, ok := c.Writer.(*responseWriter); ok {
+		if _, msg := parseResult(writer.body.Bytes(), status); msg != "" {
+			message = msg
+		}
+	}
+	return &apiException{
+		name:             fmt.Sprintf("HTTP %d", status),
+		message:          message,
+		rootCauseMessage: message,
+		function:         c.HandlerName(),
+	}
+}
+
+// rootCause 获取错误链最底层的错误
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+// panicFrame 获取 panic 发生的位置，需在 recover 所在的 defer 函数中直接调用
+func panicFrame() runtime.Frame {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	afterPanic := false
+	for {
+		frame, more := frames.Next()
+		if afterPanic && !strings.HasPrefix(frame.Function, "runtime.") {
+			return frame
+		}
+		if frame.Function == "runtime.gopanic" {
+			afterPanic = true
+		}
+		if !more {
+			return runtime.Frame{}
+		}
+	}
+}
+
+// splitFunctionName 拆分函数全名为"类名"与方法名
+// 如 github.com/x/system.(*UserHandler).GetUser -> github.com/x/system.(*UserHandler) / GetUser
+func splitFunctionName(function string) (string, string) {
+	slash := strings.LastIndex(function, "/")
+	if idx := strings.LastIndex(function, "."); idx > slash {
+		return function[:idx], function[idx+1:]
+	}
+	return function, ""
+}
+
+// record 异步记录 API 错误日志，记录失败不影响请求响应
+func (m *APIErrorLogMiddleware) record(c *gin.Context, ex *apiException) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("record api error log failed", zap.Any("error", r))
+		}
+	}()
+
+	className, methodName := splitFunctionName(ex.function)
+	log := &model.InfraApiErrorLog{
+		TraceID:                   getTraceID(c),
+		ApplicationName:           config.C.App.Name,
+		RequestMethod:             c.Request.Method,
+		RequestURL:                utils.TruncateString(c.Request.URL.Path, 255),
+		RequestParams:             m.masker.requestParams(c, readRequestBody(c), apiErrorLogMaxTextLength),
+		UserIP:                    c.ClientIP(),
+		UserAgent:                 utils.TruncateString(c.Request.UserAgent(), 512),
+		ExceptionTime:             time.Now(),
+		ExceptionName:             utils.TruncateString(ex.name, 128),
+		ExceptionMessage:          utils.TruncateString(ex.message, apiErrorLogMaxTextLength),
+		ExceptionRootCauseMessage: utils.TruncateString(ex.rootCauseMessage, apiErrorLogMaxTextLength),
+		ExceptionStackTrace:       utils.TruncateString(ex.stackTrace, apiErrorLogMaxTextLength),
+		ExceptionClassName:        utils.TruncateString(className, 512),
+		ExceptionFileName:         utils.TruncateString(ex.file, 512),
+		ExceptionMethodName:       utils.TruncateString(methodName, 512),
+		ExceptionLineNumber:       ex.line,
+		ProcessStatus:             consts.ApiErrorLogProcessStatusInit,
+	}
+	// 异步写入时没有 gin.Context，AuditPlugin 无法自动填充，这里手动设置
+	if loginUser := context.GetLoginUser(c); loginUser != nil {
+		log.UserID = loginUser.UserID
+		log.UserType = loginUser.UserType
+		log.TenantID = loginUser.TenantID
+		log.Creator = strconv.FormatInt(loginUser.UserID, 10)
+		log.Updater = log.Creator
+	}
+
+	m.svc.CreateApiErrorLogAsync(log)
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"github.com/wxlbd/admin-go/internal/service/infra"
+	"github.com/wxlbd/admin-go/pkg/config"
+	"github.com/wxlbd/admin-go/pkg/logger"
+	"github.com/wxlbd/admin-go/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestSplitFunctionName(t *testing.T) {
+	tests := []struct {
+		function   string
+		wantClass  string
+		wantMethod string
+	}{
+		{"github.com/x/system.(*UserHandler).GetUser", "github.com/x/system.(*UserHandler)", "GetUser"},
+		{"github.com/x/system.GetUser", "github.com/x/system", "GetUser"},
+		{"github.com/x.y/system", "github.com/x.y/system", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		class, method := splitFunctionName(tt.function)
+		if class != tt.wantClass || method != tt.wantMethod {
+			t.Errorf("splitFunctionName(%q) = (%q, %q), want (%q, %q)", tt.function, class, method, tt.wantClass, tt.wantMethod)
+		}
+	}
+}
+
+func TestNewPanicException(t *testing.T) {
+	root := errors.New("connection refused")
+	var frame runtime.Frame
+	var recovered any
+	func() {
+		defer func() {
+			recovered = recover()
+			frame = panicFrame()
+		}()
+		panic(fmt.Errorf("query user: %w", root))
+	}()
+
+	ex := newPanicException(recovered, "stack", frame)
+	if ex.name != "*fmt.wrapError" {
+		t.Errorf("name = %q, want *fmt.wrapError", ex.name)
+	}
+	if ex.rootCauseMessage != root.Error() {
+		t.Errorf("rootCauseMessage = %q, want %q", ex.rootCauseMessage, root.Error())
+	}
+	if !strings.HasSuffix(ex.function, "TestNewPanicException.func1") || !strings.HasSuffix(ex.file, "errorlog_test.go") {
+		t.Errorf("panic frame = %s (%s:%d), want the panicking closure", ex.function, ex.file, ex.line)
+	}
+}
+
+// newTestQuery 创建基于内存 SQLite 的查询对象
+func newTestQuery(t *testing.T, models ...any) *query.Query {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接相互独立，只使用一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return query.Use(db)
+}
+
+// serveErrorLog 使用错误日志中间件处理请求，返回落库的错误日志
+func serveErrorLog(t *testing.T, handler gin.HandlerFunc, body string) []*model.InfraApiErrorLog {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if logger.Log == nil {
+		logger.Log = zap.NewNop()
+	}
+	q := newTestQuery(t, &model.InfraApiErrorLog{})
+	svc := infra.NewApiErrorLogService(q, nil)
+	m := &APIErrorLogMiddleware{svc: svc, masker: newSensitiveMasker(config.APILogConfig{})}
+
+	r := gin.New()
+	r.Use(m.Recovery(), m.ErrorHandler())
+	r.POST("/admin-api/test", handler)
+	req := httptest.NewRequest(http.MethodPost, "/admin-api/test", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := svc.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	logs, err := q.InfraApiErrorLog.WithContext(context.Background()).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logs
+}
+
+func TestRecoveryRecordsConsumedBody(t *testing.T) {
+	logs := serveErrorLog(t, func(c *gin.Context) {
+		var req struct {
+			Name     string `json:"name"`
+			Password string `json:"password"`
+		}
+		_ = c.ShouldBindJSON(&req)
+		panic("boom")
+	}, `{"name":"admin","password":"123456"}`)
+
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 error log, got %d", len(logs))
+	}
+	if params := logs[0].RequestParams; !strings.Contains(params, `"name":"admin"`) || strings.Contains(params, "123456") {
+		t.Errorf("request params = %s, want the masked body read by the handler", params)
+	}
+}
+
+func TestErrorHandlerRecordsServerErrorResponse(t *testing.T) {
+	logs := serveErrorLog(t, func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, response.Error(500, "权限校验错误"))
+	}, `{}`)
+	if len(logs) != 1 || logs[0].ExceptionName != "HTTP 500" {
+		t.Fatalf("expected the 500 response recorded, got %+v", logs)
+	}
+
+	logs = serveErrorLog(t, func(c *gin.Context) {
+		c.JSON(http.StatusOK, response.Error(400, "参数错误"))
+	}, `{}`)
+	if len(logs) != 0 {
+		t.Fatalf("expected no error log for a business error, got %d", len(logs))
+	}
+}
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/config"
+	"github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// operateLogMaxBodyLength 请求体最大记录长度
+	operateLogMaxBodyLength = 1000
+	// operateLogMaxExtraLength extra 字段为 varchar(2000)
+	operateLogMaxExtraLength = 2000
+	// operateLogMaxResultLength 响应体最大拦截长度，仅用于解析结果码
+	operateLogMaxResultLength = 4096
+	// adminAPIPrefix 管理后台 API 前缀
+	adminAPIPrefix = "/admin-api/"
+)
+
+// OperateLogMiddleware 操作日志中间件，与 Java 的 OperateLogAspect 对齐
+// 对所有写操作（POST/PUT/DELETE/PATCH）记录操作日志，通过有界队列异步落库
+type OperateLogMiddleware struct {
+	svc    *system.OperateLogService
+	masker *sensitiveMasker
+}
+
+func NewOperateLogMiddleware(svc *system.OperateLogService) *OperateLogMiddleware {
+	return &OperateLogMiddleware{
+		svc:    svc,
+		masker: newSensitiveMasker(config.C.APILog),
+	}
+}
+
+// operateLogExtra 操作日志拓展字段，序列化后存入 extra
+type operateLogExtra struct {
+	OperateType   int    `json:"operateType"`
+	RequestParams string `json:"requestParams,omitempty"`
+	RequestBody   string `json:"requestBody,omitempty"`
+	ResultCode    int    `json:"resultCode"`
+	ResultMsg     string `json:"resultMsg,omitempty"`
+	Duration      int64  `json:"duration"` // 毫秒
+}
+
+// Handle 记录操作日志，需注册在 Auth 之后
+func (m *OperateLogMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		startTime := time.Now()
+
+		requestBody := readRequestBody(c)
+
+		// 拦截响应体，用于解析结果码
+		writer := newResponseWriter(c.Writer, operateLogMaxResultLength)
+		c.Writer = writer
+
+		c.Next()
+
+		loginUser := context.GetLoginUser(c)
+		if loginUser == nil {
+			return
+		}
+
+		module, name := resolveOperateModule(c)
+		operateType := resolveOperateType(c.Request.Method, name)
+		resultCode, resultMsg := parseResult(writer.body.Bytes(), c.Writer.Status())
+
+		extra := operateLogExtra{
+			OperateType:   operateType,
+			RequestParams: m.maskQuery(c),
+			RequestBody:   utils.TruncateString(m.masker.maskJSON(requestBody), operateLogMaxBodyLength),
+			ResultCode:    resultCode,
+			ResultMsg:     resultMsg,
+			Duration:      time.Since(startTime).Milliseconds(),
+		}
+
+		log := &model.SystemOperateLog{
+			TraceID:        getTraceID(c),
+			UserID:         loginUser.UserID,
+			UserType:       loginUser.UserType,
+			ImpersonatorID: loginUser.ImpersonatorID,
+			Type:           module,
+			SubType:        name,
+			BizID:          resolveBizID(c, requestBody),
+			Action:         consts.GetOperateTypeName(operateType) + " " + module,
+			Extra:          extra.marshal(),
+			RequestMethod:  c.Request.Method,
+			RequestURL:     utils.TruncateString(c.Request.URL.Path, 255),
+			UserIP:         c.ClientIP(),
+			UserAgent:      utils.TruncateString(c.Request.UserAgent(), 512),
+		}
+		// 异步写入时没有 gin.Context，AuditPlugin 无法自动填充，这里手动设置
+		log.TenantID = loginUser.TenantID
+		log.Creator = strconv.FormatInt(loginUser.UserID, 10)
+		log.Updater = log.Creator
+
+		m.svc.CreateOperateLogAsync(log)
+	}
+}
+
+// marshal 序列化拓展字段，超出 extra 字段长度时先截断请求体、再截断请求参数，保证结果为合法 JSON
+func (e operateLogExtra) marshal() string {
+	for {
+		data, _ := json.Marshal(e)
+		over := utf8.RuneCount(data) - operateLogMaxExtraLength
+		switch {
+		case over <= 0:
+			return string(data)
+		case e.RequestBody != "":
+			e.RequestBody = truncateRunes(e.RequestBody, utf8.RuneCountInString(e.RequestBody)-over)
+		case e.RequestParams != "":
+			e.RequestParams = truncateRunes(e.RequestParams, utf8.RuneCountInString(e.RequestParams)-over)
+		default:
+			return string(data)
+		}
+	}
+}
+
+// truncateRunes 截取前 n 个字符，n 不大于 0 时返回空字符串
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return utils.TruncateString(s, n)
+}
+
+// maskQuery 脱敏后的 query 参数
+func (m *OperateLogMiddleware) maskQuery(c *gin.Context) string {
+	values := m.masker.maskValues(c.Request.URL.Query())
+	if values == nil {
+		return ""
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// isMutatingMethod 是否为写操作
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// resolveOperateModule 解析操作模块与操作名
+// 优先使用 RequirePermission 记录的权限标识（如 system:user:create -> system:user / create），
+// 否则从请求路径推导（如 /admin-api/system/user/create -> system:user / create）
+func resolveOperateModule(c *gin.Context) (string, string) {
+	if permission := c.GetString(ctxPermissionKey); permission != "" {
+		if idx := strings.LastIndex(permission, ":"); idx > 0 {
+			return permission[:idx], permission[idx+1:]
+		}
+		return permission, ""
+	}
+
+	path := strings.TrimPrefix(c.Request.URL.Path, adminAPIPrefix)
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 1 {
+		return segments[0], ""
+	}
+	return strings.Join(segments[:len(segments)-1], ":"), segments[len(segments)-1]
+}
+
+// resolveOperateType 根据操作名和请求方法推导操作分类
+func resolveOperateType(method, name string) int {
+	switch {
+	case strings.Contains(name, "export"):
+		return consts.OperateTypeExport
+	case strings.Contains(name, "import"):
+		return consts.OperateTypeImport
+	case strings.Contains(name, "delete"):
+		return consts.OperateTypeDelete
+	case strings.Contains(name, "create"):
+		return consts.OperateTypeCreate
+	case strings.Contains(name, "update"), strings.Contains(name, "assign"), strings.Contains(name, "reset"):
+		return consts.OperateTypeUpdate
+	}
+
+	switch method {
+	case http.MethodGet:
+		return consts.OperateTypeGet
+	case http.MethodPost:
+		return consts.OperateTypeCreate
+	case http.MethodPut, http.MethodPatch:
+		return consts.OperateTypeUpdate
+	case http.MethodDelete:
+		return consts.OperateTypeDelete
+	}
+	return consts.OperateTypeOther
+}
+
+// parseResult 从统一响应结构 {code, msg} 中解析结果码，无法解析时使用 HTTP 状态码
+// 拦截的响应体可能被截断，code、msg 位于 data 之前，逐个字段解析，读到二者即停止
+func parseResult(body []byte, httpStatus int) (int, string) {
+	var (
+		code   *int
+		msg    string
+		hasMsg bool
+	)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if token, err := decoder.Token(); err == nil && token == json.Delim('{') {
+		for decoder.More() && (code == nil || !hasMsg) {
+			key, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			switch key {
+			case "code":
+				err = decoder.Decode(&code)
+			case "msg":
+				err = decoder.Decode(&msg)
+				hasMsg = err == nil
+			default:
+				var skip json.RawMessage
+				err = decoder.Decode(&skip)
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+	if code == nil {
+		if httpStatus == http.StatusOK {
+			return 0, ""
+		}
+		return httpStatus, http.StatusText(httpStatus)
+	}
+	return *code, utils.TruncateString(msg, 512)
+}
+
+// resolveBizID 解析业务编号：优先取 query 中的 id，其次取 JSON 请求体中的 id
+func resolveBizID(c *gin.Context, requestBody string) int64 {
+	if id := utils.ParseInt64(c.Query("id")); id > 0 {
+		return id
+	}
+	if requestBody == "" {
+		return 0
+	}
+	var body struct {
+		ID json.Number `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(requestBody), &body); err != nil {
+		return 0
+	}
+	id, _ := body.ID.Int64()
+	return id
+}
+
+// getTraceID 获取链路追踪编号
+func getTraceID(c *gin.Context) string {
+	if traceID := c.GetString("X-Request-ID"); traceID != "" {
+		return traceID
+	}
+	return c.GetHeader("X-Request-ID")
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		wantCode int
+		wantMsg  string
+	}{
+		{"success", `{"code":0,"msg":"success","data":{"id":1}}`, http.StatusOK, 0, "success"},
+		{"biz error", `{"code":1002000000,"msg":"用户不存在","data":null}`, http.StatusOK, 1002000000, "用户不存在"},
+		{"truncated data", `{"code":0,"msg":"success","data":{"list":[{"id":1},{"id"`, http.StatusOK, 0, "success"},
+		{"not json ok", `pong`, http.StatusOK, 0, ""},
+		{"not json error", ``, http.StatusInternalServerError, http.StatusInternalServerError, "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, msg := parseResult([]byte(tt.body), tt.status)
+			if code != tt.wantCode || msg != tt.wantMsg {
+				t.Errorf("parseResult() = (%d, %q), want (%d, %q)", code, msg, tt.wantCode, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestOperateLogExtraMarshal(t *testing.T) {
+	// 引号转义后长度翻倍，截断拼接后的 JSON 会得到非法内容
+	extra := operateLogExtra{
+		OperateType:   1,
+		RequestParams: strings.Repeat(`"`, 800),
+		RequestBody:   strings.Repeat("中", operateLogMaxBodyLength),
+		ResultCode:    0,
+	}
+	data := extra.marshal()
+	if n := utf8.RuneCountInString(data); n > operateLogMaxExtraLength {
+		t.Fatalf("extra length = %d, want <= %d", n, operateLogMaxExtraLength)
+	}
+	var decoded operateLogExtra
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("extra is not valid json: %v", err)
+	}
+	if utf8.RuneCountInString(decoded.RequestBody) >= operateLogMaxBodyLength || decoded.RequestParams != extra.RequestParams {
+		t.Errorf("request body should be truncated before request params, got body %d params %d",
+			utf8.RuneCountInString(decoded.RequestBody), len(decoded.RequestParams))
+	}
+
+	short := operateLogExtra{RequestBody: `{"id":1}`}
+	if got := short.marshal(); !strings.Contains(got, `"requestBody":"{\"id\":1}"`) {
+		t.Errorf("short extra should be kept, got %s", got)
+	}
+}
+
+func TestResponseWriterLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var writer *responseWriter
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		writer = newResponseWriter(c.Writer, 8)
+		c.Writer = writer
+		c.Next()
+	})
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "0123456789")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Body.String() != "0123456789" {
+		t.Errorf("response = %q, want the full body", w.Body.String())
+	}
+	if writer.body.String() != "01234567" || !writer.truncated {
+		t.Errorf("captured = %q (truncated %v), want the first 8 bytes", writer.body.String(), writer.truncated)
+	}
+}
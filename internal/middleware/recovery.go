@@ -15,11 +15,15 @@ import (
 	"go.uber.org/zap"
 )
 
-// Recovery 全局异常捕获中间件
-func Recovery() gin.HandlerFunc {
+// Recovery 全局异常捕获中间件，panic 记录到 API 错误日志
+func (m *APIErrorLogMiddleware) Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 记录 handler 读取过的请求体，发生异常时再补读剩余部分，正常请求不额外读取
+		recordRequestBody(c)
+
 		defer func() {
 			if err := recover(); err != nil {
+				frame := panicFrame()
 				// 获取调用栈
 				stack := string(debug.Stack())
 
@@ -45,6 +49,8 @@ func Recovery() gin.HandlerFunc {
 					return
 				}
 
+				m.record(c, newPanicException(err, stack, frame))
+
 				// 返回 500
 				c.JSON(http.StatusInternalServerError, response.Error(errors.ServerErrCode, "系统异常，请联系管理员"))
 				c.Abort()
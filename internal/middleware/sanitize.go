@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/wxlbd/admin-go/pkg/config"
+	"github.com/wxlbd/admin-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sensitiveMask = "******"
+
+var (
+	defaultMaskFields = []string{"password", "oldPassword", "newPassword", "token", "accessToken", "refreshToken", "clientSecret", "secretKey",
+		"client_secret", "access_token", "refresh_token", "code_verifier", "secret", "qrCodeUri", "recoveryCodes"}
+	defaultMobileFields = []string{"mobile", "phone"}
+)
+
+// sensitiveMasker 敏感数据脱敏器，字段名匹配不区分大小写
+type sensitiveMasker struct {
+	maskFields   map[string]struct{}
+	mobileFields map[string]struct{}
+}
+
+// newSensitiveMasker 根据配置创建脱敏器，未配置时使用默认字段
+func newSensitiveMasker(cfg config.APILogConfig) *sensitiveMasker {
+	maskFields, mobileFields := cfg.MaskFields, cfg.MobileFields
+	if len(maskFields) == 0 {
+		maskFields = defaultMaskFields
+	}
+	if len(mobileFields) == 0 {
+		mobileFields = defaultMobileFields
+	}
+	return &sensitiveMasker{
+		maskFields:   toLowerSet(maskFields),
+		mobileFields: toLowerSet(mobileFields),
+	}
+}
+
+func toLowerSet(fields []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		set[strings.ToLower(field)] = struct{}{}
+	}
+	return set
+}
+
+// requestLogParams 日志中的请求参数，序列化后存入 request_params
+type requestLogParams struct {
+	Query map[string]any `json:"query,omitempty"`
+	Body  any            `json:"body,omitempty"`
+}
+
+// requestParams 合并 query 参数与请求体，脱敏后序列化
+func (m *sensitiveMasker) requestParams(c *gin.Context, requestBody string, maxLen int) string {
+	params := requestLogParams{Query: m.maskValues(c.Request.URL.Query())}
+	if requestBody != "" {
+		if body, ok := m.decodeJSON(requestBody); ok {
+			params.Body = body
+		} else if c.ContentType() == "application/x-www-form-urlencoded" {
+			if values, err := url.ParseQuery(requestBody); err == nil {
+				params.Body = m.maskValues(values)
+			}
+		} else {
+			params.Body = requestBody
+		}
+	}
+	if params.Query == nil && params.Body == nil {
+		return ""
+	}
+	data, _ := json.Marshal(params)
+	return utils.TruncateString(string(data), maxLen)
+}
+
+// maskJSON 对 JSON 文本脱敏，非 JSON 文本原样返回
+func (m *sensitiveMasker) maskJSON(data string) string {
+	value, ok := m.decodeJSON(data)
+	if !ok {
+		return data
+	}
+	result, err := json.Marshal(value)
+	if err != nil {
+		return data
+	}
+	return string(result)
+}
+
+// decodeJSON 解析 JSON 文本并脱敏，数字保持原样输出
+func (m *sensitiveMasker) decodeJSON(data string) (any, bool) {
+	if data == "" {
+		return nil, false
+	}
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	return m.maskValue("", value), true
+}
+
+// maskValues 对 query / form 参数脱敏，单值参数展开为字符串
+func (m *sensitiveMasker) maskValues(values url.Values) map[string]any {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make(map[string]any, len(values))
+	for key, items := range values {
+		if len(items) == 1 {
+			result[key] = m.maskValue(key, items[0])
+			continue
+		}
+		masked := make([]any, len(items))
+		for i, item := range items {
+			masked[i] = m.maskValue(key, item)
+		}
+		result[key] = masked
+	}
+	return result
+}
+
+// maskValue 递归脱敏，key 为当前值所属的字段名
+func (m *sensitiveMasker) maskValue(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = m.maskValue(k, item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = m.maskValue(key, item)
+		}
+		return v
+	case nil:
+		return nil
+	}
+
+	lowerKey := strings.ToLower(key)
+	if _, ok := m.maskFields[lowerKey]; ok {
+		return sensitiveMask
+	}
+	if _, ok := m.mobileFields[lowerKey]; ok {
+		if s, ok := value.(string); ok {
+			return maskMobile(s)
+		}
+		if n, ok := value.(json.Number); ok {
+			return maskMobile(n.String())
+		}
+	}
+	return value
+}
+
+// maskMobile 手机号脱敏，保留前 3 位和后 4 位，如 138****1234
+func maskMobile(mobile string) string {
+	runes := []rune(mobile)
+	if len(runes) < 7 {
+		return sensitiveMask
+	}
+	return string(runes[:3]) + strings.Repeat("*", len(runes)-7) + string(runes[len(runes)-4:])
+}
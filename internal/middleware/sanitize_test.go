@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/wxlbd/admin-go/pkg/config"
+)
+
+func TestSensitiveMaskerMaskJSON(t *testing.T) {
+	m := newSensitiveMasker(config.APILogConfig{})
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", ""},
+		{"not json", "username=admin", "username=admin"},
+		{"password", `{"username":"admin","password":"123456"}`, `{"password":"******","username":"admin"}`},
+		{"case insensitive", `{"PassWord":"123456"}`, `{"PassWord":"******"}`},
+		{"nested", `{"data":{"accessToken":"abc","list":[{"mobile":"13812341234"}]}}`, `{"data":{"accessToken":"******","list":[{"mobile":"138****1234"}]}}`},
+		{"number kept", `{"id":1024,"mobile":13812341234}`, `{"id":1024,"mobile":"138****1234"}`},
+		{"short mobile", `{"mobile":"123"}`, `{"mobile":"******"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.maskJSON(tt.data); got != tt.want {
+				t.Errorf("maskJSON(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSensitiveMaskerMaskValues(t *testing.T) {
+	m := newSensitiveMasker(config.APILogConfig{MaskFields: []string{"secret"}, MobileFields: []string{"tel"}})
+
+	got := m.maskValues(url.Values{
+		"secret":   {"abc"},
+		"tel":      {"13812341234"},
+		"password": {"kept"},
+		"ids":      {"1", "2"},
+	})
+	if got["secret"] != sensitiveMask {
+		t.Errorf("secret = %v, want %q", got["secret"], sensitiveMask)
+	}
+	if got["tel"] != "138****1234" {
+		t.Errorf("tel = %v, want 138****1234", got["tel"])
+	}
+	if got["password"] != "kept" {
+		t.Errorf("password = %v, want kept (not in configured fields)", got["password"])
+	}
+	if ids, ok := got["ids"].([]any); !ok || len(ids) != 2 {
+		t.Errorf("ids = %v, want two values", got["ids"])
+	}
+	if m.maskValues(nil) != nil {
+		t.Error("maskValues(nil) should be nil")
+	}
+}
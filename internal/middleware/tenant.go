@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/response"
+	"github.com/wxlbd/admin-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderTenantID 指定租户的请求头，与 Java 前端保持一致
+const HeaderTenantID = "tenant-id"
+
+// TenantMiddleware 租户解析中间件
+type TenantMiddleware struct {
+	tenantSvc *system.TenantService
+}
+
+func NewTenantMiddleware(tenantSvc *system.TenantService) *TenantMiddleware {
+	return &TenantMiddleware{tenantSvc: tenantSvc}
+}
+
+// Handle 解析当前请求的租户，依次取请求头 tenant-id、令牌中的租户；两者均未指定时按请求域名匹配绑定的租户
+// 请求头指定的租户与令牌中的租户不一致时拒绝访问；租户须存在、启用且未过期
+// 未解析到租户时继续处理，租户插件会拒绝访问租户数据，公开接口须自行指定租户（如登录时使用默认租户）
+func (m *TenantMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 1. 从请求头解析
+		tenantId, err := obtainTenantHeader(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, response.Error(400, "请求头 tenant-id 格式不正确"))
+			return
+		}
+
+		// 2. 令牌中的租户，与请求头指定的租户须一致
+		if tokenTenantId := obtainTokenTenant(c); tokenTenantId > 0 {
+			if tenantId > 0 && tenantId != tokenTenantId {
+				c.AbortWithStatusJSON(http.StatusForbidden, response.Error(403, "您无权访问该租户的数据"))
+				return
+			}
+			tenantId = tokenTenantId
+		}
+
+		// 3. 均未指定时，按请求域名匹配租户绑定的域名
+		if tenantId == 0 {
+			tenantId, err = m.obtainWebsiteTenant(c)
+			if err != nil {
+				_ = c.Error(err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, response.Error(500, "解析租户失败"))
+				return
+			}
+		}
+		if tenantId == 0 {
+			c.Next()
+			return
+		}
+
+		// 4. 校验租户状态与有效期
+		if err := m.tenantSvc.ValidTenant(c.Request.Context(), tenantId); err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.Error(403, err.Error()))
+			return
+		}
+
+		context.SetTenantId(c, tenantId)
+		c.Next()
+	}
+}
+
+// obtainWebsiteTenant 根据请求域名获取绑定的租户，域名可带端口绑定；未绑定时返回 0
+func (m *TenantMiddleware) obtainWebsiteTenant(c *gin.Context) (int64, error) {
+	hosts := []string{c.Request.Host}
+	if hostname, _, err := net.SplitHostPort(c.Request.Host); err == nil {
+		hosts = append(hosts, hostname)
+	}
+	for _, host := range hosts {
+		tenant, err := m.tenantSvc.GetTenantByWebsite(c.Request.Context(), host)
+		if err != nil {
+			return 0, err
+		}
+		if tenant != nil {
+			return tenant.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// obtainTenantHeader 从请求头获取租户编号，未指定时返回 0
+func obtainTenantHeader(c *gin.Context) (int64, error) {
+	header := c.GetHeader(HeaderTenantID)
+	if header == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(header, 10, 64)
+}
+
+// obtainTokenTenant 从令牌中获取租户编号，令牌不存在或无效时返回 0，由认证中间件处理
+func obtainTokenTenant(c *gin.Context) int64 {
+	token := obtainAuthorization(c)
+	if token == "" {
+		return 0
+	}
+	claims, err := utils.ParseToken(token)
+	if err != nil {
+		return 0
+	}
+	return claims.TenantID
+}
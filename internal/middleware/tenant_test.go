@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/service/system"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/utils"
+)
+
+func TestTenantMiddlewareRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token, err := utils.GenerateTokenWithInfo(1, 2, 2, "admin", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		token  string
+		want   int
+	}{
+		{"invalid header", "abc", "", http.StatusBadRequest},
+		{"header mismatch token", "3", token, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use((&TenantMiddleware{}).Handle())
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(HeaderTenantID, tt.header)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantMiddlewareWebsite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	q := newTestQuery(t, &model.SystemTenant{})
+	tenants := []*model.SystemTenant{
+		{Name: "website", Websites: model.StringListFromCSV{"a.com"}},
+		{Name: "header"},
+	}
+	if err := q.SystemTenant.WithContext(context.Background()).Create(tenants...); err != nil {
+		t.Fatal(err)
+	}
+	m := NewTenantMiddleware(system.NewTenantService(q, nil, nil, nil, nil, nil))
+
+	serve := func(host, header string) (int, int64) {
+		var tenantId int64
+		r := gin.New()
+		r.Use(m.Handle())
+		r.GET("/", func(c *gin.Context) {
+			tenantId = pkgContext.GetTenantId(c)
+			c.Status(http.StatusOK)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+		req.Header.Set(HeaderTenantID, header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code, tenantId
+	}
+
+	if code, tenantId := serve("a.com:8080", ""); code != http.StatusOK || tenantId != tenants[0].ID {
+		t.Errorf("website: status = %d, tenant = %d, want %d", code, tenantId, tenants[0].ID)
+	}
+	// 请求头指定租户时不按域名匹配
+	if code, tenantId := serve("a.com", "2"); code != http.StatusOK || tenantId != tenants[1].ID {
+		t.Errorf("header: status = %d, tenant = %d, want %d", code, tenantId, tenants[1].ID)
+	}
+
+	// 查询域名失败时拒绝访问，而不是当作未绑定
+	if err := q.SystemTenant.WithContext(context.Background()).UnderlyingDB().Migrator().DropTable(&model.SystemTenant{}); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := serve("a.com", ""); code != http.StatusInternalServerError {
+		t.Errorf("db error: status = %d, want %d", code, http.StatusInternalServerError)
+	}
+}
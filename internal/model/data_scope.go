@@ -0,0 +1,23 @@
+package model
+
+// DataScopeRule 表的数据权限规则，实现 DataScopeRule() 方法的模型参与数据权限过滤
+type DataScopeRule struct {
+	DeptColumn string // 部门字段，为空时不按部门过滤
+	UserColumn string // 用户字段，为空时不按本人过滤
+	TableAlias string // 表别名，联表查询时字段所属的表，为空时为当前表
+}
+
+// RoleDataScopeConfig 角色数据权限的拓展配置，供需要参数的数据范围使用
+type RoleDataScopeConfig struct {
+	DeptDepth int `json:"deptDepth,omitempty"` // 部门层级深度，数据范围为「本部门及以下 N 级」时使用
+}
+
+// DataScopeRule 用户按所属部门与用户本身过滤
+func (SystemUser) DataScopeRule() DataScopeRule {
+	return DataScopeRule{DeptColumn: "dept_id", UserColumn: "id"}
+}
+
+// DataScopeRule 部门按部门编号本身过滤
+func (SystemDept) DataScopeRule() DataScopeRule {
+	return DataScopeRule{DeptColumn: "id"}
+}
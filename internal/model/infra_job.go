@@ -11,6 +11,10 @@ type InfraJob struct {
 	RetryCount     int    `gorm:"column:retry_count;type:int;not null;default:0;comment:重试次数" json:"retryCount"`
 	RetryInterval  int    `gorm:"column:retry_interval;type:int;not null;default:0;comment:重试间隔，单位：毫秒" json:"retryInterval"`
 	MonitorTimeout *int   `gorm:"column:monitor_timeout;type:int;comment:监控超时时间，单位：毫秒" json:"monitorTimeout"`
+	// ConcurrencyPolicy 并发策略：0 执行中跳过，1 允许并发，2 排队等待
+	ConcurrencyPolicy int `gorm:"column:concurrency_policy;type:tinyint;not null;default:0;comment:并发策略" json:"concurrencyPolicy"`
+	// MisfirePolicy 错过触发的补偿策略：0 忽略，1 补偿执行一次，2 补偿执行全部错过的触发
+	MisfirePolicy int `gorm:"column:misfire_policy;type:tinyint;not null;default:0;comment:错过触发的补偿策略" json:"misfirePolicy"`
 	BaseDO
 }
 
@@ -11,6 +11,7 @@ type InfraJobLog struct {
 	HandlerName  string     `gorm:"column:handler_name;type:varchar(64);not null;comment:处理器的名字" json:"handlerName"`
 	HandlerParam string     `gorm:"column:handler_param;type:varchar(255);comment:处理器的参数" json:"handlerParam"`
 	ExecuteIndex int        `gorm:"column:execute_index;type:tinyint;not null;default:1;comment:第几次执行" json:"executeIndex"`
+	InstanceID   string     `gorm:"column:instance_id;type:varchar(64);comment:执行实例" json:"instanceId"` // ruoyi-vue-pro 原表没有该字段，需执行 README 中的变更 SQL
 	BeginTime    time.Time  `gorm:"column:begin_time;not null;comment:开始执行时间" json:"beginTime"`
 	EndTime      *time.Time `gorm:"column:end_time;comment:结束执行时间" json:"endTime"`
 	Duration     *int       `gorm:"column:duration;type:int;comment:执行时长，单位：毫秒" json:"duration"`
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// SystemOAuth2Approve OAuth2 批准记录，用户对客户端每个授权范围的批准结果
+type SystemOAuth2Approve struct {
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement;comment:编号" json:"id"`
+	UserID      int64     `gorm:"column:user_id;not null;comment:用户编号" json:"userId"`
+	UserType    int       `gorm:"column:user_type;not null;comment:用户类型" json:"userType"`
+	ClientID    string    `gorm:"column:client_id;not null;comment:客户端编号" json:"clientId"`
+	Scope       string    `gorm:"column:scope;not null;default:'';comment:授权范围" json:"scope"`
+	Approved    BitBool   `gorm:"column:approved;not null;default:0;comment:是否接受" json:"approved"`
+	ExpiresTime time.Time `gorm:"column:expires_time;not null;comment:过期时间" json:"expiresTime"`
+	TenantBaseDO
+}
+
+func (SystemOAuth2Approve) TableName() string {
+	return "system_oauth2_approve"
+}
@@ -16,6 +16,8 @@ type SystemTenant struct {
 	PackageID     int64             `gorm:"column:package_id" json:"packageId"`
 	ExpireDate    time.Time         `gorm:"column:expire_time" json:"expireTime"` // 对齐 Java 契约字段名
 	AccountCount  int32             `gorm:"column:account_count" json:"accountCount"`
+	// TwoFactorRoleCodes 强制开启二次验证的角色编码，持有任一角色的用户登录时必须完成 TOTP 验证
+	TwoFactorRoleCodes StringListFromCSV `gorm:"column:two_factor_role_codes" json:"twoFactorRoleCodes"`
 	BaseDO
 }
 
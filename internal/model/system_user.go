@@ -21,6 +21,10 @@ type SystemUser struct {
 	LoginIP   string     `gorm:"column:login_ip" json:"loginIp"`
 	LoginDate *time.Time `gorm:"column:login_date" json:"loginDate"`
 
+	PasswordUpdateTime *time.Time `gorm:"column:password_update_time;comment:密码最后修改时间" json:"passwordUpdateTime"` // 为空时以创建时间计算密码有效期
+	// PasswordChangeRequired 使用初始密码创建的用户，修改密码前每次登录都提示修改
+	PasswordChangeRequired bool `gorm:"column:password_change_required;not null;default:0;comment:是否需要修改密码" json:"-"`
+
 	// 基础字段
 	TenantBaseDO
 }
@@ -0,0 +1,13 @@
+package model
+
+// SystemUserPasswordHistory 用户历史密码，用于禁止重复使用最近的密码
+type SystemUserPasswordHistory struct {
+	ID       int64  `gorm:"primaryKey;autoIncrement;comment:编号" json:"id"`
+	UserID   int64  `gorm:"column:user_id;type:bigint;not null;index;comment:用户编号" json:"userId"`
+	Password string `gorm:"column:password;type:varchar(100);not null;comment:密码摘要" json:"-"`
+	TenantBaseDO
+}
+
+func (SystemUserPasswordHistory) TableName() string {
+	return "system_user_password_history"
+}
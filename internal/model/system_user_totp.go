@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// SystemUserTotp 用户 TOTP 二次验证
+type SystemUserTotp struct {
+	ID            int64             `gorm:"primaryKey;autoIncrement;comment:编号" json:"id"`
+	UserID        int64             `gorm:"column:user_id;type:bigint;not null;uniqueIndex;comment:用户编号" json:"userId"`
+	Secret        string            `gorm:"column:secret;type:varchar(64);not null;comment:TOTP 密钥" json:"-"`
+	Enabled       BitBool           `gorm:"column:enabled;not null;default:0;comment:是否已开启" json:"enabled"`
+	RecoveryCodes StringListFromCSV `gorm:"column:recovery_codes;type:varchar(1024);comment:恢复码摘要" json:"-"`       // SHA-256 摘要，使用后移除
+	LastUsedStep  int64             `gorm:"column:last_used_step;type:bigint;default:0;comment:最近使用的时间步" json:"-"` // 防止验证码重放
+	EnableTime    *time.Time        `gorm:"column:enable_time;comment:开启时间" json:"enableTime"`
+	TenantBaseDO
+}
+
+func (SystemUserTotp) TableName() string {
+	return "system_user_totp"
+}
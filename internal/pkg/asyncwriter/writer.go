@@ -0,0 +1,154 @@
+package asyncwriter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultQueueSize     = 1024
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+)
+
+// Options 异步写入器配置
+type Options struct {
+	QueueSize     int           // 队列容量，队列满时新数据直接丢弃，避免阻塞请求
+	BatchSize     int           // 单次批量写入的最大条数
+	FlushInterval time.Duration // 定时刷新间隔
+}
+
+// FlushFunc 批量落库函数
+type FlushFunc[T any] func(ctx context.Context, items []T) error
+
+// Writer 基于有界队列的异步批量写入器
+// 用于访问日志、操作日志等"写多读少、允许少量丢失"的场景
+type Writer[T any] struct {
+	name    string
+	opts    Options
+	flush   FlushFunc[T]
+	log     *zap.Logger
+	queue   chan T
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Int64
+}
+
+// New 创建并启动异步写入器
+func New[T any](name string, log *zap.Logger, opts Options, flush FlushFunc[T]) *Writer[T] {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+
+	w := &Writer[T]{
+		name:  name,
+		opts:  opts,
+		flush: flush,
+		log:   log,
+		queue: make(chan T, opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write 非阻塞写入，队列已满或写入器已关闭时返回 false
+func (w *Writer[T]) Write(item T) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return false
+	}
+
+	select {
+	case w.queue <- item:
+		return true
+	default:
+		if n := w.dropped.Add(1); n == 1 || n%1000 == 0 {
+			w.log.Warn("Async writer queue is full, dropping item",
+				zap.String("writer", w.name), zap.Int64("dropped", n))
+		}
+		return false
+	}
+}
+
+// Dropped 返回因队列已满而丢弃的条数
+func (w *Writer[T]) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Close 停止接收新数据，并等待队列中剩余数据全部落库
+// ctx 超时后直接返回，剩余数据放弃
+func (w *Writer[T]) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 后台消费循环：攒够 BatchSize 条或到达 FlushInterval 时批量写入
+func (w *Writer[T]) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, w.opts.BatchSize)
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				w.doFlush(batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= w.opts.BatchSize {
+				w.doFlush(batch)
+				batch = make([]T, 0, w.opts.BatchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.doFlush(batch)
+				batch = make([]T, 0, w.opts.BatchSize)
+			}
+		}
+	}
+}
+
+func (w *Writer[T]) doFlush(batch []T) {
+	if len(batch) == 0 {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			w.log.Error("Async writer flush panic", zap.String("writer", w.name), zap.Any("panic", r))
+		}
+	}()
+	if err := w.flush(context.Background(), batch); err != nil {
+		w.log.Error("Async writer flush failed",
+			zap.String("writer", w.name), zap.Int("size", len(batch)), zap.Error(err))
+	}
+}
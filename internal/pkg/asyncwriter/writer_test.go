@@ -0,0 +1,83 @@
+package asyncwriter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriterFlushOnBatchSize(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	w := New("test", nil, Options{QueueSize: 10, BatchSize: 3, FlushInterval: time.Hour}, func(_ context.Context, items []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, append([]int(nil), items...))
+		return nil
+	})
+
+	for i := 0; i < 7; i++ {
+		if !w.Write(i) {
+			t.Fatalf("Write(%d) = false, want true", i)
+		}
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 3 {
+		t.Fatalf("got %d batches, want 3", len(batches))
+	}
+	if len(batches[0]) != 3 || len(batches[1]) != 3 || len(batches[2]) != 1 {
+		t.Errorf("unexpected batch sizes: %v", batches)
+	}
+}
+
+func TestWriterFlushOnInterval(t *testing.T) {
+	flushed := make(chan []int, 1)
+	w := New("test", nil, Options{QueueSize: 10, BatchSize: 100, FlushInterval: 10 * time.Millisecond}, func(_ context.Context, items []int) error {
+		flushed <- items
+		return nil
+	})
+	defer w.Close(context.Background())
+
+	w.Write(1)
+	select {
+	case items := <-flushed:
+		if len(items) != 1 || items[0] != 1 {
+			t.Errorf("flushed = %v, want [1]", items)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("interval flush did not happen")
+	}
+}
+
+func TestWriterDropWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	w := New("test", nil, Options{QueueSize: 1, BatchSize: 1, FlushInterval: time.Hour}, func(_ context.Context, _ []int) error {
+		<-block
+		return nil
+	})
+
+	// 第一条被消费协程取走并阻塞在 flush，第二条占满队列，后续写入应被丢弃
+	w.Write(1)
+	time.Sleep(10 * time.Millisecond)
+	w.Write(2)
+	if w.Write(3) {
+		t.Error("Write() on full queue = true, want false")
+	}
+	if w.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", w.Dropped())
+	}
+
+	close(block)
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if w.Write(4) {
+		t.Error("Write() after Close = true, want false")
+	}
+}
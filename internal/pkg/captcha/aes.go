@@ -0,0 +1,49 @@
+package captcha
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/base64"
+	"errors"
+)
+
+// 滑块坐标与二次校验串使用 AES/ECB/PKCS5Padding 加密，与 AJ-Captcha 前端保持一致
+
+// AesEncrypt 使用 key 加密并输出 base64
+func AesEncrypt(plain, key string) (string, error) {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return "", err
+	}
+	size := block.BlockSize()
+	padding := size - len(plain)%size
+	data := append([]byte(plain), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	for i := 0; i < len(data); i += size {
+		block.Encrypt(data[i:i+size], data[i:i+size])
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// AesDecrypt 解密 base64 密文
+func AesDecrypt(cipherText, key string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return "", err
+	}
+	size := block.BlockSize()
+	if len(data) == 0 || len(data)%size != 0 {
+		return "", errors.New("captcha: invalid cipher text length")
+	}
+	for i := 0; i < len(data); i += size {
+		block.Decrypt(data[i:i+size], data[i:i+size])
+	}
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > size {
+		return "", errors.New("captcha: invalid padding")
+	}
+	return string(data[:len(data)-padding]), nil
+}
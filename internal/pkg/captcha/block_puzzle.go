@@ -0,0 +1,112 @@
+package captcha
+
+import (
+	"image"
+	"image/color"
+	"math/rand/v2"
+)
+
+// 滑块拼图尺寸，与 AJ-Captcha 默认底图尺寸一致
+const (
+	BlockPuzzleWidth  = 310
+	BlockPuzzleHeight = 155
+
+	blockSize   = 42 // 拼图方块边长
+	blockKnob   = 7  // 拼图右侧凸起半径
+	blockWidth  = blockSize + blockKnob
+	blockMargin = 5
+)
+
+// BlockPuzzle 滑块拼图验证码
+type BlockPuzzle struct {
+	OriginalImage string // 带缺口的底图（base64 PNG）
+	JigsawImage   string // 拼图块（base64 PNG），宽为拼图宽度，高与底图一致
+	X             int    // 缺口左上角横坐标，即正确的滑动距离
+	Y             int    // 缺口左上角纵坐标
+}
+
+// NewBlockPuzzle 生成滑块拼图验证码
+func NewBlockPuzzle() (*BlockPuzzle, error) {
+	// 缺口不与拼图初始位置重叠
+	x := blockWidth*2 + rand.IntN(BlockPuzzleWidth-blockWidth*3-blockMargin)
+	y := blockMargin + rand.IntN(BlockPuzzleHeight-blockSize-blockMargin*2)
+
+	background := drawBackground(BlockPuzzleWidth, BlockPuzzleHeight)
+	jigsaw := image.NewRGBA(image.Rect(0, 0, blockWidth, BlockPuzzleHeight))
+	border := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	for dy := 0; dy < blockSize; dy++ {
+		for dx := 0; dx < blockWidth; dx++ {
+			if !inBlock(dx, dy) {
+				continue
+			}
+			px, py := x+dx, y+dy
+			origin := background.RGBAAt(px, py)
+			if isBlockBorder(dx, dy) {
+				jigsaw.SetRGBA(dx, py, border)
+				background.SetRGBA(px, py, blend(origin, border, 0.8))
+				continue
+			}
+			jigsaw.SetRGBA(dx, py, origin)
+			background.SetRGBA(px, py, blend(origin, color.RGBA{A: 0xff}, 0.55))
+		}
+	}
+
+	originalImage, err := encodePNG(background)
+	if err != nil {
+		return nil, err
+	}
+	jigsawImage, err := encodePNG(jigsaw)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockPuzzle{OriginalImage: originalImage, JigsawImage: jigsawImage, X: x, Y: y}, nil
+}
+
+// inBlock 判断拼图块内的相对坐标是否属于拼图形状：方块 + 右侧半圆凸起
+func inBlock(dx, dy int) bool {
+	if dx < 0 || dy < 0 || dx >= blockWidth || dy >= blockSize {
+		return false
+	}
+	if dx < blockSize {
+		return true
+	}
+	cx, cy := dx-blockSize, dy-blockSize/2
+	return cx*cx+cy*cy <= blockKnob*blockKnob
+}
+
+// isBlockBorder 判断是否为拼图形状的边缘像素
+func isBlockBorder(dx, dy int) bool {
+	return !inBlock(dx-1, dy) || !inBlock(dx+1, dy) || !inBlock(dx, dy-1) || !inBlock(dx, dy+1)
+}
+
+// drawBackground 生成随机底图：渐变底色 + 随机色块 + 噪点，保证缺口可辨识
+func drawBackground(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	from, to := randomColor(60, 200), randomColor(60, 200)
+	for x := 0; x < width; x++ {
+		c := blend(from, to, float64(x)/float64(width))
+		for y := 0; y < height; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	for i := 0; i < 14; i++ {
+		cx, cy := rand.IntN(width), rand.IntN(height)
+		r := 10 + rand.IntN(30)
+		c := randomColor(30, 230)
+		for y := max(cy-r, 0); y < min(cy+r, height); y++ {
+			for x := max(cx-r, 0); x < min(cx+r, width); x++ {
+				if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r {
+					img.SetRGBA(x, y, blend(img.RGBAAt(x, y), c, 0.6))
+				}
+			}
+		}
+	}
+
+	for i := range img.Pix {
+		if i%4 != 3 {
+			img.Pix[i] = clamp(int(img.Pix[i]) + rand.IntN(21) - 10)
+		}
+	}
+	return img
+}
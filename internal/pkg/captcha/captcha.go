@@ -0,0 +1,53 @@
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+)
+
+// 验证码类型，与前端 AJ-Captcha 组件的 captchaType 对齐
+const (
+	TypeBlockPuzzle = "blockPuzzle" // 滑块拼图
+	TypeImageText   = "imageText"   // 图形文字
+)
+
+// IsValidType 验证码类型是否有效
+func IsValidType(captchaType string) bool {
+	return captchaType == TypeBlockPuzzle || captchaType == TypeImageText
+}
+
+// encodePNG 编码为不带 data URI 前缀的 base64 PNG，前缀由前端拼接
+func encodePNG(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// randomColor 生成指定亮度区间内的随机颜色
+func randomColor(min, max int) color.RGBA {
+	n := func() uint8 { return uint8(min + rand.IntN(max-min+1)) }
+	return color.RGBA{R: n(), G: n(), B: n(), A: 0xff}
+}
+
+// blend 按 alpha 混合两种颜色
+func blend(dst, src color.RGBA, alpha float64) color.RGBA {
+	mix := func(d, s uint8) uint8 { return uint8(float64(d)*(1-alpha) + float64(s)*alpha) }
+	return color.RGBA{R: mix(dst.R, src.R), G: mix(dst.G, src.G), B: mix(dst.B, src.B), A: 0xff}
+}
+
+// clamp 将数值限制在 [0, 255]
+func clamp(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
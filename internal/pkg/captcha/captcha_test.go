@@ -0,0 +1,78 @@
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"testing"
+)
+
+func TestAesRoundTrip(t *testing.T) {
+	key := "0123456789abcdef"
+	for _, plain := range []string{"", `{"x":120.5,"y":5}`, "token---0123456789abcdef"} {
+		encrypted, err := AesEncrypt(plain, key)
+		if err != nil {
+			t.Fatalf("AesEncrypt(%q) error = %v", plain, err)
+		}
+		decrypted, err := AesDecrypt(encrypted, key)
+		if err != nil {
+			t.Fatalf("AesDecrypt(%q) error = %v", encrypted, err)
+		}
+		if decrypted != plain {
+			t.Errorf("round trip = %q, want %q", decrypted, plain)
+		}
+	}
+
+	if _, err := AesDecrypt("not-base64!", key); err == nil {
+		t.Error("AesDecrypt(invalid) error = nil, want error")
+	}
+}
+
+func TestNewBlockPuzzle(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		puzzle, err := NewBlockPuzzle()
+		if err != nil {
+			t.Fatalf("NewBlockPuzzle() error = %v", err)
+		}
+		if puzzle.X < blockWidth || puzzle.X+blockWidth > BlockPuzzleWidth {
+			t.Errorf("X = %d out of range", puzzle.X)
+		}
+		if puzzle.Y < 0 || puzzle.Y+blockSize > BlockPuzzleHeight {
+			t.Errorf("Y = %d out of range", puzzle.Y)
+		}
+	}
+
+	puzzle, _ := NewBlockPuzzle()
+	if w, h := decodeSize(t, puzzle.OriginalImage); w != BlockPuzzleWidth || h != BlockPuzzleHeight {
+		t.Errorf("original image size = %dx%d", w, h)
+	}
+	if w, h := decodeSize(t, puzzle.JigsawImage); w != blockWidth || h != BlockPuzzleHeight {
+		t.Errorf("jigsaw image size = %dx%d", w, h)
+	}
+}
+
+func TestNewImageText(t *testing.T) {
+	text, err := NewImageText()
+	if err != nil {
+		t.Fatalf("NewImageText() error = %v", err)
+	}
+	if len(text.Code) != ImageTextLength {
+		t.Errorf("Code = %q, want %d digits", text.Code, ImageTextLength)
+	}
+	if w, h := decodeSize(t, text.Image); w != ImageTextWidth || h != ImageTextHeight {
+		t.Errorf("image size = %dx%d", w, h)
+	}
+}
+
+func decodeSize(t *testing.T, encoded string) (int, int) {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("decode base64: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	return img.Bounds().Dx(), img.Bounds().Dy()
+}
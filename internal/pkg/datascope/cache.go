@@ -0,0 +1,254 @@
+package datascope
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// CacheKeyPrefix 用户数据范围的 Redis 缓存，格式：data_scope:user:{userId}，值为带全局版本的 Scope JSON
+	CacheKeyPrefix = "data_scope:user:"
+	// CacheVersionKey 数据范围缓存的全局版本，全部失效时递增，版本不一致的用户缓存视为失效
+	CacheVersionKey = "data_scope:version"
+	// CacheUserVersionKeyPrefix 用户数据范围的版本，格式：data_scope:user_version:{userId}，用户失效时递增
+	CacheUserVersionKeyPrefix = "data_scope:user_version:"
+	// CacheChannel 数据范围失效通知的 Redis 频道，消息为逗号分隔的用户编号，全部失效时为 *
+	CacheChannel = "data_scope:invalidate"
+
+	cacheTTL      = 30 * time.Minute // Redis 缓存有效期，兜底未通知到的变更
+	localCacheTTL = time.Minute      // 进程内缓存有效期，兜底未收到的失效通知
+	invalidateAll = "*"
+)
+
+// setCacheScript 全局版本与用户版本均未变化时才写入缓存，避免计算期间发生的失效被旧结果覆盖
+// KEYS: 全局版本、用户版本、用户缓存；ARGV: 计算前的全局版本、用户版本、缓存内容、有效期（秒）
+var setCacheScript = redis.NewScript(`
+if (redis.call('GET', KEYS[1]) or '') ~= ARGV[1] or (redis.call('GET', KEYS[2]) or '') ~= ARGV[2] then
+	return 0
+end
+redis.call('SET', KEYS[3], ARGV[3], 'EX', ARGV[4])
+return 1
+`)
+
+// Cache 用户数据范围缓存：进程内缓存 + Redis 缓存
+// 角色、部门等变更时递增 Redis 中的版本并删除用户缓存，并通过 Redis 发布订阅通知所有实例清理进程内缓存
+// Redis 不可用时仅使用进程内缓存
+type Cache struct {
+	rdb        *redis.Client
+	logger     *zap.Logger
+	local      sync.Map     // userId -> *cacheEntry
+	generation atomic.Int64 // 每次失效递增，丢弃失效前开始计算的结果
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+type cacheEntry struct {
+	scope    *Scope
+	expireAt time.Time
+}
+
+// redisCacheEntry Redis 中的用户缓存，Version 为计算时的全局版本
+type redisCacheEntry struct {
+	Version string `json:"version"`
+	Scope   *Scope `json:"scope"`
+}
+
+// Generation 缓存版本，计算数据范围前获取，写入缓存时传入，期间发生过失效时不写入
+type Generation struct {
+	local       int64
+	version     string // Redis 中的全局版本
+	userVersion string // Redis 中的用户版本
+	redisOK     bool   // 是否读取到 Redis 中的版本，读取失败时不写入 Redis
+}
+
+// NewCache 创建数据范围缓存，并订阅失效通知
+func NewCache(rdb *redis.Client, logger *zap.Logger) *Cache {
+	c := &Cache{rdb: rdb, logger: logger, done: make(chan struct{})}
+	if rdb == nil {
+		close(c.done)
+		return c
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go c.subscribe(ctx)
+	return c
+}
+
+// Stop 停止订阅失效通知，服务停止时调用
+func (c *Cache) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	<-c.done
+}
+
+// Generation 返回用户当前的缓存版本，计算数据范围前获取，写入缓存时传入
+func (c *Cache) Generation(ctx context.Context, userId int64) Generation {
+	g := Generation{local: c.generation.Load()}
+	if c.rdb == nil {
+		return g
+	}
+	values, err := c.rdb.MGet(ctx, CacheVersionKey, userVersionKey(userId)).Result()
+	if err != nil {
+		c.logger.Warn("Failed to get data scope cache version", zap.Int64("user_id", userId), zap.Error(err))
+		return g
+	}
+	g.version, _ = values[0].(string)
+	g.userVersion, _ = values[1].(string)
+	g.redisOK = true
+	return g
+}
+
+// Get 获取用户的数据范围，依次读取进程内缓存与 Redis 缓存
+func (c *Cache) Get(ctx context.Context, userId int64) (*Scope, bool) {
+	if v, ok := c.local.Load(userId); ok {
+		entry := v.(*cacheEntry)
+		if time.Now().Before(entry.expireAt) {
+			return entry.scope, true
+		}
+		c.local.Delete(userId)
+	}
+	if c.rdb == nil {
+		return nil, false
+	}
+
+	generation := c.generation.Load()
+	values, err := c.rdb.MGet(ctx, CacheVersionKey, userCacheKey(userId)).Result()
+	if err != nil {
+		c.logger.Warn("Failed to get data scope cache", zap.Int64("user_id", userId), zap.Error(err))
+		return nil, false
+	}
+	data, ok := values[1].(string)
+	if !ok {
+		return nil, false
+	}
+	var entry redisCacheEntry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil || entry.Scope == nil {
+		c.logger.Warn("Invalid data scope cache", zap.Int64("user_id", userId), zap.Error(err))
+		return nil, false
+	}
+	// 全部失效后写入的缓存才有效
+	if version, _ := values[0].(string); entry.Version != version {
+		return nil, false
+	}
+	c.setLocal(userId, entry.Scope, generation)
+	return entry.Scope, true
+}
+
+// Set 缓存用户的数据范围，generation 与当前版本不一致时说明期间发生过失效，不缓存
+// 进程内缓存与 Redis 缓存分别按各自的版本判断
+func (c *Cache) Set(ctx context.Context, userId int64, scope *Scope, generation Generation) {
+	c.setLocal(userId, scope, generation.local)
+	if c.rdb == nil || !generation.redisOK {
+		return
+	}
+	data, err := json.Marshal(&redisCacheEntry{Version: generation.version, Scope: scope})
+	if err != nil {
+		return
+	}
+	keys := []string{CacheVersionKey, userVersionKey(userId), userCacheKey(userId)}
+	if err := setCacheScript.Run(ctx, c.rdb, keys, generation.version, generation.userVersion, data, int(cacheTTL.Seconds())).Err(); err != nil {
+		c.logger.Warn("Failed to set data scope cache", zap.Int64("user_id", userId), zap.Error(err))
+	}
+}
+
+func (c *Cache) setLocal(userId int64, scope *Scope, generation int64) {
+	if generation != c.generation.Load() {
+		return
+	}
+	c.local.Store(userId, &cacheEntry{scope: scope, expireAt: time.Now().Add(localCacheTTL)})
+}
+
+// InvalidateUser 使指定用户的数据范围失效，用于用户角色、所属部门变更
+func (c *Cache) InvalidateUser(ctx context.Context, userIds ...int64) {
+	if len(userIds) == 0 {
+		return
+	}
+	fields := make([]string, len(userIds))
+	for i, id := range userIds {
+		fields[i] = strconv.FormatInt(id, 10)
+	}
+	c.clearLocal(fields)
+	if c.rdb == nil {
+		return
+	}
+	// 递增用户版本，正在计算的结果不再写入；版本与缓存同时过期
+	pipe := c.rdb.TxPipeline()
+	for _, id := range userIds {
+		pipe.Incr(ctx, userVersionKey(id))
+		pipe.Expire(ctx, userVersionKey(id), cacheTTL)
+		pipe.Del(ctx, userCacheKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Warn("Failed to delete data scope cache", zap.Strings("user_ids", fields), zap.Error(err))
+	}
+	c.publish(ctx, strings.Join(fields, ","))
+}
+
+// InvalidateAll 使所有用户的数据范围失效，用于角色数据权限、状态及部门树变更
+// 递增全局版本，旧版本的用户缓存不再使用，到期后自动删除
+func (c *Cache) InvalidateAll(ctx context.Context) {
+	c.clearLocal([]string{invalidateAll})
+	if c.rdb == nil {
+		return
+	}
+	if err := c.rdb.Incr(ctx, CacheVersionKey).Err(); err != nil {
+		c.logger.Warn("Failed to delete data scope cache", zap.Error(err))
+	}
+	c.publish(ctx, invalidateAll)
+}
+
+func (c *Cache) publish(ctx context.Context, message string) {
+	if err := c.rdb.Publish(ctx, CacheChannel, message).Err(); err != nil {
+		c.logger.Warn("Failed to publish data scope invalidation", zap.Error(err))
+	}
+}
+
+// clearLocal 清理进程内缓存，fields 为用户编号或 *
+func (c *Cache) clearLocal(fields []string) {
+	c.generation.Add(1)
+	for _, field := range fields {
+		if field == invalidateAll {
+			c.local.Clear()
+			return
+		}
+		if id, err := strconv.ParseInt(field, 10, 64); err == nil {
+			c.local.Delete(id)
+		}
+	}
+}
+
+// subscribe 订阅其他实例的失效通知，go-redis 会在连接断开后自动重新订阅
+func (c *Cache) subscribe(ctx context.Context) {
+	defer close(c.done)
+	pubsub := c.rdb.Subscribe(ctx, CacheChannel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			c.clearLocal(strings.Split(msg.Payload, ","))
+		}
+	}
+}
+
+func userCacheKey(userId int64) string {
+	return CacheKeyPrefix + strconv.FormatInt(userId, 10)
+}
+
+func userVersionKey(userId int64) string {
+	return CacheUserVersionKeyPrefix + strconv.FormatInt(userId, 10)
+}
@@ -0,0 +1,140 @@
+package datascope
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func TestCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(nil, zap.NewNop())
+
+	c.Set(ctx, 1, &Scope{DeptIDs: []int64{10}}, c.Generation(ctx, 1))
+	c.Set(ctx, 2, &Scope{All: true}, c.Generation(ctx, 2))
+	if scope, ok := c.Get(ctx, 1); !ok || len(scope.DeptIDs) != 1 {
+		t.Fatalf("expected cached scope, got %+v, %v", scope, ok)
+	}
+
+	c.InvalidateUser(ctx, 1)
+	if _, ok := c.Get(ctx, 1); ok {
+		t.Fatal("expected user 1 invalidated")
+	}
+	if _, ok := c.Get(ctx, 2); !ok {
+		t.Fatal("expected user 2 still cached")
+	}
+
+	c.InvalidateAll(ctx)
+	if _, ok := c.Get(ctx, 2); ok {
+		t.Fatal("expected all invalidated")
+	}
+}
+
+func TestCacheDiscardsStaleResult(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(nil, zap.NewNop())
+
+	// 计算数据范围期间发生失效，计算结果可能已过期，不写入缓存
+	generation := c.Generation(ctx, 1)
+	c.InvalidateAll(ctx)
+	c.Set(ctx, 1, &Scope{All: true}, generation)
+	if _, ok := c.Get(ctx, 1); ok {
+		t.Fatal("expected stale scope discarded")
+	}
+}
+
+// newTestRedis 创建基于 miniredis 的 Redis 客户端
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+// newTestCache 创建使用 Redis 的缓存，测试结束时停止订阅
+func newTestCache(t *testing.T, rdb *redis.Client) *Cache {
+	t.Helper()
+	c := NewCache(rdb, zap.NewNop())
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func TestCacheRedisPerUserKey(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	a, b := newTestCache(t, rdb), newTestCache(t, rdb)
+
+	a.Set(ctx, 1, &Scope{DeptIDs: []int64{10}}, a.Generation(ctx, 1))
+	a.Set(ctx, 2, &Scope{All: true}, a.Generation(ctx, 2))
+	if ttl := mr.TTL(userCacheKey(1)); ttl != cacheTTL {
+		t.Fatalf("user cache ttl = %v, want %v", ttl, cacheTTL)
+	}
+	if scope, ok := b.Get(ctx, 1); !ok || len(scope.DeptIDs) != 1 {
+		t.Fatalf("expected scope shared through redis, got %+v, %v", scope, ok)
+	}
+
+	// 用户失效只删除该用户的缓存
+	a.InvalidateUser(ctx, 1)
+	if mr.Exists(userCacheKey(1)) || !mr.Exists(userCacheKey(2)) {
+		t.Fatal("expected only user 1 deleted from redis")
+	}
+
+	// 全部失效后，旧版本的缓存不再使用
+	a.InvalidateAll(ctx)
+	if _, ok := newTestCache(t, rdb).Get(ctx, 2); ok {
+		t.Fatal("expected cache of the old version ignored")
+	}
+}
+
+func TestCacheRedisDiscardsStaleResult(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	a, b := newTestCache(t, rdb), newTestCache(t, rdb)
+
+	// 实例 A 计算期间，实例 B 使该用户失效，A 的计算结果不写入 Redis
+	generation := a.Generation(ctx, 1)
+	b.InvalidateUser(ctx, 1)
+	a.Set(ctx, 1, &Scope{All: true}, generation)
+	if _, ok := newTestCache(t, rdb).Get(ctx, 1); ok {
+		t.Fatal("expected stale scope not written to redis")
+	}
+
+	// 全部失效同样丢弃
+	generation = a.Generation(ctx, 1)
+	b.InvalidateAll(ctx)
+	a.Set(ctx, 1, &Scope{All: true}, generation)
+	if _, ok := newTestCache(t, rdb).Get(ctx, 1); ok {
+		t.Fatal("expected stale scope not written to redis after invalidating all")
+	}
+
+	// 其他用户的失效不影响写入
+	generation = a.Generation(ctx, 1)
+	b.InvalidateUser(ctx, 2)
+	a.Set(ctx, 1, &Scope{All: true}, generation)
+	if _, ok := newTestCache(t, rdb).Get(ctx, 1); !ok {
+		t.Fatal("expected scope written when another user is invalidated")
+	}
+}
+
+func TestCacheStop(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	c := NewCache(rdb, zap.NewNop())
+	done := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	// 未使用 Redis 时 Stop 立即返回
+	NewCache(nil, zap.NewNop()).Stop()
+}
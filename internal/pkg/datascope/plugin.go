@@ -2,33 +2,40 @@ package datascope
 
 import (
 	"context"
-	"fmt"
+	"reflect"
 
 	"github.com/wxlbd/admin-go/internal/consts"
-	"github.com/wxlbd/admin-go/internal/service/system"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
 	pkgcontext "github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/errors"
 
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
+// ErrDataScopeDenied 更新、删除的数据超出当前用户的数据权限
+var ErrDataScopeDenied = errors.NewBizError(errors.ForbiddenCode, "没有权限操作该数据")
+
 // Plugin GORM数据权限插件
+// 对有数据权限规则的表生效（见 RegisterRule、模型的 DataScopeRule 方法及按 dept_id 字段推断的默认规则），
+// 按登录用户角色的数据范围，追加「部门字段 IN 可访问部门 OR 用户字段 IN 可访问用户」条件
 type Plugin struct {
-	logger        *zap.Logger
-	permissionSvc *system.PermissionService
-	deptSvc       *system.DeptService
+	logger *zap.Logger
+	q      *query.Query
+	cache  *Cache
 }
 
 // NewPlugin 创建数据权限插件
-func NewPlugin(
-	logger *zap.Logger,
-	permissionSvc *system.PermissionService,
-	deptSvc *system.DeptService,
-) *Plugin {
+// 角色、部门等数据直接查询，不依赖业务 Service，以便业务层可以引用本包跳过数据权限及使缓存失效
+func NewPlugin(db *gorm.DB, logger *zap.Logger, cache *Cache) *Plugin {
 	return &Plugin{
-		logger:        logger,
-		permissionSvc: permissionSvc,
-		deptSvc:       deptSvc,
+		logger: logger,
+		q:      query.Use(db),
+		cache:  cache,
 	}
 }
 
@@ -40,199 +47,330 @@ func (p *Plugin) Name() string {
 // Initialize 初始化插件，注册GORM回调
 func (p *Plugin) Initialize(db *gorm.DB) error {
 	// 在查询前检查并应用数据权限
-	return db.Callback().Query().Before("gorm:query").
-		Register("datascope:before_query", p.beforeQuery)
+	if err := db.Callback().Query().Before("gorm:query").
+		Register("datascope:before_query", p.beforeQuery); err != nil {
+		return err
+	}
+	// 在更新、删除前校验目标数据均在数据权限内
+	if err := db.Callback().Update().Before("gorm:update").
+		Register("datascope:before_update", p.beforeUpdate); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").
+		Register("datascope:before_delete", p.beforeUpdate)
 }
 
 // beforeQuery 在查询前应用数据权限过滤
 func (p *Plugin) beforeQuery(db *gorm.DB) {
-	ctx := db.Statement.Context
-	if ctx == nil {
+	if condition := p.buildCondition(db); condition != nil {
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition}})
+	}
+}
+
+// beforeUpdate 在更新、删除前校验数据权限
+// 命中的数据超出数据权限时返回 ErrDataScopeDenied，而不是静默地只更新权限内的数据
+func (p *Plugin) beforeUpdate(db *gorm.DB) {
+	if db.Error != nil || db.Statement.SQL.Len() > 0 {
+		return
+	}
+	condition := p.buildCondition(db)
+	if condition == nil {
 		return
 	}
 
+	// 没有条件且模型没有主键值时，GORM 会以 ErrMissingWhereClause 拒绝执行，不在此处查询
+	primaryColumn, primaryValues := p.primaryCondition(db)
+	_, hasWhere := db.Statement.Clauses["WHERE"]
+	if !hasWhere && len(primaryValues) == 0 && !db.AllowGlobalUpdate {
+		return
+	}
+
+	// 1. 统计命中但不在数据权限内的数据
+	tx := db.Session(&gorm.Session{NewDB: true, Context: SkipDataScope(db.Statement.Context)}).
+		Model(reflect.New(db.Statement.Schema.ModelType).Interface())
+	if where, ok := db.Statement.Clauses["WHERE"].Expression.(clause.Where); ok {
+		tx.Statement.AddClause(clause.Where{Exprs: where.Exprs})
+	}
+	if len(primaryValues) > 0 {
+		tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: primaryColumn, Values: primaryValues}}})
+	}
+	// 字段为 NULL 时条件结果为 NULL，NOT 后仍为 NULL 会漏掉这些数据，先按 FALSE 处理
+	var count int64
+	if err := tx.Where(clause.Expr{SQL: "NOT COALESCE(?, FALSE)", Vars: []interface{}{condition}}).Count(&count).Error; err != nil {
+		_ = db.AddError(err)
+		return
+	}
+	if count > 0 {
+		p.logger.Warn("Data scope denied",
+			zap.String("table", db.Statement.Table),
+			zap.Int64("count", count))
+		_ = db.AddError(ErrDataScopeDenied)
+		return
+	}
+
+	// 2. 同时追加数据权限条件，避免校验与执行之间数据发生变化
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition}})
+}
+
+// primaryCondition 获取模型的主键条件，GORM 会在执行更新、删除时据此追加主键条件
+func (p *Plugin) primaryCondition(db *gorm.DB) (interface{}, []interface{}) {
+	if !db.Statement.ReflectValue.IsValid() {
+		return nil, nil
+	}
+	_, primaryValues := schema.GetIdentityFieldValuesMap(db.Statement.Context, db.Statement.ReflectValue, db.Statement.Schema.PrimaryFields)
+	if len(primaryValues) == 0 {
+		return nil, nil
+	}
+	return schema.ToQueryValues(clause.CurrentTable, db.Statement.Schema.PrimaryFieldDBNames, primaryValues)
+}
+
+// buildCondition 计算当前用户在该表上的数据权限条件，不需要限制时返回 nil
+func (p *Plugin) buildCondition(db *gorm.DB) clause.Expression {
+	ctx := db.Statement.Context
+	if ctx == nil || db.Statement.Schema == nil {
+		return nil
+	}
+
 	// 1. 检查是否跳过数据权限
 	if ShouldSkipDataScope(ctx) {
 		p.logger.Debug("Skipping data scope check (context flag)")
-		return
+		return nil
+	}
+
+	// 2. 只处理有数据权限规则的表
+	rule, ok := lookupRule(db.Statement.Schema)
+	if !ok {
+		return nil
 	}
 
-	// 2. 获取当前登录用户
+	// 3. 获取当前登录用户
 	loginUser := pkgcontext.GetLoginUserFromContext(ctx)
 	if loginUser == nil {
 		// 没有登录用户,不应用数据权限(可能是公开API)
-		return
+		return nil
 	}
 
-	// 3. 数据权限只对管理员（UserType=2）生效，用户端（UserType=1）不需要数据权限过滤
+	// 4. 数据权限只对管理员（UserType=2）生效，用户端（UserType=1）不需要数据权限过滤
 	if loginUser.UserType != consts.UserTypeAdmin {
 		p.logger.Debug("Skipping data scope check (not admin user)",
 			zap.Int64("user_id", loginUser.UserID),
 			zap.Int("user_type", loginUser.UserType))
-		return
+		return nil
 	}
 
-	// 3. 为权限查询创建跳过数据权限的context,避免无限递归
-	// beforeQuery -> IsSuperAdmin -> GetUserRoleIdListByUserId -> beforeQuery (递归)
-	skipCtx := SkipDataScope(ctx)
-
-	// 4. 超级管理员跳过数据权限
-	isSuperAdmin, err := p.permissionSvc.IsSuperAdmin(skipCtx, loginUser.UserID)
+	// 5. 解析用户的数据范围，为权限查询创建跳过数据权限的context,避免无限递归
+	scope, err := p.getScope(SkipDataScope(ctx), loginUser)
 	if err != nil {
-		p.logger.Error("Failed to check super admin", zap.Error(err))
-		return
+		// 无法确定数据范围时不返回任何数据
+		p.logger.Error("Failed to resolve data scope", zap.Error(err), zap.Int64("user_id", loginUser.UserID))
+		return denyCondition
 	}
-	if isSuperAdmin {
-		p.logger.Debug("Skipping data scope check (super admin)",
-			zap.Int64("user_id", loginUser.UserID))
-		return
+	if scope.All {
+		return nil
 	}
 
-	// 5. 获取用户的所有角色
-	roleIDs, err := p.permissionSvc.GetUserRoleIdListByUserId(skipCtx, loginUser.UserID)
-	if err != nil {
-		p.logger.Error("Failed to get user roles", zap.Error(err), zap.Int64("user_id", loginUser.UserID))
-		return
-	}
+	// 6. 根据数据范围生成SQL条件
+	return p.scopeCondition(db, rule, scope, loginUser.UserID)
+}
 
-	if len(roleIDs) == 0 {
-		// 用户没有任何角色,默认只能看到自己创建的数据
-		p.applySelfScope(db, loginUser.UserID)
-		return
-	}
+// denyCondition 没有任何可访问数据时的条件
+var denyCondition = clause.Expr{SQL: "1 = 0"}
 
-	// 6. 计算最宽松的数据范围(数字越小权限越大)
-	// 如果任一角色有全部数据权限,则不限制
-	dataScope, customDeptIDs, err := p.calculateDataScope(skipCtx, roleIDs)
+// getScope 获取用户的数据范围，优先读取缓存
+func (p *Plugin) getScope(ctx context.Context, user *pkgcontext.LoginUser) (*Scope, error) {
+	if scope, ok := p.cache.Get(ctx, user.UserID); ok {
+		return scope, nil
+	}
+	generation := p.cache.Generation(ctx, user.UserID)
+	scope, err := p.resolveScope(ctx, user)
 	if err != nil {
-		p.logger.Error("Failed to calculate data scope", zap.Error(err))
-		return
+		return nil, err
 	}
-
-	// 7. 根据数据范围应用SQL条件
-	p.applyDataScope(db, dataScope, customDeptIDs, loginUser)
+	p.cache.Set(ctx, user.UserID, scope, generation)
+	return scope, nil
 }
 
-// calculateDataScope 计算用户的最宽松数据范围
-func (p *Plugin) calculateDataScope(ctx context.Context, roleIDs []int64) (DataScope, []int64, error) {
-	maxScope := DataScopeSelf // 默认最严格
-	var customDeptIDs []int64
-
-	for _, roleID := range roleIDs {
-		role, err := p.permissionSvc.GetRoleById(ctx, roleID)
+// resolveScope 解析用户的数据范围，多个角色取并集，超级管理员不限制
+func (p *Plugin) resolveScope(ctx context.Context, user *pkgcontext.LoginUser) (*Scope, error) {
+	// 1. 获取用户启用的角色
+	ur := p.q.SystemUserRole
+	var roleIDs []int64
+	if err := ur.WithContext(ctx).Where(ur.UserID.Eq(user.UserID)).Pluck(ur.RoleID, &roleIDs); err != nil {
+		return nil, err
+	}
+	var roles []*model.SystemRole
+	if len(roleIDs) > 0 {
+		r := p.q.SystemRole
+		var err error
+		roles, err = r.WithContext(ctx).Where(r.ID.In(roleIDs...), r.Status.Eq(consts.CommonStatusEnable)).Find()
 		if err != nil {
-			return maxScope, nil, err
+			return nil, err
 		}
+	}
+	if len(roles) == 0 {
+		// 用户没有任何角色,默认只能看到自己的数据
+		return &Scope{UserIDs: []int64{user.UserID}}, nil
+	}
 
-		roleScope := DataScope(role.DataScope)
-
-		// 取最宽松的权限
-		if roleScope < maxScope {
-			maxScope = roleScope
-			// 如果是自定义部门，收集部门ID
-			if roleScope == DataScopeDeptCustom {
-				deptIDs, err := p.permissionSvc.GetRoleDeptIdListByRoleId(ctx, roleID)
-				if err != nil {
-					return maxScope, nil, err
-				}
-				customDeptIDs = append(customDeptIDs, deptIDs...)
-			}
-		} else if roleScope == DataScopeDeptCustom && maxScope == DataScopeDeptCustom {
-			// 多个角色都是自定义部门，合并部门ID
-			deptIDs, err := p.permissionSvc.GetRoleDeptIdListByRoleId(ctx, roleID)
-			if err != nil {
-				return maxScope, nil, err
-			}
-			customDeptIDs = append(customDeptIDs, deptIDs...)
-		}
+	// 2. 超级管理员跳过数据权限
+	if lo.ContainsBy(roles, func(role *model.SystemRole) bool { return role.Code == consts.RoleCodeSuperAdmin }) {
+		p.logger.Debug("Skipping data scope check (super admin)",
+			zap.Int64("user_id", user.UserID))
+		return &Scope{All: true}, nil
 	}
 
-	return maxScope, customDeptIDs, nil
+	// 3. 合并各角色的数据范围
+	result := &Scope{}
+	for _, role := range roles {
+		scope, err := p.resolveRoleScope(ctx, user, role)
+		if err != nil {
+			return nil, err
+		}
+		if scope.All {
+			return scope, nil
+		}
+		result.DeptIDs = append(result.DeptIDs, scope.DeptIDs...)
+		result.UserIDs = append(result.UserIDs, scope.UserIDs...)
+	}
+	result.DeptIDs = lo.Uniq(result.DeptIDs)
+	result.UserIDs = lo.Uniq(result.UserIDs)
+	return result, nil
 }
 
-// applyDataScope 应用数据范围SQL条件
-func (p *Plugin) applyDataScope(db *gorm.DB, scope DataScope, customDeptIDs []int64, user *pkgcontext.LoginUser) {
+// resolveRoleScope 解析单个角色的数据范围，需要部门的数据范围在用户没有部门时降级为仅本人
+func (p *Plugin) resolveRoleScope(ctx context.Context, user *pkgcontext.LoginUser, role *model.SystemRole) (*Scope, error) {
+	self := &Scope{UserIDs: []int64{user.UserID}}
+	scope := DataScope(role.DataScope)
 	switch scope {
 	case DataScopeAll:
-		// 全部数据权限，不添加任何条件
-		p.logger.Debug("Data scope: ALL (no restriction)",
-			zap.Int64("user_id", user.UserID))
-		return
+		return &Scope{All: true}, nil
 
 	case DataScopeDeptCustom:
-		// 自定义部门数据权限
-		if len(customDeptIDs) > 0 {
-			p.applyDeptScope(db, customDeptIDs, "DEPT_CUSTOM", user.UserID)
-		} else {
-			// 没有自定义部门，降级为仅本人
-			p.applySelfScope(db, user.UserID)
+		if len(role.DataScopeDeptIds) == 0 {
+			return self, nil
 		}
+		return &Scope{DeptIDs: role.DataScopeDeptIds}, nil
 
-	case DataScopeDeptOnly:
-		// 本部门数据权限
-		if user.DeptID != nil && *user.DeptID > 0 {
-			p.applyDeptScope(db, []int64{*user.DeptID}, "DEPT_ONLY", user.UserID)
-		} else {
-			// 用户没有部门，降级为仅本人
-			p.applySelfScope(db, user.UserID)
+	case DataScopeDeptOnly, DataScopeDeptAndChild, DataScopeDeptAndChildDepth:
+		deptID, err := p.getUserDeptID(ctx, user)
+		if err != nil || deptID == 0 {
+			return self, err
 		}
-
-	case DataScopeDeptAndChild:
-		// 本部门及子部门数据权限
-		if user.DeptID != nil && *user.DeptID > 0 {
-			deptIDs, err := p.deptSvc.GetDeptIdListByParentId(context.Background(), *user.DeptID)
-			if err != nil {
-				p.logger.Error("Failed to get child dept IDs", zap.Error(err))
-				p.applySelfScope(db, user.UserID)
-				return
+		depth := 0 // 不限层级
+		switch scope {
+		case DataScopeDeptOnly:
+			return &Scope{DeptIDs: []int64{deptID}}, nil
+		case DataScopeDeptAndChildDepth:
+			if depth = role.DataScopeConfig.DeptDepth; depth <= 0 {
+				return &Scope{DeptIDs: []int64{deptID}}, nil
 			}
-			// 包含当前部门
-			allDeptIDs := append([]int64{*user.DeptID}, deptIDs...)
-			p.applyDeptScope(db, allDeptIDs, "DEPT_AND_CHILD", user.UserID)
-		} else {
-			p.applySelfScope(db, user.UserID)
 		}
+		deptIDs, err := p.getDeptAndChildIDs(ctx, deptID, depth)
+		if err != nil {
+			return nil, err
+		}
+		return &Scope{DeptIDs: deptIDs}, nil
 
 	case DataScopeSelf:
-		// 仅本人数据权限
-		p.applySelfScope(db, user.UserID)
+		return self, nil
+
+	case DataScopeSamePost:
+		userIDs, err := p.getSamePostUserIDs(ctx, user.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return &Scope{UserIDs: userIDs}, nil
+	}
+
+	if resolver, ok := lookupScopeResolver(scope); ok {
+		return resolver(ctx, user, role)
+	}
+	p.logger.Warn("Unknown data scope, applying SELF", zap.Int("scope", int(scope)))
+	return self, nil
+}
 
-	default:
-		p.logger.Warn("Unknown data scope, applying SELF", zap.Int("scope", int(scope)))
-		p.applySelfScope(db, user.UserID)
+// getUserDeptID 获取用户的部门，令牌中没有部门时从用户表读取
+func (p *Plugin) getUserDeptID(ctx context.Context, user *pkgcontext.LoginUser) (int64, error) {
+	if user.DeptID != nil {
+		return *user.DeptID, nil
 	}
+	u := p.q.SystemUser
+	var deptIDs []int64
+	if err := u.WithContext(ctx).Where(u.ID.Eq(user.UserID)).Pluck(u.DeptID, &deptIDs); err != nil {
+		return 0, err
+	}
+	if len(deptIDs) == 0 {
+		return 0, nil
+	}
+	return deptIDs[0], nil
 }
 
-// applyDeptScope 应用部门范围条件
-func (p *Plugin) applyDeptScope(db *gorm.DB, deptIDs []int64, scopeType string, userID int64) {
-	if p.hasColumn(db, "dept_id") {
-		db.Where("dept_id IN ?", deptIDs)
-		p.logger.Debug(fmt.Sprintf("Applied data scope: %s", scopeType),
-			zap.Int64("user_id", userID),
-			zap.Int64s("dept_ids", deptIDs))
-	} else {
-		// 表没有dept_id字段，降级为creator过滤
-		p.applySelfScope(db, userID)
+// getDeptAndChildIDs 获取部门及其下级部门，depth 为向下的层级数，小于等于 0 时不限层级
+func (p *Plugin) getDeptAndChildIDs(ctx context.Context, deptID int64, depth int) ([]int64, error) {
+	d := p.q.SystemDept
+	result := []int64{deptID}
+	parentIDs := []int64{deptID}
+	for level := 1; len(parentIDs) > 0 && (depth <= 0 || level <= depth); level++ {
+		var childIDs []int64
+		if err := d.WithContext(ctx).Where(d.ParentID.In(parentIDs...)).Pluck(d.ID, &childIDs); err != nil {
+			return nil, err
+		}
+		// 排除已访问的部门，避免错误数据形成环时死循环
+		childIDs = lo.Without(childIDs, result...)
+		result = append(result, childIDs...)
+		parentIDs = childIDs
 	}
+	return result, nil
 }
 
-// applySelfScope 应用仅本人范围条件
-func (p *Plugin) applySelfScope(db *gorm.DB, userID int64) {
-	if p.hasColumn(db, "creator") {
-		db.Where("creator = ?", userID)
-		p.logger.Debug("Applied data scope: SELF",
-			zap.Int64("user_id", userID))
-	} else {
-		p.logger.Debug("No creator column, skipping data scope",
-			zap.String("table", db.Statement.Table))
+// getSamePostUserIDs 获取与用户担任相同岗位的用户，包含用户本人
+func (p *Plugin) getSamePostUserIDs(ctx context.Context, userID int64) ([]int64, error) {
+	up := p.q.SystemUserPost
+	var postIDs []int64
+	if err := up.WithContext(ctx).Where(up.UserID.Eq(userID)).Pluck(up.PostID, &postIDs); err != nil {
+		return nil, err
+	}
+	userIDs := []int64{userID}
+	if len(postIDs) == 0 {
+		return userIDs, nil
+	}
+	var postUserIDs []int64
+	if err := up.WithContext(ctx).Where(up.PostID.In(postIDs...)).Pluck(up.UserID, &postUserIDs); err != nil {
+		return nil, err
 	}
+	return lo.Uniq(append(userIDs, postUserIDs...)), nil
 }
 
-// hasColumn 检查表是否有指定列
-func (p *Plugin) hasColumn(db *gorm.DB, columnName string) bool {
-	if db.Statement.Schema == nil {
-		return false
+// scopeCondition 根据表的规则生成数据范围条件，部门条件与用户条件之间为 OR
+// 表没有可用的字段时不返回任何数据
+func (p *Plugin) scopeCondition(db *gorm.DB, rule model.DataScopeRule, scope *Scope, userID int64) clause.Expression {
+	table := clause.CurrentTable
+	if rule.TableAlias != "" {
+		table = rule.TableAlias
+	}
+	var exprs []clause.Expression
+	if rule.DeptColumn != "" && len(scope.DeptIDs) > 0 {
+		exprs = append(exprs, clause.IN{Column: clause.Column{Table: table, Name: rule.DeptColumn}, Values: lo.ToAnySlice(scope.DeptIDs)})
+	}
+	userIDs := scope.UserIDs
+	if rule.DeptColumn == "" && len(scope.DeptIDs) > 0 {
+		// 表没有部门字段，部门数据范围降级为仅本人
+		userIDs = lo.Uniq(append([]int64{userID}, userIDs...))
+	}
+	if rule.UserColumn != "" && len(userIDs) > 0 {
+		exprs = append(exprs, clause.IN{Column: clause.Column{Table: table, Name: rule.UserColumn}, Values: lo.ToAnySlice(userIDs)})
+	}
+	p.logger.Debug("Applied data scope",
+		zap.String("table", db.Statement.Table),
+		zap.Int64("user_id", userID),
+		zap.Int64s("dept_ids", scope.DeptIDs),
+		zap.Int64s("user_ids", scope.UserIDs))
+
+	switch len(exprs) {
+	case 0:
+		return denyCondition
+	case 1:
+		return exprs[0]
 	}
-	_, ok := db.Statement.Schema.FieldsByDBName[columnName]
-	return ok
+	return clause.Or(exprs...)
 }
@@ -0,0 +1,120 @@
+package datascope
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	pkgcontext "github.com/wxlbd/admin-go/pkg/context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestPluginDB 创建注册了数据权限插件的内存 SQLite 数据库，当前用户只能访问部门 10
+func newTestPluginDB(t *testing.T) (*gorm.DB, context.Context, *Cache) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接相互独立，只使用一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(&model.SystemUser{}, &model.SystemConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCache(nil, zap.NewNop())
+	if err := db.Use(NewPlugin(db, zap.NewNop(), cache)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), pkgcontext.CtxLoginUserKey, &pkgcontext.LoginUser{
+		UserID:   100,
+		UserType: consts.UserTypeAdmin,
+	})
+	cache.Set(ctx, 100, &Scope{DeptIDs: []int64{10}}, cache.Generation(ctx, 100))
+
+	// 部门 10、部门 20、没有部门（NULL）的用户各一个
+	users := []*model.SystemUser{{Username: "a", DeptID: 10}, {Username: "b", DeptID: 20}, {Username: "c"}}
+	if err := db.WithContext(SkipDataScope(ctx)).Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(SkipDataScope(ctx)).Exec("UPDATE system_users SET dept_id = NULL WHERE id = ?", users[2].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, ctx, cache
+}
+
+func TestPluginBeforeUpdate(t *testing.T) {
+	db, ctx, _ := newTestPluginDB(t)
+
+	tests := []struct {
+		name    string
+		ids     []int64
+		wantErr error
+	}{
+		{"in scope", []int64{1}, nil},
+		{"other dept", []int64{1, 2}, ErrDataScopeDenied},
+		{"null dept", []int64{1, 3}, ErrDataScopeDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.WithContext(ctx).Model(&model.SystemUser{}).Where("id IN ?", tt.ids).Update("nickname", tt.name).Error
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("update err = %v, want %v", err, tt.wantErr)
+			}
+			err = db.WithContext(ctx).Where("id IN ?", tt.ids).Delete(&model.SystemUser{}).Error
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("delete err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 被拒绝的更新、删除没有修改任何数据
+	var count int64
+	if err := db.WithContext(SkipDataScope(ctx)).Model(&model.SystemUser{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("users = %d, want 2 (only the in-scope user deleted)", count)
+	}
+}
+
+func TestPluginBeforeQuery(t *testing.T) {
+	db, ctx, _ := newTestPluginDB(t)
+	var users []*model.SystemUser
+	if err := db.WithContext(ctx).Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Username != "a" {
+		t.Fatalf("users = %+v, want only the user in dept 10", users)
+	}
+}
+
+func TestPluginIgnoresGlobalConfig(t *testing.T) {
+	db, ctx, cache := newTestPluginDB(t)
+	cache.Set(ctx, 100, &Scope{UserIDs: []int64{100}}, cache.Generation(ctx, 100))
+	config := &model.SystemConfig{Category: "biz", Name: "初始密码", ConfigKey: "system.user.init-password", Value: "123456"}
+	config.Creator = "1"
+	if err := db.WithContext(SkipDataScope(ctx)).Create(config).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 仅本人数据范围的用户也能读取其他用户创建的全局配置
+	var configs []*model.SystemConfig
+	if err := db.WithContext(ctx).Find(&configs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].ConfigKey != config.ConfigKey {
+		t.Fatalf("configs = %+v, want the global config", configs)
+	}
+}
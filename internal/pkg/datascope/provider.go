@@ -1,8 +1,6 @@
 package datascope
 
 import (
-	"github.com/wxlbd/admin-go/internal/service/system"
-
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -12,13 +10,8 @@ type PluginRegistered struct{}
 
 // RegisterPlugin 注册数据权限插件到GORM (用于Wire依赖注入)
 // 返回PluginRegistered标记以便Wire知道插件已注册
-func RegisterPlugin(
-	db *gorm.DB,
-	logger *zap.Logger,
-	permissionSvc *system.PermissionService,
-	deptSvc *system.DeptService,
-) (*PluginRegistered, error) {
-	plugin := NewPlugin(logger, permissionSvc, deptSvc)
+func RegisterPlugin(db *gorm.DB, logger *zap.Logger, cache *Cache) (*PluginRegistered, error) {
+	plugin := NewPlugin(db, logger, cache)
 	if err := db.Use(plugin); err != nil {
 		return nil, err
 	}
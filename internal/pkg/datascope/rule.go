@@ -0,0 +1,98 @@
+package datascope
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/wxlbd/admin-go/internal/model"
+	pkgcontext "github.com/wxlbd/admin-go/pkg/context"
+
+	"github.com/samber/lo"
+	"gorm.io/gorm/schema"
+)
+
+// ScopeResolver 自定义数据范围的解析规则，根据登录用户及其角色计算可访问的部门与用户
+type ScopeResolver func(ctx context.Context, user *pkgcontext.LoginUser, role *model.SystemRole) (*Scope, error)
+
+var (
+	rulesMu   sync.RWMutex
+	rules     = make(map[string]model.DataScopeRule)
+	resolvers = make(map[DataScope]ScopeResolver)
+)
+
+// RegisterRule 注册表的数据权限规则，优先于模型 DataScopeRule() 方法声明的规则及按字段推断的默认规则
+// 用于无法修改模型的表，或按需覆盖默认字段
+func RegisterRule(table string, rule model.DataScopeRule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[table] = rule
+}
+
+// RegisterScopeResolver 注册自定义的数据范围类型，角色的 dataScope 为该类型时由 resolver 计算
+// 内置的数据范围类型不能覆盖
+func RegisterScopeResolver(scope DataScope, resolver ScopeResolver) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	resolvers[scope] = resolver
+}
+
+// defaultDeptColumn 默认规则的部门字段
+// 不按 creator 推断用户字段：BaseDO 的每张表都有 creator，推断后全局配置等数据会对仅本人等数据范围不可见
+const defaultDeptColumn = "dept_id"
+
+// DefaultIgnoreTables 不使用默认规则的表：角色、菜单等权限数据及全局配置，登录、鉴权及业务校验时读取，不按数据范围过滤
+// 注册规则或模型声明规则后仍会生效
+var DefaultIgnoreTables = []string{
+	"system_role",
+	"system_menu",
+	"system_role_menu",
+	"system_user_role",
+	"system_tenant",
+	"system_tenant_package",
+	"system_dict_type",
+	"system_dict_data",
+	"infra_config",
+	"infra_file_config",
+	"system_oauth2_client",
+	"system_notify_template",
+	"system_mail_account",
+	"system_mail_template",
+	"system_sms_channel",
+	"system_sms_template",
+	"system_social_client",
+}
+
+// lookupRule 获取表的数据权限规则，依次取注册的规则、模型声明的规则
+// 都没有时有 dept_id 字段的表按部门过滤，其他表及忽略的表不参与数据权限过滤
+func lookupRule(s *schema.Schema) (model.DataScopeRule, bool) {
+	rulesMu.RLock()
+	rule, ok := rules[s.Table]
+	rulesMu.RUnlock()
+	if ok {
+		return rule, true
+	}
+	if m, ok := reflect.New(s.ModelType).Interface().(interface{ DataScopeRule() model.DataScopeRule }); ok {
+		return m.DataScopeRule(), true
+	}
+	return defaultRule(s)
+}
+
+// defaultRule 按字段推断表的数据权限规则，只推断部门字段，需要按用户过滤的表注册规则或在模型中声明
+func defaultRule(s *schema.Schema) (model.DataScopeRule, bool) {
+	if lo.Contains(DefaultIgnoreTables, s.Table) {
+		return model.DataScopeRule{}, false
+	}
+	if _, ok := s.FieldsByDBName[defaultDeptColumn]; !ok {
+		return model.DataScopeRule{}, false
+	}
+	return model.DataScopeRule{DeptColumn: defaultDeptColumn}, true
+}
+
+// lookupScopeResolver 获取自定义的数据范围解析规则
+func lookupScopeResolver(scope DataScope) (ScopeResolver, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	resolver, ok := resolvers[scope]
+	return resolver, ok
+}
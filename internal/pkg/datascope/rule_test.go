@@ -0,0 +1,121 @@
+package datascope
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/model"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// newDryRunDB 创建只生成 SQL、不连接数据库的 DB
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "root:root@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// buildSQL 生成表达式的 SQL
+func buildSQL(db *gorm.DB, expr clause.Expression) string {
+	stmt := &gorm.Statement{DB: db, Clauses: map[string]clause.Clause{}}
+	expr.Build(stmt)
+	return stmt.SQL.String()
+}
+
+// testPlainModel 没有部门字段的表
+type testPlainModel struct {
+	ID   int64
+	Name string
+}
+
+// testDeptModel 有部门字段的表
+type testDeptModel struct {
+	ID     int64
+	DeptID int64
+	model.BaseDO
+}
+
+func TestLookupRule(t *testing.T) {
+	db := newDryRunDB(t)
+
+	// 模型声明的规则
+	stmt := db.Model(&model.SystemUser{}).Statement
+	if err := stmt.Parse(stmt.Model); err != nil {
+		t.Fatal(err)
+	}
+	rule, ok := lookupRule(stmt.Schema)
+	if !ok || rule.DeptColumn != "dept_id" || rule.UserColumn != "id" {
+		t.Fatalf("unexpected rule for system_users: %+v, %v", rule, ok)
+	}
+
+	// 未声明规则的表按 dept_id 字段推断，忽略的表及没有 dept_id 字段的表不参与数据权限过滤，不按 creator 推断
+	for _, m := range []any{&model.SystemRole{}, &model.SystemConfig{}, &model.SystemNotice{}, &testPlainModel{}} {
+		stmt = db.Model(m).Statement
+		if err := stmt.Parse(stmt.Model); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := lookupRule(stmt.Schema); ok {
+			t.Fatalf("expected no rule for %s", stmt.Schema.Table)
+		}
+	}
+	stmt = db.Model(&testDeptModel{}).Statement
+	if err := stmt.Parse(stmt.Model); err != nil {
+		t.Fatal(err)
+	}
+	rule, ok = lookupRule(stmt.Schema)
+	if !ok || rule.DeptColumn != "dept_id" || rule.UserColumn != "" {
+		t.Fatalf("unexpected default rule for %s: %+v, %v", stmt.Schema.Table, rule, ok)
+	}
+
+	// 注册的规则优先于默认规则
+	RegisterRule(stmt.Schema.Table, model.DataScopeRule{UserColumn: "owner_user_id"})
+	defer func() {
+		rulesMu.Lock()
+		delete(rules, stmt.Schema.Table)
+		rulesMu.Unlock()
+	}()
+	rule, ok = lookupRule(stmt.Schema)
+	if !ok || rule.UserColumn != "owner_user_id" {
+		t.Fatalf("unexpected registered rule: %+v, %v", rule, ok)
+	}
+}
+
+func TestScopeCondition(t *testing.T) {
+	db := newDryRunDB(t)
+	p := NewPlugin(db, zap.NewNop(), NewCache(nil, zap.NewNop()))
+	tx := db.Model(&model.SystemUser{})
+
+	// 部门条件与用户条件之间为 OR，按别名限定字段
+	rule := model.DataScopeRule{DeptColumn: "dept_id", UserColumn: "contact_user_id", TableAlias: "c"}
+	sql := buildSQL(db, p.scopeCondition(tx, rule, &Scope{DeptIDs: []int64{1, 2}, UserIDs: []int64{3}}, 3))
+	if !strings.Contains(sql, "`c`.`dept_id` IN (?,?)") || !strings.Contains(sql, " OR ") ||
+		!strings.Contains(sql, "`c`.`contact_user_id` = ?") {
+		t.Fatalf("unexpected condition: %s", sql)
+	}
+
+	// 表没有部门字段，部门数据范围降级为仅本人
+	rule = model.DataScopeRule{UserColumn: "creator"}
+	if sql := buildSQL(db, p.scopeCondition(tx, rule, &Scope{DeptIDs: []int64{1}}, 3)); !strings.HasSuffix(sql, "`creator` = ?") {
+		t.Fatalf("expected self condition, got: %s", sql)
+	}
+
+	// 表没有用户字段，仅本人的数据范围不返回任何数据
+	rule = model.DataScopeRule{DeptColumn: "dept_id"}
+	if sql := buildSQL(db, p.scopeCondition(tx, rule, &Scope{UserIDs: []int64{3}}, 3)); sql != "1 = 0" {
+		t.Fatalf("expected deny condition, got: %s", sql)
+	}
+}
@@ -2,19 +2,30 @@ package datascope
 
 import (
 	"context"
+
+	"github.com/wxlbd/admin-go/internal/consts"
 )
 
 // DataScope 数据范围类型
 type DataScope int
 
 const (
-	DataScopeAll          DataScope = 1 // 全部数据权限
-	DataScopeDeptCustom   DataScope = 2 // 指定部门数据权限
-	DataScopeDeptOnly     DataScope = 3 // 本部门数据权限
-	DataScopeDeptAndChild DataScope = 4 // 本部门及以下数据权限
-	DataScopeSelf         DataScope = 5 // 仅本人数据权限
+	DataScopeAll               DataScope = consts.DataScopeAll               // 全部数据权限
+	DataScopeDeptCustom        DataScope = consts.DataScopeDeptCustom        // 指定部门数据权限
+	DataScopeDeptOnly          DataScope = consts.DataScopeDeptOnly          // 本部门数据权限
+	DataScopeDeptAndChild      DataScope = consts.DataScopeDeptAndChild      // 本部门及以下数据权限
+	DataScopeSelf              DataScope = consts.DataScopeSelf              // 仅本人数据权限
+	DataScopeSamePost          DataScope = consts.DataScopeSamePost          // 同岗位数据权限
+	DataScopeDeptAndChildDepth DataScope = consts.DataScopeDeptAndChildDepth // 本部门及以下 N 级数据权限
 )
 
+// Scope 用户解析后的数据范围：可访问的部门与用户，多个角色取并集
+type Scope struct {
+	All     bool    `json:"all,omitempty"`     // 全部数据，不限制
+	DeptIDs []int64 `json:"deptIds,omitempty"` // 可访问的部门
+	UserIDs []int64 `json:"userIds,omitempty"` // 可访问的用户
+}
+
 // Context Keys
 type contextKey string
 
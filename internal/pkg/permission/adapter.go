@@ -1,7 +1,11 @@
 package permission
 
 import (
-	"fmt"
+	"context"
+	"sync/atomic"
+
+	internalModel "github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/tenant"
 
 	"github.com/casbin/casbin/v2/model"
 	"github.com/casbin/casbin/v2/persist"
@@ -9,87 +13,189 @@ import (
 )
 
 // YudoAdapter 自定义 Casbin 适配器
-// 直接从 system_role_menu 和 system_user_role 表加载策略
+// 直接从 system_role_menu 和 system_user_role 表加载策略，并加载各租户套餐内的权限标识
 type YudoAdapter struct {
 	db *gorm.DB
+	// tenantPermissions 租户域 -> 套餐内的权限标识，值为 nil 表示不限制（系统租户）
+	tenantPermissions atomic.Pointer[map[string]map[string]struct{}]
 }
 
-// NewAdapter 创建适配器
+// NewAdapter 创建适配器，策略包含全部租户，加载时跳过租户隔离
 func NewAdapter(db *gorm.DB) *YudoAdapter {
-	return &YudoAdapter{db: db}
+	return &YudoAdapter{db: db.WithContext(tenant.SkipTenant(context.Background()))}
 }
 
 // LoadPolicy 从数据库加载策略
 func (a *YudoAdapter) LoadPolicy(model model.Model) error {
-	// 1. 加载角色-权限策略 (p, role, permission, access)
+	// 1. 加载角色-权限策略 (p, role, tenant, permission, access)
 	// 对应 system_role_menu + system_menu
-	if err := a.loadRolePolicy(model); err != nil {
+	rolePolicies, err := a.LoadRolePolicies()
+	if err != nil {
 		return err
 	}
 
-	// 2. 加载用户-角色策略 (g, userId, role)
+	// 2. 加载用户-角色策略 (g, userId, role, tenant)
 	// 对应 system_user_role
-	if err := a.loadUserRolePolicy(model); err != nil {
+	userRolePolicies, err := a.LoadUserRolePolicies()
+	if err != nil {
 		return err
 	}
 
+	// 3. 加载租户套餐内的权限标识
+	tenantPermissions, err := a.loadTenantPermissions()
+	if err != nil {
+		return err
+	}
+	a.tenantPermissions.Store(&tenantPermissions)
+
+	for _, rule := range rolePolicies {
+		if err := persist.LoadPolicyArray(append([]string{"p"}, rule...), model); err != nil {
+			return err
+		}
+	}
+	for _, rule := range userRolePolicies {
+		if err := persist.LoadPolicyArray(append([]string{"g"}, rule...), model); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (a *YudoAdapter) loadRolePolicy(model model.Model) error {
+// LoadRolePolicies 加载角色-权限策略，roleIds 为空时加载全部角色
+func (a *YudoAdapter) LoadRolePolicies(roleIds ...int64) ([][]string, error) {
 	// 查询 SQL: 用于获取 角色ID -> 权限标识 的映射
 	// 过滤掉 permission 为空的菜单（目录等）
 	type Result struct {
 		RoleID     int64
+		TenantID   int64
 		Permission string
 	}
 
 	// 联表查询：system_role <-> system_role_menu <-> system_menu
 	// 使用 DISTINCT 去重
 	var results []Result
-	err := a.db.Table("system_role_menu srm").
-		Select("DISTINCT srm.role_id, sm.permission").
+	tx := a.db.Table("system_role_menu srm").
+		Select("DISTINCT srm.role_id, sr.tenant_id, sm.permission").
 		Joins("JOIN system_role sr ON sr.id = srm.role_id").
 		Joins("JOIN system_menu sm ON sm.id = srm.menu_id").
-		Where("sm.permission != '' AND sm.deleted = 0 AND sr.deleted = 0 AND srm.deleted = 0").
-		Scan(&results).Error
-
-	if err != nil {
-		return err
+		Where("sm.permission != '' AND sm.deleted = 0 AND sr.deleted = 0 AND srm.deleted = 0")
+	if len(roleIds) > 0 {
+		tx = tx.Where("srm.role_id IN ?", roleIds)
+	}
+	if err := tx.Scan(&results).Error; err != nil {
+		return nil, err
 	}
 
+	// 策略: p, role_id, tenant_id, permission, access
+	rules := make([][]string, 0, len(results))
 	for _, line := range results {
-		// 添加策略: p, role_id, permission, access
-		// 使用 role_id 确保多租户下的唯一性
-		persist.LoadPolicyLine(fmt.Sprintf("p, role:%d, %s, access", line.RoleID, line.Permission), model)
+		rules = append(rules, []string{RoleSubject(line.RoleID), TenantDomain(line.TenantID), line.Permission, PolicyActionAccess})
 	}
-	return nil
+	return rules, nil
 }
 
-func (a *YudoAdapter) loadUserRolePolicy(model model.Model) error {
+// LoadUserRolePolicies 加载用户-角色策略，userIds 为空时加载全部用户
+func (a *YudoAdapter) LoadUserRolePolicies(userIds ...int64) ([][]string, error) {
 	// 查询 SQL: 获取 用户ID -> 角色ID 的映射
 	type Result struct {
-		UserID int64
-		RoleID int64
+		UserID   int64
+		RoleID   int64
+		TenantID int64
 	}
 
 	var results []Result
-	err := a.db.Table("system_user_role sur").
-		Select("sur.user_id, sur.role_id").
+	tx := a.db.Table("system_user_role sur").
+		Select("sur.user_id, sur.role_id, sr.tenant_id").
 		Joins("JOIN system_role sr ON sr.id = sur.role_id").
-		Where("sur.deleted = 0 AND sr.deleted = 0").
-		Scan(&results).Error
-
-	if err != nil {
-		return err
+		Where("sur.deleted = 0 AND sr.deleted = 0")
+	if len(userIds) > 0 {
+		tx = tx.Where("sur.user_id IN ?", userIds)
+	}
+	if err := tx.Scan(&results).Error; err != nil {
+		return nil, err
 	}
 
+	// 分组策略: g, userId, role_id, tenant_id
+	rules := make([][]string, 0, len(results))
 	for _, line := range results {
-		// 添加分组策略: g, userId, role_id
-		persist.LoadPolicyLine(fmt.Sprintf("g, user:%d, role:%d", line.UserID, line.RoleID), model)
+		rules = append(rules, []string{UserSubject(line.UserID), RoleSubject(line.RoleID), TenantDomain(line.TenantID)})
 	}
+	return rules, nil
+}
 
-	return nil
+// loadTenantPermissions 加载各租户套餐内的权限标识，套餐编号为 0 的系统租户不限制
+func (a *YudoAdapter) loadTenantPermissions() (map[string]map[string]struct{}, error) {
+	var tenants []internalModel.SystemTenant
+	if err := a.db.Select("id", "package_id").Find(&tenants).Error; err != nil {
+		return nil, err
+	}
+	var packages []internalModel.SystemTenantPackage
+	if err := a.db.Select("id", "menu_ids").Find(&packages).Error; err != nil {
+		return nil, err
+	}
+	type Menu struct {
+		ID         int64
+		Permission string
+	}
+	var menus []Menu
+	if err := a.db.Table("system_menu").
+		Select("id, permission").
+		Where("permission != '' AND deleted = 0").
+		Scan(&menus).Error; err != nil {
+		return nil, err
+	}
+
+	menuPermissions := make(map[int64]string, len(menus))
+	for _, menu := range menus {
+		menuPermissions[menu.ID] = menu.Permission
+	}
+	packagePermissions := make(map[int64]map[string]struct{}, len(packages))
+	for _, pkg := range packages {
+		permissions := make(map[string]struct{}, len(pkg.MenuIDs))
+		for _, menuId := range pkg.MenuIDs {
+			if permission, ok := menuPermissions[menuId]; ok {
+				permissions[permission] = struct{}{}
+			}
+		}
+		packagePermissions[pkg.ID] = permissions
+	}
+
+	result := make(map[string]map[string]struct{}, len(tenants))
+	for _, tenant := range tenants {
+		if tenant.PackageID == 0 {
+			result[TenantDomain(tenant.ID)] = nil
+			continue
+		}
+		// 套餐不存在时没有任何权限
+		permissions := packagePermissions[tenant.PackageID]
+		if permissions == nil {
+			permissions = map[string]struct{}{}
+		}
+		result[TenantDomain(tenant.ID)] = permissions
+	}
+	return result, nil
+}
+
+// tenantPermitted Casbin 函数 tenantPermitted(dom, obj)：权限是否在租户套餐的菜单范围内
+func (a *YudoAdapter) tenantPermitted(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return false, nil
+	}
+	dom, _ := args[0].(string)
+	obj, _ := args[1].(string)
+	tenantPermissions := a.tenantPermissions.Load()
+	if tenantPermissions == nil {
+		return false, nil
+	}
+	permissions, ok := (*tenantPermissions)[dom]
+	if !ok {
+		return false, nil
+	}
+	if permissions == nil {
+		return true, nil
+	}
+	_, ok = permissions[obj]
+	return ok, nil
 }
 
 // SavePolicy 保存策略 (只读，不需要实现)
@@ -1,6 +1,7 @@
 package permission
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/casbin/casbin/v2"
@@ -8,26 +9,46 @@ import (
 	"gorm.io/gorm"
 )
 
-// CasbinRBACModel 定义 RBAC 模型
+// CasbinRBACModel 定义带租户域的 RBAC 模型
+// 用户、角色及其权限都限定在租户域内，并且权限必须在租户套餐的菜单范围内（tenantPermitted）
 const CasbinRBACModel = `
 [request_definition]
-r = sub, obj, act
+r = sub, dom, obj, act
 
 [policy_definition]
-p = sub, obj, act
+p = sub, dom, obj, act
 
 [role_definition]
-g = _, _
+g = _, _, _
 
 [policy_effect]
 e = some(where (p.eft == allow))
 
 [matchers]
-m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act
+m = g(r.sub, p.sub, r.dom) && r.dom == p.dom && r.obj == p.obj && r.act == p.act && tenantPermitted(r.dom, r.obj)
 `
 
+// PolicyActionAccess 权限策略的操作，权限标识只区分有无访问权限
+const PolicyActionAccess = "access"
+
+// UserSubject 用户的策略主体
+func UserSubject(userId int64) string {
+	return fmt.Sprintf("user:%d", userId)
+}
+
+// RoleSubject 角色的策略主体
+func RoleSubject(roleId int64) string {
+	return fmt.Sprintf("role:%d", roleId)
+}
+
+// TenantDomain 租户的策略域
+func TenantDomain(tenantId int64) string {
+	return fmt.Sprintf("tenant:%d", tenantId)
+}
+
 // InitEnforcer 初始化 Casbin Enforcer
-func InitEnforcer(db *gorm.DB) (*casbin.Enforcer, error) {
+// 策略会被 PolicySyncer 在运行时更新，使用并发安全的 SyncedEnforcer
+func InitEnforcer(db *gorm.DB) (*casbin.SyncedEnforcer, error) {
 	// 1. 加载模型
 	m, err := model.NewModelFromString(CasbinRBACModel)
 	if err != nil {
@@ -37,11 +58,14 @@ func InitEnforcer(db *gorm.DB) (*casbin.Enforcer, error) {
 	// 2. 创建适配器
 	adapter := NewAdapter(db)
 
-	// 3. 创建 Enforcer
-	enforcer, err := casbin.NewEnforcer(m, adapter)
+	// 3. 创建 Enforcer，注册租户套餐的权限校验函数
+	enforcer, err := casbin.NewSyncedEnforcer(m, adapter)
 	if err != nil {
 		return nil, err
 	}
+	enforcer.AddFunction("tenantPermitted", adapter.tenantPermitted)
+	// 适配器只读，策略变更由业务写入数据库后再同步到 Enforcer
+	enforcer.EnableAutoSave(false)
 
 	// 4. 加载策略
 	if err := enforcer.LoadPolicy(); err != nil {
@@ -49,8 +73,7 @@ func InitEnforcer(db *gorm.DB) (*casbin.Enforcer, error) {
 		return nil, err
 	}
 
-	// 5. 开启自动加载 (可选，根据业务需求，这里先不开启，手动 Reload)
-	// enforcer.StartAutoLoadPolicy(5 * time.Second)
+	// 5. 不开启定时全量加载，策略变更由 PolicySyncer 增量更新并通知其他实例
 
 	return enforcer, nil
 }
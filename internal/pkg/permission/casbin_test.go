@@ -0,0 +1,75 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+)
+
+// newTestEnforcer 创建不连接数据库的 Enforcer，策略与租户套餐权限直接写入内存
+func newTestEnforcer(t *testing.T, tenantPermissions map[string]map[string]struct{}) *casbin.SyncedEnforcer {
+	t.Helper()
+	m, err := model.NewModelFromString(CasbinRBACModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enforcer, err := casbin.NewSyncedEnforcer(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := &YudoAdapter{}
+	adapter.tenantPermissions.Store(&tenantPermissions)
+	enforcer.AddFunction("tenantPermitted", adapter.tenantPermitted)
+
+	if _, err := enforcer.AddPolicies([][]string{
+		{RoleSubject(1), TenantDomain(1), "system:user:query", PolicyActionAccess},
+		{RoleSubject(2), TenantDomain(2), "system:user:query", PolicyActionAccess},
+		{RoleSubject(2), TenantDomain(2), "system:tenant:query", PolicyActionAccess},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enforcer.AddGroupingPolicies([][]string{
+		{UserSubject(10), RoleSubject(1), TenantDomain(1)},
+		{UserSubject(20), RoleSubject(2), TenantDomain(2)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return enforcer
+}
+
+func assertEnforce(t *testing.T, e *casbin.SyncedEnforcer, userId, tenantId int64, perm string, want bool) {
+	t.Helper()
+	ok, err := e.Enforce(UserSubject(userId), TenantDomain(tenantId), perm, PolicyActionAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok != want {
+		t.Fatalf("user %d tenant %d %s: expected %v, got %v", userId, tenantId, perm, want, ok)
+	}
+}
+
+func TestEnforceTenantDomain(t *testing.T) {
+	e := newTestEnforcer(t, map[string]map[string]struct{}{
+		TenantDomain(1): nil, // 系统租户不限制
+		TenantDomain(2): {"system:user:query": {}, "system:tenant:query": {}},
+	})
+
+	assertEnforce(t, e, 10, 1, "system:user:query", true)
+	assertEnforce(t, e, 20, 2, "system:user:query", true)
+	// 角色只在所属租户内生效
+	assertEnforce(t, e, 10, 2, "system:user:query", false)
+	assertEnforce(t, e, 20, 1, "system:user:query", false)
+}
+
+func TestEnforceTenantPackage(t *testing.T) {
+	e := newTestEnforcer(t, map[string]map[string]struct{}{
+		TenantDomain(2): {"system:user:query": {}},
+	})
+
+	// 角色拥有但超出租户套餐的权限被拒绝
+	assertEnforce(t, e, 20, 2, "system:user:query", true)
+	assertEnforce(t, e, 20, 2, "system:tenant:query", false)
+	// 未加载到的租户没有任何权限
+	assertEnforce(t, e, 10, 1, "system:user:query", false)
+}
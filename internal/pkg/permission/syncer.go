@@ -0,0 +1,298 @@
+package permission
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// PolicyVersionKey 策略版本号的 Redis Key，每次策略变更递增
+	PolicyVersionKey = "casbin:policy:version"
+	// PolicyChannel 策略变更通知的 Redis 频道，消息格式为 {instanceId}:{version}
+	PolicyChannel = "casbin:policy:update"
+
+	// policyCheckInterval 定期比对策略版本的间隔，兜底丢失的变更通知
+	policyCheckInterval = time.Minute
+)
+
+// PolicyStatus 实例持有的策略状态，用于健康检查
+type PolicyStatus struct {
+	InstanceID    string    `json:"instanceId"`
+	Version       int64     `json:"version"`       // 本实例持有的策略版本
+	LatestVersion int64     `json:"latestVersion"` // Redis 中最新的策略版本，Redis 不可用时为 -1
+	LoadTime      time.Time `json:"loadTime"`      // 最近一次全量加载时间
+	PolicyCount   int       `json:"policyCount"`
+	GroupingCount int       `json:"groupingCount"`
+}
+
+// PolicySyncer Casbin 策略同步器
+// 角色菜单、用户角色变更后增量更新本实例的 Enforcer，递增 Redis 中的策略版本并发布通知，
+// 其他实例收到通知后全量重新加载策略，并定期比对版本兜底丢失的通知
+// Redis 不可用时只更新本实例
+type PolicySyncer struct {
+	enforcer   *casbin.SyncedEnforcer
+	adapter    *YudoAdapter
+	rdb        *redis.Client
+	logger     *zap.Logger
+	instanceID string
+
+	version  atomic.Int64
+	loadTime atomic.Value // time.Time
+	reloadMu sync.Mutex
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPolicySyncer 创建策略同步器，并订阅其他实例的策略变更
+func NewPolicySyncer(enforcer *casbin.SyncedEnforcer, rdb *redis.Client, logger *zap.Logger) *PolicySyncer {
+	hostname, _ := os.Hostname()
+	adapter, _ := enforcer.GetAdapter().(*YudoAdapter)
+	s := &PolicySyncer{
+		enforcer:   enforcer,
+		adapter:    adapter,
+		rdb:        rdb,
+		logger:     logger,
+		instanceID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+	// InitEnforcer 已加载策略，以当前的最新版本作为本实例持有的版本
+	s.loadTime.Store(time.Now())
+	if rdb != nil {
+		if version, err := s.latestVersion(context.Background()); err == nil {
+			s.version.Store(version)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.wg.Add(2)
+		go s.subscribe(ctx)
+		go s.checkVersion(ctx)
+	}
+	return s
+}
+
+// Stop 停止订阅策略变更及定期比对版本，等待后台协程退出
+func (s *PolicySyncer) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// RefreshRoles 角色菜单变更后，重新加载角色的权限策略，角色已删除时移除其策略
+// 在写锁内查询数据库，避免并发刷新时先查询到的旧策略覆盖后查询到的新策略
+func (s *PolicySyncer) RefreshRoles(ctx context.Context, roleIds ...int64) {
+	if len(roleIds) == 0 {
+		return
+	}
+	err := s.update(func(e *casbin.Enforcer) error {
+		rules, err := s.adapter.LoadRolePolicies(roleIds...)
+		if err != nil {
+			return err
+		}
+		for _, roleId := range roleIds {
+			if _, err := e.RemoveFilteredPolicy(0, RoleSubject(roleId)); err != nil {
+				return err
+			}
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		_, err = e.AddPolicies(rules)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("Failed to update role policies", zap.Int64s("role_ids", roleIds), zap.Error(err))
+		return
+	}
+	s.publish(ctx)
+}
+
+// RefreshUsers 用户角色变更后，重新加载用户的角色策略，用户已删除时移除其策略
+// 与 RefreshRoles 相同，在写锁内查询数据库
+func (s *PolicySyncer) RefreshUsers(ctx context.Context, userIds ...int64) {
+	if len(userIds) == 0 {
+		return
+	}
+	err := s.update(func(e *casbin.Enforcer) error {
+		rules, err := s.adapter.LoadUserRolePolicies(userIds...)
+		if err != nil {
+			return err
+		}
+		for _, userId := range userIds {
+			if _, err := e.RemoveFilteredGroupingPolicy(0, UserSubject(userId)); err != nil {
+				return err
+			}
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		_, err = e.AddGroupingPolicies(rules)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("Failed to update user role policies", zap.Int64s("user_ids", userIds), zap.Error(err))
+		return
+	}
+	s.publish(ctx)
+}
+
+// Reload 全量重新加载策略并通知其他实例，用于菜单权限标识、租户套餐变更及租户初始化等批量变更
+func (s *PolicySyncer) Reload(ctx context.Context) {
+	if err := s.reload(); err != nil {
+		s.logger.Error("Failed to reload casbin policy", zap.Error(err))
+		return
+	}
+	s.publish(ctx)
+}
+
+// Status 返回本实例持有的策略状态
+func (s *PolicySyncer) Status(ctx context.Context) *PolicyStatus {
+	policies, _ := s.enforcer.GetPolicy()
+	groupings, _ := s.enforcer.GetGroupingPolicy()
+	status := &PolicyStatus{
+		InstanceID:    s.instanceID,
+		Version:       s.version.Load(),
+		LatestVersion: -1,
+		LoadTime:      s.loadTime.Load().(time.Time),
+		PolicyCount:   len(policies),
+		GroupingCount: len(groupings),
+	}
+	if s.rdb != nil {
+		if version, err := s.latestVersion(ctx); err == nil {
+			status.LatestVersion = version
+		}
+	}
+	return status
+}
+
+// update 在写锁内执行多步更新，避免鉴权时看到移除后、添加前的中间状态
+func (s *PolicySyncer) update(fn func(e *casbin.Enforcer) error) error {
+	lock := s.enforcer.GetLock()
+	lock.Lock()
+	defer lock.Unlock()
+	return fn(s.enforcer.Enforcer)
+}
+
+func (s *PolicySyncer) reload() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	if err := s.enforcer.LoadPolicy(); err != nil {
+		return err
+	}
+	s.loadTime.Store(time.Now())
+	return nil
+}
+
+// publish 递增策略版本并通知其他实例
+func (s *PolicySyncer) publish(ctx context.Context) {
+	if s.rdb == nil {
+		return
+	}
+	previous := s.version.Load()
+	version, err := s.rdb.Incr(ctx, PolicyVersionKey).Result()
+	if err != nil {
+		s.logger.Warn("Failed to increase casbin policy version", zap.Error(err))
+		return
+	}
+	if version > previous+1 {
+		// 期间有其他实例的变更尚未加载，全量加载后再持有新版本
+		s.reloadTo(version)
+	} else {
+		s.storeVersion(version)
+	}
+	message := s.instanceID + ":" + strconv.FormatInt(version, 10)
+	if err := s.rdb.Publish(ctx, PolicyChannel, message).Err(); err != nil {
+		s.logger.Warn("Failed to publish casbin policy update", zap.Error(err))
+	}
+}
+
+// subscribe 订阅其他实例的策略变更，go-redis 会在连接断开后自动重新订阅
+func (s *PolicySyncer) subscribe(ctx context.Context) {
+	defer s.wg.Done()
+	pubsub := s.rdb.Subscribe(ctx, PolicyChannel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			s.handleMessage(msg.Payload)
+		}
+	}
+}
+
+// handleMessage 处理策略变更通知，其他实例的变更与本实例的变更可能交错，只要来自其他实例就重新加载
+func (s *PolicySyncer) handleMessage(payload string) {
+	idx := strings.LastIndex(payload, ":")
+	if idx < 0 {
+		return
+	}
+	version, err := strconv.ParseInt(payload[idx+1:], 10, 64)
+	if err != nil || payload[:idx] == s.instanceID {
+		return
+	}
+	s.reloadTo(version)
+}
+
+// checkVersion 定期比对策略版本，落后时全量重新加载
+func (s *PolicySyncer) checkVersion(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(policyCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		version, err := s.latestVersion(ctx)
+		if err != nil {
+			s.logger.Warn("Failed to get casbin policy version", zap.Error(err))
+			continue
+		}
+		if version > s.version.Load() {
+			s.reloadTo(version)
+		}
+	}
+}
+
+func (s *PolicySyncer) reloadTo(version int64) {
+	if err := s.reload(); err != nil {
+		s.logger.Error("Failed to reload casbin policy", zap.Int64("version", version), zap.Error(err))
+		return
+	}
+	s.storeVersion(version)
+	s.logger.Info("Casbin policy reloaded", zap.Int64("version", version))
+}
+
+// storeVersion 更新本实例持有的版本，版本只增不减
+func (s *PolicySyncer) storeVersion(version int64) {
+	for {
+		current := s.version.Load()
+		if version <= current || s.version.CompareAndSwap(current, version) {
+			return
+		}
+	}
+}
+
+func (s *PolicySyncer) latestVersion(ctx context.Context) (int64, error) {
+	version, err := s.rdb.Get(ctx, PolicyVersionKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
@@ -0,0 +1,162 @@
+package permission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	internalModel "github.com/wxlbd/admin-go/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestPolicyDB 创建内存 SQLite 数据库：租户 1 不限制套餐，用户 10 持有角色 1，角色 1 拥有 system:user:query
+func newTestPolicyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接相互独立，只使用一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(&internalModel.SystemTenant{}, &internalModel.SystemTenantPackage{},
+		&internalModel.SystemMenu{}, &internalModel.SystemRole{},
+		&internalModel.SystemRoleMenu{}, &internalModel.SystemUserRole{}); err != nil {
+		t.Fatal(err)
+	}
+
+	records := []any{
+		&internalModel.SystemTenant{ID: 1, Name: "系统租户"},
+		&internalModel.SystemMenu{ID: 1, Name: "用户查询", Permission: "system:user:query", Type: 3},
+		&internalModel.SystemMenu{ID: 2, Name: "角色查询", Permission: "system:role:query", Type: 3},
+		&internalModel.SystemRole{ID: 1, Name: "管理员", Code: "admin", TenantBaseDO: internalModel.TenantBaseDO{TenantID: 1}},
+		&internalModel.SystemRoleMenu{RoleID: 1, MenuID: 1, TenantBaseDO: internalModel.TenantBaseDO{TenantID: 1}},
+		&internalModel.SystemUserRole{UserID: 10, RoleID: 1, TenantBaseDO: internalModel.TenantBaseDO{TenantID: 1}},
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func newTestSyncer(t *testing.T, db *gorm.DB, rdb *redis.Client) *PolicySyncer {
+	t.Helper()
+	enforcer, err := InitEnforcer(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewPolicySyncer(enforcer, rdb, zap.NewNop())
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestPolicySyncerRefresh(t *testing.T) {
+	db := newTestPolicyDB(t)
+	s := newTestSyncer(t, db, nil)
+	assertEnforce(t, s.enforcer, 10, 1, "system:user:query", true)
+	assertEnforce(t, s.enforcer, 10, 1, "system:role:query", false)
+
+	// 角色菜单变更后只刷新该角色
+	if err := db.Create(&internalModel.SystemRoleMenu{RoleID: 1, MenuID: 2, TenantBaseDO: internalModel.TenantBaseDO{TenantID: 1}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	s.RefreshRoles(context.Background(), 1)
+	assertEnforce(t, s.enforcer, 10, 1, "system:role:query", true)
+
+	// 用户角色删除后移除其分组策略
+	if err := db.Where("user_id = ?", 10).Delete(&internalModel.SystemUserRole{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	s.RefreshUsers(context.Background(), 10)
+	assertEnforce(t, s.enforcer, 10, 1, "system:user:query", false)
+
+	// Redis 不可用时只更新本实例
+	status := s.Status(context.Background())
+	if status.Version != 0 || status.LatestVersion != -1 {
+		t.Fatalf("expected version 0 without redis, got %+v", status)
+	}
+}
+
+func TestPolicySyncerPublish(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	s := newTestSyncer(t, newTestPolicyDB(t), rdb)
+
+	s.RefreshRoles(context.Background(), 1)
+	s.RefreshUsers(context.Background(), 10)
+	status := s.Status(context.Background())
+	if status.Version != 2 || status.LatestVersion != 2 {
+		t.Fatalf("expected version 2, got %+v", status)
+	}
+	if status.PolicyCount != 1 || status.GroupingCount != 1 {
+		t.Fatalf("unexpected policy count: %+v", status)
+	}
+
+	// 期间有其他实例的变更时，全量加载后持有最新版本
+	mr.Set(PolicyVersionKey, "5")
+	s.RefreshRoles(context.Background(), 1)
+	if version := s.version.Load(); version != 6 {
+		t.Fatalf("expected version 6, got %d", version)
+	}
+}
+
+func TestPolicySyncerHandleMessage(t *testing.T) {
+	db := newTestPolicyDB(t)
+	s := newTestSyncer(t, db, nil)
+	if err := db.Create(&internalModel.SystemRoleMenu{RoleID: 1, MenuID: 2, TenantBaseDO: internalModel.TenantBaseDO{TenantID: 1}}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 本实例发出的通知及格式错误的通知不重新加载
+	s.handleMessage(s.instanceID + ":1")
+	s.handleMessage("invalid")
+	assertEnforce(t, s.enforcer, 10, 1, "system:role:query", false)
+
+	// 其他实例的通知全量重新加载，并持有通知的版本
+	s.handleMessage("other-1:3")
+	assertEnforce(t, s.enforcer, 10, 1, "system:role:query", true)
+	if version := s.version.Load(); version != 3 {
+		t.Fatalf("expected version 3, got %d", version)
+	}
+	// 版本只增不减
+	s.handleMessage("other-1:2")
+	if version := s.version.Load(); version != 3 {
+		t.Fatalf("expected version 3, got %d", version)
+	}
+}
+
+func TestPolicySyncerStop(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	enforcer, err := InitEnforcer(newTestPolicyDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewPolicySyncer(enforcer, rdb, zap.NewNop())
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("policy syncer did not stop")
+	}
+	// 重复停止直接返回
+	s.Stop()
+}
@@ -0,0 +1,141 @@
+package tenant
+
+import (
+	"errors"
+
+	pkgcontext "github.com/wxlbd/admin-go/pkg/context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// columnTenantID 租户编号字段
+const columnTenantID = "tenant_id"
+
+// ErrTenantRequired 访问租户数据时未指定租户，需要跨租户访问时须显式使用 SkipTenant
+var ErrTenantRequired = errors.New("tenant: 未指定租户，不能访问租户数据")
+
+// Plugin GORM租户隔离插件
+// 对包含 tenant_id 字段的表，在查询、更新、删除时自动追加 tenant_id = 当前租户 条件
+// 未指定租户且未通过 SkipTenant 跳过时拒绝执行，返回 ErrTenantRequired
+// 创建时的 TenantID 由 database.AuditPlugin 填充
+type Plugin struct {
+	logger       *zap.Logger
+	ignoreTables map[string]struct{}
+}
+
+// NewPlugin 创建租户插件，ignoreTables 与 DefaultIgnoreTables 合并
+func NewPlugin(logger *zap.Logger, ignoreTables ...string) *Plugin {
+	p := &Plugin{
+		logger:       logger,
+		ignoreTables: make(map[string]struct{}),
+	}
+	for _, table := range append(DefaultIgnoreTables, ignoreTables...) {
+		p.ignoreTables[table] = struct{}{}
+	}
+	return p
+}
+
+// Name 返回插件名称
+func (p *Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize 初始化插件，注册GORM回调
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").
+		Register("tenant:before_query", p.beforeQuery); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").
+		Register("tenant:before_row", p.beforeQuery); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").
+		Register("tenant:before_update", p.beforeUpdate); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").
+		Register("tenant:before_delete", p.beforeUpdate)
+}
+
+// beforeQuery 在查询前追加租户条件
+func (p *Plugin) beforeQuery(db *gorm.DB) {
+	if tenantId, ok := p.resolveTenant(db); ok {
+		p.applyTenant(db, tenantId)
+	}
+}
+
+// beforeUpdate 在更新、删除前追加租户条件
+func (p *Plugin) beforeUpdate(db *gorm.DB) {
+	tenantId, ok := p.resolveTenant(db)
+	if !ok {
+		return
+	}
+	// 没有条件且模型没有主键值时，GORM 会以 ErrMissingWhereClause 拒绝执行
+	// 此时不追加租户条件，避免绕过全表更新的保护
+	if _, hasWhere := db.Statement.Clauses["WHERE"]; !hasWhere && !db.AllowGlobalUpdate && !p.hasPrimaryValue(db) {
+		return
+	}
+	p.applyTenant(db, tenantId)
+}
+
+// resolveTenant 判断当前语句是否需要租户隔离，并返回当前租户编号
+// 需要隔离但未指定租户时，为语句添加 ErrTenantRequired 错误，GORM 不再执行该语句
+func (p *Plugin) resolveTenant(db *gorm.DB) (int64, bool) {
+	ctx := db.Statement.Context
+	if ctx == nil || db.Error != nil {
+		return 0, false
+	}
+
+	// 1. 检查是否跳过租户隔离
+	if ShouldSkipTenant(ctx) {
+		return 0, false
+	}
+
+	// 2. 原生 SQL 不处理，由调用方自行拼接租户条件
+	if db.Statement.SQL.Len() > 0 {
+		return 0, false
+	}
+
+	// 3. 只处理包含 tenant_id 字段、且不在忽略列表中的表
+	if db.Statement.Schema == nil {
+		return 0, false
+	}
+	if _, ok := db.Statement.Schema.FieldsByDBName[columnTenantID]; !ok {
+		return 0, false
+	}
+	if _, ok := p.ignoreTables[db.Statement.Table]; ok {
+		return 0, false
+	}
+
+	// 4. 获取当前租户，没有租户时拒绝执行，避免未登录的公开接口、后台任务读写全部租户的数据
+	tenantId := pkgcontext.GetTenantIDFromContext(ctx)
+	if tenantId == 0 {
+		p.logger.Warn("Rejected statement without tenant", zap.String("table", db.Statement.Table))
+		_ = db.AddError(ErrTenantRequired)
+		return 0, false
+	}
+	return tenantId, true
+}
+
+// applyTenant 追加租户条件
+func (p *Plugin) applyTenant(db *gorm.DB, tenantId int64) {
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: columnTenantID}, Value: tenantId},
+	}})
+	p.logger.Debug("Applied tenant condition",
+		zap.String("table", db.Statement.Table),
+		zap.Int64("tenant_id", tenantId))
+}
+
+// hasPrimaryValue 检查语句的模型是否带有主键值，GORM 会据此生成主键条件
+func (p *Plugin) hasPrimaryValue(db *gorm.DB) bool {
+	if !db.Statement.ReflectValue.IsValid() {
+		return false
+	}
+	_, values := schema.GetIdentityFieldValuesMap(db.Statement.Context, db.Statement.ReflectValue, db.Statement.Schema.PrimaryFields)
+	return len(values) > 0
+}
@@ -0,0 +1,153 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/model"
+	pkgcontext "github.com/wxlbd/admin-go/pkg/context"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB 创建只生成 SQL、不连接数据库的 DB
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "root:root@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(NewPlugin(zap.NewNop())); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func loginContext(tenantId int64) context.Context {
+	return context.WithValue(context.Background(), pkgcontext.CtxLoginUserKey, &pkgcontext.LoginUser{
+		UserID:   1,
+		UserType: 2,
+		TenantID: tenantId,
+	})
+}
+
+// assertTenantCondition 校验 SQL 是否带有指定租户的条件
+func assertTenantCondition(t *testing.T, stmt *gorm.Statement, tenantId int64) {
+	t.Helper()
+	sql := stmt.SQL.String()
+	if !strings.Contains(sql, "`tenant_id` = ?") {
+		t.Fatalf("expected tenant condition, got: %s", sql)
+	}
+	for _, v := range stmt.Vars {
+		if v == tenantId {
+			return
+		}
+	}
+	t.Fatalf("expected tenant %d in vars %v, sql: %s", tenantId, stmt.Vars, sql)
+}
+
+func assertNoTenantCondition(t *testing.T, stmt *gorm.Statement) {
+	t.Helper()
+	if sql := stmt.SQL.String(); strings.Contains(sql, "tenant_id") {
+		t.Fatalf("expected no tenant condition, got: %s", sql)
+	}
+}
+
+func TestPluginBlocksCrossTenantQuery(t *testing.T) {
+	db := newDryRunDB(t)
+
+	// 租户 2 的用户按编号查询租户 1 的用户，也只能命中租户 2 的数据
+	var user model.SystemUser
+	stmt := db.WithContext(loginContext(2)).Where("id = ?", 1).First(&user).Statement
+	assertTenantCondition(t, stmt, int64(2))
+
+	var count int64
+	stmt = db.WithContext(loginContext(2)).Model(&model.SystemRole{}).Count(&count).Statement
+	assertTenantCondition(t, stmt, int64(2))
+}
+
+func TestPluginBlocksCrossTenantWrite(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := loginContext(2)
+
+	stmt := db.WithContext(ctx).Model(&model.SystemUser{}).Where("id = ?", 1).Update("nickname", "x").Statement
+	assertTenantCondition(t, stmt, int64(2))
+
+	stmt = db.WithContext(ctx).Where("id = ?", 1).Delete(&model.SystemRole{}).Statement
+	assertTenantCondition(t, stmt, int64(2))
+
+	// 按主键更新时同样追加租户条件
+	user := &model.SystemUser{ID: 1, Nickname: "x"}
+	stmt = db.WithContext(ctx).Model(user).Update("nickname", "y").Statement
+	assertTenantCondition(t, stmt, int64(2))
+}
+
+func TestPluginKeepsMissingWhereProtection(t *testing.T) {
+	db := newDryRunDB(t)
+	err := db.WithContext(loginContext(2)).Model(&model.SystemUser{}).Update("nickname", "x").Error
+	if err != gorm.ErrMissingWhereClause {
+		t.Fatalf("expected ErrMissingWhereClause, got %v", err)
+	}
+}
+
+func TestPluginSkip(t *testing.T) {
+	db := newDryRunDB(t)
+	var users []model.SystemUser
+
+	// 跳过租户隔离
+	stmt := db.WithContext(SkipTenant(loginContext(2))).Find(&users).Statement
+	assertNoTenantCondition(t, stmt)
+
+	// 全局表
+	var tenants []model.SystemTenant
+	stmt = db.WithContext(loginContext(2)).Find(&tenants).Statement
+	assertNoTenantCondition(t, stmt)
+}
+
+func TestPluginRequiresTenant(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := context.Background()
+
+	// 未登录且未指定租户时拒绝读写租户数据
+	var users []model.SystemUser
+	if err := db.WithContext(ctx).Find(&users).Error; !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("query without tenant: err = %v, want ErrTenantRequired", err)
+	}
+	var count int64
+	if err := db.WithContext(ctx).Model(&model.SystemUser{}).Count(&count).Error; !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("count without tenant: err = %v, want ErrTenantRequired", err)
+	}
+	if err := db.WithContext(ctx).Where("id = ?", 1).Delete(&model.SystemRole{}).Error; !errors.Is(err, ErrTenantRequired) {
+		t.Fatalf("delete without tenant: err = %v, want ErrTenantRequired", err)
+	}
+
+	// 全局表与显式跳过不受影响
+	var tenants []model.SystemTenant
+	if err := db.WithContext(ctx).Find(&tenants).Error; err != nil {
+		t.Fatalf("global table: err = %v", err)
+	}
+	if err := db.WithContext(SkipTenant(ctx)).Find(&users).Error; err != nil {
+		t.Fatalf("skip tenant: err = %v", err)
+	}
+}
+
+func TestPluginWithTenantID(t *testing.T) {
+	db := newDryRunDB(t)
+	var users []model.SystemUser
+
+	// 显式指定的租户优先于登录用户的租户
+	ctx := pkgcontext.WithTenantID(loginContext(1), 3)
+	stmt := db.WithContext(ctx).Find(&users).Statement
+	assertTenantCondition(t, stmt, int64(3))
+}
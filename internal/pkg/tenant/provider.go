@@ -0,0 +1,20 @@
+package tenant
+
+import (
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PluginRegistered 是一个空标记类型,表示租户插件已注册
+type PluginRegistered struct{}
+
+// RegisterPlugin 注册租户插件到GORM (用于Wire依赖注入)
+// 返回PluginRegistered标记以便Wire知道插件已注册
+func RegisterPlugin(db *gorm.DB, logger *zap.Logger) (*PluginRegistered, error) {
+	plugin := NewPlugin(logger)
+	if err := db.Use(plugin); err != nil {
+		return nil, err
+	}
+	logger.Info("Tenant plugin registered successfully")
+	return &PluginRegistered{}, nil
+}
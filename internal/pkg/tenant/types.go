@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"context"
+)
+
+// Context Keys
+type contextKey string
+
+const (
+	// SkipTenantKey 跳过租户隔离的Context Key
+	SkipTenantKey contextKey = "skip_tenant"
+)
+
+// DefaultIgnoreTables 不做租户隔离的全局表
+var DefaultIgnoreTables = []string{
+	"system_tenant",
+	"system_tenant_package",
+	"system_dict_type",
+	"system_dict_data",
+	"system_area",
+}
+
+// SkipTenant 返回一个跳过租户隔离的Context，用于需要跨租户查询的场景（如定时任务、平台统计）
+func SkipTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, SkipTenantKey, true)
+}
+
+// ShouldSkipTenant 检查是否应该跳过租户隔离
+func ShouldSkipTenant(ctx context.Context) bool {
+	if v := ctx.Value(SkipTenantKey); v != nil {
+		if skip, ok := v.(bool); ok {
+			return skip
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量，取后 6 位
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code, _ := Code(rfcSecret, step)
+	previous, _ := Code(rfcSecret, step-1)
+
+	if got, ok := Validate(rfcSecret, code, now, 0); !ok || got != step {
+		t.Errorf("Validate(current) = (%d, %v), want (%d, true)", got, ok, step)
+	}
+	if _, ok := Validate(rfcSecret, previous, now, 0); !ok {
+		t.Error("Validate(previous step) should pass within skew")
+	}
+	if _, ok := Validate(rfcSecret, code, now, step); ok {
+		t.Error("Validate(replayed step) should fail")
+	}
+	if _, ok := Validate(rfcSecret, "000000", now, 0); ok && code != "000000" {
+		t.Error("Validate(wrong code) should fail")
+	}
+	if _, ok := Validate(rfcSecret, "12345", now, 0); ok {
+		t.Error("Validate(short code) should fail")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	uri := ProvisioningURI("Admin Go", "admin", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Admin%20Go:admin?") {
+		t.Errorf("uri = %s", uri)
+	}
+	if !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("uri missing secret: %s", uri)
+	}
+}
@@ -1,11 +1,22 @@
 package websocket
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 )
 
+// KickChannel 强制断开连接通知的 Redis 频道，消息为 JSON 格式的 kickMessage
+const KickChannel = "websocket:kick"
+
 // Session 表示一个 WebSocket 连接会话
 type Session struct {
 	ID       string
@@ -13,7 +24,9 @@ type Session struct {
 	UserID   int64
 	UserType int
 	TenantID int64
-	mu       sync.Mutex
+	// LoginSessionID 建立连接所用令牌的登录会话编号，令牌刷新后不变，强制退出时据此定位连接
+	LoginSessionID string
+	mu             sync.Mutex
 }
 
 // Send 发送消息到此会话
@@ -28,19 +41,64 @@ func (s *Session) SendText(text string) error {
 	return s.Send([]byte(text))
 }
 
-// Manager 管理所有 WebSocket 会话
+// Close 发送关闭帧后关闭此会话的连接
+func (s *Session) Close(code int, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(time.Second))
+	return s.Conn.Close()
+}
+
+// Manager 管理本实例的 WebSocket 会话
+// 强制断开连接的通知通过 Redis 发布订阅转发给所有实例，Redis 不可用时只处理本实例的连接
 type Manager struct {
 	sessions map[string]*Session  // sessionID -> Session
 	userMap  map[int64][]*Session // userID -> Sessions (一个用户可能有多个连接)
 	mu       sync.RWMutex
+
+	rdb        *redis.Client
+	logger     *zap.Logger
+	instanceID string
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// kickMessage 强制断开连接的通知
+type kickMessage struct {
+	InstanceID     string `json:"instanceId"`
+	UserType       int    `json:"userType"`
+	UserID         int64  `json:"userId"`
+	LoginSessionID string `json:"loginSessionId"`
+	Message        string `json:"message"`
 }
 
-// NewManager 创建新的会话管理器
-func NewManager() *Manager {
-	return &Manager{
-		sessions: make(map[string]*Session),
-		userMap:  make(map[int64][]*Session),
+// NewManager 创建新的会话管理器，并订阅其他实例的强制断开通知
+func NewManager(rdb *redis.Client, logger *zap.Logger) *Manager {
+	hostname, _ := os.Hostname()
+	m := &Manager{
+		sessions:   make(map[string]*Session),
+		userMap:    make(map[int64][]*Session),
+		rdb:        rdb,
+		logger:     logger,
+		instanceID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		done:       make(chan struct{}),
+	}
+	if rdb == nil {
+		close(m.done)
+		return m
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	go m.subscribe(ctx)
+	return m
+}
+
+// Stop 停止订阅强制断开通知，服务停止时调用
+func (m *Manager) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	<-m.done
 }
 
 // Add 添加会话
@@ -78,7 +136,8 @@ func (m *Manager) Remove(sessionID string) {
 func (m *Manager) GetByUser(userID int64) []*Session {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.userMap[userID]
+	// 返回副本，Remove 会原地修改切片
+	return slices.Clone(m.userMap[userID])
 }
 
 // GetBySession 获取指定会话
@@ -123,3 +182,72 @@ func (m *Manager) BroadcastByUserType(userType int, message []byte) {
 		}
 	}
 }
+
+// CloseAll 关闭所有会话，服务停止时调用，客户端收到关闭帧后可自行重连
+func (m *Manager) CloseAll(text string) {
+	m.mu.RLock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mu.RUnlock()
+	for _, session := range sessions {
+		_ = session.Close(websocket.CloseGoingAway, text)
+	}
+}
+
+// Kick 通知用户登录会话建立的连接后关闭连接，并转发给其他实例
+// loginSessionID 为令牌中的登录会话编号，令牌刷新前后建立的连接都会被关闭
+func (m *Manager) Kick(ctx context.Context, userType int, userID int64, loginSessionID string, message []byte) {
+	kick := &kickMessage{
+		InstanceID:     m.instanceID,
+		UserType:       userType,
+		UserID:         userID,
+		LoginSessionID: loginSessionID,
+		Message:        string(message),
+	}
+	m.kickLocal(kick)
+	if m.rdb == nil {
+		return
+	}
+	data, _ := json.Marshal(kick)
+	if err := m.rdb.Publish(ctx, KickChannel, data).Err(); err != nil {
+		m.logger.Warn("Failed to publish websocket kick", zap.Int64("userId", userID), zap.Error(err))
+	}
+}
+
+// kickLocal 关闭本实例上匹配的连接，关闭后由读循环从 Manager 中移除
+func (m *Manager) kickLocal(kick *kickMessage) {
+	for _, session := range m.GetByUser(kick.UserID) {
+		if session.UserType != kick.UserType || session.LoginSessionID != kick.LoginSessionID {
+			continue
+		}
+		if err := session.Send([]byte(kick.Message)); err != nil {
+			m.logger.Warn("Failed to notify websocket kick", zap.String("sessionId", session.ID), zap.Error(err))
+		}
+		_ = session.Close(websocket.ClosePolicyViolation, "kicked")
+	}
+}
+
+// subscribe 订阅其他实例的强制断开通知，go-redis 会在连接断开后自动重新订阅
+func (m *Manager) subscribe(ctx context.Context) {
+	defer close(m.done)
+	pubsub := m.rdb.Subscribe(ctx, KickChannel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var kick kickMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &kick); err != nil || kick.InstanceID == m.instanceID {
+				continue
+			}
+			m.kickLocal(&kick)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// newTestSession 建立一个真实的 WebSocket 连接并加入 Manager，返回客户端连接
+func newTestSession(t *testing.T, m *Manager, id string, userID int64, loginSessionID string) *websocket.Conn {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		m.Add(&Session{ID: id, Conn: conn, UserID: userID, UserType: 2, LoginSessionID: loginSessionID})
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	deadline := time.Now().Add(time.Second)
+	for m.GetBySession(id) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("session not added")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return client
+}
+
+// readKick 读取客户端收到的消息，返回通知内容以及连接是否被关闭
+func readKick(client *websocket.Conn, wait time.Duration) (string, bool) {
+	_ = client.SetReadDeadline(time.Now().Add(wait))
+	_, data, err := client.ReadMessage()
+	if err != nil {
+		return "", websocket.IsCloseError(err, websocket.ClosePolicyViolation)
+	}
+	_, _, err = client.ReadMessage()
+	return string(data), websocket.IsCloseError(err, websocket.ClosePolicyViolation)
+}
+
+func TestManagerKickByLoginSession(t *testing.T) {
+	m := NewManager(nil, zap.NewNop())
+	kicked := newTestSession(t, m, "a", 1, "login-1")
+	other := newTestSession(t, m, "b", 1, "login-2")
+
+	m.Kick(context.Background(), 2, 1, "login-1", []byte("bye"))
+	if msg, closed := readKick(kicked, time.Second); msg != "bye" || !closed {
+		t.Errorf("kicked session got (%q, closed %v), want the message then close", msg, closed)
+	}
+	// 同一用户的其他登录会话不受影响
+	if _, closed := readKick(other, 100*time.Millisecond); closed {
+		t.Error("session of another login should stay open")
+	}
+}
+
+func TestManagerKickAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newRedis := func() *redis.Client {
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = rdb.Close() })
+		return rdb
+	}
+	local := NewManager(newRedis(), zap.NewNop())
+	remote := NewManager(newRedis(), zap.NewNop())
+	t.Cleanup(local.Stop)
+	t.Cleanup(remote.Stop)
+	// 同一进程内实例编号相同，改为不同编号模拟多实例
+	remote.instanceID = "remote"
+	client := newTestSession(t, remote, "a", 1, "login-1")
+
+	// 等待订阅生效后再发布
+	deadline := time.Now().Add(time.Second)
+	for len(mr.PubSubNumSub(KickChannel)) == 0 || mr.PubSubNumSub(KickChannel)[KickChannel] < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscribers not ready")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	local.Kick(context.Background(), 2, 1, "login-1", []byte("bye"))
+	if msg, closed := readKick(client, time.Second); msg != "bye" || !closed {
+		t.Errorf("remote session got (%q, closed %v), want the message then close", msg, closed)
+	}
+}
+
+func TestManagerStop(t *testing.T) {
+	m := NewManager(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), zap.NewNop())
+	done := make(chan struct{})
+	go func() {
+		m.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop should return after the subscriber exits")
+	}
+	// Redis 不可用时 Stop 直接返回
+	NewManager(nil, zap.NewNop()).Stop()
+}
@@ -1,12 +1,15 @@
 package repo
 
 import (
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
+	"github.com/wxlbd/admin-go/internal/pkg/tenant"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 
 	"gorm.io/gorm"
 )
 
 // NewQuery 适配 query.Use，屏蔽 opts 变长参数，方便 Wire 注入
-func NewQuery(db *gorm.DB) *query.Query {
+// 依赖租户、数据权限插件的注册标记，保证所有查询都经过租户隔离与数据权限过滤
+func NewQuery(db *gorm.DB, _ *tenant.PluginRegistered, _ *datascope.PluginRegistered) *query.Query {
 	return query.Use(db)
 }
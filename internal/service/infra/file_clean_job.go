@@ -0,0 +1,165 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/file"
+	pkgTenant "github.com/wxlbd/admin-go/internal/pkg/tenant"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"go.uber.org/zap"
+)
+
+const (
+	// FileCleanJobHandlerName 孤立文件清理任务的处理器名称
+	FileCleanJobHandlerName = "fileCleanJob"
+
+	defaultFileCleanBatchSize = 100
+)
+
+// FileCleanJobParam 孤立文件清理任务的参数，如 {"batchSize": 100}
+type FileCleanJobParam struct {
+	BatchSize int `json:"batchSize"` // 每批处理的文件数
+}
+
+// FileCleanJob 孤立文件清理任务，只处理已删除的文件记录
+// 1. 使用所属文件配置（含已删除的配置）删除存储中的文件后物理删除记录，存储删除失败的保留记录，下次执行时重试
+// 2. 所属文件配置已物理删除的，无法再删除存储中的文件，直接物理删除记录
+type FileCleanJob struct {
+	q *query.Query
+}
+
+func NewFileCleanJob(q *query.Query) *FileCleanJob {
+	return &FileCleanJob{q: q}
+}
+
+// GetHandlerName 返回处理器名称
+func (j *FileCleanJob) GetHandlerName() string {
+	return FileCleanJobHandlerName
+}
+
+// Execute 执行任务，param 见 FileCleanJobParam
+func (j *FileCleanJob) Execute(ctx context.Context, param string) error {
+	p := FileCleanJobParam{BatchSize: defaultFileCleanBatchSize}
+	if err := ParseJobParam(param, &p); err != nil {
+		return err
+	}
+	if p.BatchSize <= 0 || p.BatchSize > maxLogCleanBatchSize {
+		return fmt.Errorf("每批处理的文件数须在 1 到 %d 之间", maxLogCleanBatchSize)
+	}
+
+	ctx = pkgTenant.SkipTenant(ctx)
+	// 已删除的文件配置仍可用于删除其存储中的文件
+	configs, err := j.q.InfraFileConfig.WithContext(ctx).Unscoped().Find()
+	if err != nil {
+		return err
+	}
+	configMap := make(map[int64]*model.InfraFileConfig, len(configs))
+	configIds := make([]int64, 0, len(configs))
+	for _, config := range configs {
+		configMap[config.ID] = config
+		configIds = append(configIds, config.ID)
+	}
+
+	purged, failed, err := j.cleanDeletedFiles(ctx, configMap, configIds, p.BatchSize)
+	if err != nil {
+		return err
+	}
+	orphaned, err := j.cleanOrphanedFiles(ctx, configIds, p.BatchSize)
+	if err != nil {
+		return err
+	}
+	zap.L().Info("File clean job finished", zap.Int("purged", purged), zap.Int("failed", failed), zap.Int64("orphaned", orphaned))
+	return nil
+}
+
+// cleanDeletedFiles 删除已删除记录对应的存储文件并物理删除记录，返回清理成功与失败的数量
+// 只处理所属文件配置仍存在的记录，其余由 cleanOrphanedFiles 处理
+func (j *FileCleanJob) cleanDeletedFiles(ctx context.Context, configMap map[int64]*model.InfraFileConfig, configIds []int64, batchSize int) (int, int, error) {
+	if len(configIds) == 0 {
+		return 0, 0, nil
+	}
+	f := j.q.InfraFile
+	clients := make(map[int64]file.FileClient)
+	purged, failed := 0, 0
+	var lastID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return purged, failed, err
+		}
+		files, err := f.WithContext(ctx).Unscoped().
+			Where(f.Deleted.Eq(model.BitBool(true)), f.ConfigId.In(configIds...), f.ID.Gt(lastID)).
+			Order(f.ID).Limit(batchSize).Find()
+		if err != nil {
+			return purged, failed, err
+		}
+
+		ids := make([]int64, 0, len(files))
+		for _, item := range files {
+			lastID = item.ID
+			if err := j.deleteObject(item, configMap, clients); err != nil {
+				zap.L().Warn("Failed to delete file object", zap.Int64("fileId", item.ID), zap.String("path", item.Path), zap.Error(err))
+				failed++
+				continue
+			}
+			ids = append(ids, item.ID)
+		}
+		if len(ids) > 0 {
+			if _, err := f.WithContext(ctx).Unscoped().Where(f.ID.In(ids...)).Delete(); err != nil {
+				return purged, failed, err
+			}
+			purged += len(ids)
+		}
+		if len(files) < batchSize {
+			return purged, failed, nil
+		}
+	}
+}
+
+// deleteObject 删除存储中的文件，文件已不存在时视为成功
+func (j *FileCleanJob) deleteObject(item *model.InfraFile, configMap map[int64]*model.InfraFileConfig, clients map[int64]file.FileClient) error {
+	config, ok := configMap[item.ConfigId]
+	if !ok {
+		return fmt.Errorf("文件配置(%d)不存在", item.ConfigId)
+	}
+	client, ok := clients[config.ID]
+	if !ok {
+		var err error
+		if client, err = file.NewFileClient(config.Storage, config.Config); err != nil {
+			return err
+		}
+		clients[config.ID] = client
+	}
+	if err := client.Delete(item.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// cleanOrphanedFiles 物理删除所属文件配置已物理删除的已删除文件记录，返回删除的数量
+func (j *FileCleanJob) cleanOrphanedFiles(ctx context.Context, configIds []int64, batchSize int) (int64, error) {
+	// 没有任何文件配置时不清理，避免配置查询异常时误删全部记录
+	if len(configIds) == 0 {
+		return 0, nil
+	}
+	f := j.q.InfraFile
+	var deleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		info, err := f.WithContext(ctx).Unscoped().
+			Where(f.Deleted.Eq(model.BitBool(true)), f.ConfigId.NotIn(configIds...)).
+			Limit(batchSize).Delete()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += info.RowsAffected
+		if info.RowsAffected < int64(batchSize) {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/model"
+)
+
+func TestFileCleanJobExecute(t *testing.T) {
+	q := newTestQuery(t, &model.InfraFileConfig{}, &model.InfraFile{})
+	ctx := context.Background()
+	activeDir, deletedDir := t.TempDir(), t.TempDir()
+	localConfig := func(dir string) json.RawMessage {
+		data, _ := json.Marshal(map[string]string{"basePath": dir})
+		return data
+	}
+	configs := []*model.InfraFileConfig{
+		{ID: 1, Name: "本地", Storage: 10, Config: localConfig(activeDir)},
+		{ID: 2, Name: "已删除", Storage: 10, Config: localConfig(deletedDir)},
+		{ID: 3, Name: "未知存储", Storage: 99, Config: localConfig(activeDir)},
+	}
+	if err := q.InfraFileConfig.WithContext(ctx).Create(configs...); err != nil {
+		t.Fatal(err)
+	}
+	c := q.InfraFileConfig
+	if _, err := c.WithContext(ctx).Where(c.ID.Eq(2)).Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{filepath.Join(activeDir, "a.txt"), filepath.Join(activeDir, "keep.txt"), filepath.Join(deletedDir, "b.txt")} {
+		if err := os.WriteFile(path, []byte("test"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := []*model.InfraFile{
+		{ID: 1, ConfigId: 1, Path: "a.txt"},       // 已删除，删除存储中的文件
+		{ID: 2, ConfigId: 2, Path: "b.txt"},       // 已删除，所属配置已删除，仍删除存储中的文件
+		{ID: 3, ConfigId: 1, Path: "missing.txt"}, // 已删除，存储中的文件已不存在
+		{ID: 4, ConfigId: 9, Path: "c.txt"},       // 已删除，所属配置已物理删除
+		{ID: 5, ConfigId: 3, Path: "d.txt"},       // 已删除，存储删除失败
+		{ID: 6, ConfigId: 1, Path: "keep.txt"},    // 未删除
+		{ID: 7, ConfigId: 9, Path: "e.txt"},       // 未删除，所属配置已物理删除
+	}
+	if err := q.InfraFile.WithContext(ctx).Create(files...); err != nil {
+		t.Fatal(err)
+	}
+	f := q.InfraFile
+	if _, err := f.WithContext(ctx).Where(f.ID.In(1, 2, 3, 4, 5)).Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewFileCleanJob(q).Execute(ctx, `{"batchSize": 2}`); err != nil {
+		t.Fatal(err)
+	}
+
+	// 存储删除失败的及未删除的记录保留
+	remaining, err := f.WithContext(ctx).Unscoped().Order(f.ID).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, item := range remaining {
+		ids = append(ids, item.ID)
+	}
+	if len(ids) != 3 || ids[0] != 5 || ids[1] != 6 || ids[2] != 7 {
+		t.Fatalf("unexpected remaining files: %v", ids)
+	}
+	for path, exists := range map[string]bool{
+		filepath.Join(activeDir, "a.txt"):    false,
+		filepath.Join(deletedDir, "b.txt"):   false,
+		filepath.Join(activeDir, "keep.txt"): true,
+	} {
+		if _, err := os.Stat(path); (err == nil) != exists {
+			t.Fatalf("expected %s exists %v, got %v", path, exists, err)
+		}
+	}
+}
@@ -2,19 +2,47 @@ package infra
 
 import (
 	"context"
+	"time"
 
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/infra"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/asyncwriter"
 	"github.com/wxlbd/admin-go/internal/repo/query"
+	"github.com/wxlbd/admin-go/pkg/config"
 	"github.com/wxlbd/admin-go/pkg/pagination"
+
+	"go.uber.org/zap"
 )
 
 type ApiAccessLogService struct {
-	q *query.Query
+	q      *query.Query
+	writer *asyncwriter.Writer[*model.InfraApiAccessLog]
+}
+
+func NewApiAccessLogService(q *query.Query, log *zap.Logger) *ApiAccessLogService {
+	cfg := config.C.APILog
+	s := &ApiAccessLogService{q: q}
+	s.writer = asyncwriter.New("api-access-log", log, asyncwriter.Options{
+		QueueSize:     cfg.QueueSize,
+		BatchSize:     cfg.BatchSize,
+		FlushInterval: time.Duration(cfg.FlushInterval) * time.Millisecond,
+	}, s.createApiAccessLogs)
+	return s
+}
+
+// CreateApiAccessLogAsync 异步记录 API 访问日志，由后台写入器按批次落库
+func (s *ApiAccessLogService) CreateApiAccessLogAsync(log *model.InfraApiAccessLog) {
+	s.writer.Write(log)
+}
+
+// Close 停止接收新日志，并等待已入队的日志落库
+func (s *ApiAccessLogService) Close(ctx context.Context) error {
+	return s.writer.Close(ctx)
 }
 
-func NewApiAccessLogService(q *query.Query) *ApiAccessLogService {
-	return &ApiAccessLogService{q: q}
+// createApiAccessLogs 批量写入 API 访问日志
+func (s *ApiAccessLogService) createApiAccessLogs(ctx context.Context, logs []*model.InfraApiAccessLog) error {
+	return s.q.InfraApiAccessLog.WithContext(ctx).CreateInBatches(logs, len(logs))
 }
 
 // GetApiAccessLogPage 获取API访问日志分页
@@ -6,16 +6,40 @@ import (
 
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/infra"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/asyncwriter"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 	"github.com/wxlbd/admin-go/pkg/pagination"
+
+	"go.uber.org/zap"
 )
 
 type ApiErrorLogService struct {
-	q *query.Query
+	q      *query.Query
+	writer *asyncwriter.Writer[*model.InfraApiErrorLog]
+}
+
+func NewApiErrorLogService(q *query.Query, log *zap.Logger) *ApiErrorLogService {
+	s := &ApiErrorLogService{q: q}
+	// 错误日志量小但不希望丢失，队列容量取默认值，批次较小以便尽快落库
+	s.writer = asyncwriter.New("api-error-log", log, asyncwriter.Options{
+		BatchSize: 20,
+	}, s.createApiErrorLogs)
+	return s
+}
+
+// CreateApiErrorLogAsync 异步记录 API 错误日志
+func (s *ApiErrorLogService) CreateApiErrorLogAsync(log *model.InfraApiErrorLog) {
+	s.writer.Write(log)
+}
+
+// Close 停止接收新日志，并等待已入队的日志落库
+func (s *ApiErrorLogService) Close(ctx context.Context) error {
+	return s.writer.Close(ctx)
 }
 
-func NewApiErrorLogService(q *query.Query) *ApiErrorLogService {
-	return &ApiErrorLogService{q: q}
+// createApiErrorLogs 批量写入 API 错误日志
+func (s *ApiErrorLogService) createApiErrorLogs(ctx context.Context, logs []*model.InfraApiErrorLog) error {
+	return s.q.InfraApiErrorLog.WithContext(ctx).CreateInBatches(logs, len(logs))
 }
 
 // GetApiErrorLogPage 获取API错误日志分页
@@ -55,14 +55,16 @@ func (s *JobService) CreateJob(ctx context.Context, r *infra.JobSaveReq) (int64,
 
 		// 5. 创建任务记录（初始状态）
 		job := &model.InfraJob{
-			Name:           r.Name,
-			Status:         JobStatusInit,
-			HandlerName:    r.HandlerName,
-			HandlerParam:   r.HandlerParam,
-			CronExpression: r.CronExpression,
-			RetryCount:     r.RetryCount,
-			RetryInterval:  r.RetryInterval,
-			MonitorTimeout: monitorTimeout,
+			Name:              r.Name,
+			Status:            JobStatusInit,
+			HandlerName:       r.HandlerName,
+			HandlerParam:      r.HandlerParam,
+			CronExpression:    r.CronExpression,
+			RetryCount:        r.RetryCount,
+			RetryInterval:     r.RetryInterval,
+			MonitorTimeout:    monitorTimeout,
+			ConcurrencyPolicy: r.ConcurrencyPolicy,
+			MisfirePolicy:     r.MisfirePolicy,
 		}
 		if err := tx.InfraJob.WithContext(ctx).Create(job); err != nil {
 			return err
@@ -131,13 +133,15 @@ func (s *JobService) UpdateJob(ctx context.Context, r *infra.JobSaveReq) error {
 		}
 
 		_, err = tx.InfraJob.WithContext(ctx).Where(tx.InfraJob.ID.Eq(*r.ID)).Updates(map[string]interface{}{
-			"name":            r.Name,
-			"handler_name":    r.HandlerName,
-			"handler_param":   r.HandlerParam,
-			"cron_expression": r.CronExpression,
-			"retry_count":     r.RetryCount,
-			"retry_interval":  r.RetryInterval,
-			"monitor_timeout": monitorTimeout,
+			"name":               r.Name,
+			"handler_name":       r.HandlerName,
+			"handler_param":      r.HandlerParam,
+			"cron_expression":    r.CronExpression,
+			"retry_count":        r.RetryCount,
+			"retry_interval":     r.RetryInterval,
+			"monitor_timeout":    monitorTimeout,
+			"concurrency_policy": r.ConcurrencyPolicy,
+			"misfire_policy":     r.MisfirePolicy,
 		})
 		if err != nil {
 			return err
@@ -254,6 +258,25 @@ func (s *JobService) TriggerJob(ctx context.Context, id int64) error {
 	return errors.New("调度器未初始化")
 }
 
+// GetJobExecutions 获取执行中（含排队等待）的任务
+func (s *JobService) GetJobExecutions(ctx context.Context) []*JobExecution {
+	if s.scheduler == nil {
+		return []*JobExecution{}
+	}
+	return s.scheduler.GetExecutions(ctx)
+}
+
+// CancelJobExecution 取消执行中（含排队等待）的任务
+func (s *JobService) CancelJobExecution(ctx context.Context, executionID string) error {
+	if executionID == "" {
+		return errors.New("执行编号不能为空")
+	}
+	if s.scheduler == nil {
+		return errors.New("调度器未初始化")
+	}
+	return s.scheduler.CancelExecution(ctx, executionID)
+}
+
 // SyncJob 同步定时任务 (从数据库重加载)
 func (s *JobService) SyncJob(ctx context.Context) error {
 	if s.scheduler == nil {
@@ -0,0 +1,147 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	pkgTenant "github.com/wxlbd/admin-go/internal/pkg/tenant"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"go.uber.org/zap"
+)
+
+const (
+	// JobLogCleanJobHandlerName 定时任务日志清理任务的处理器名称
+	JobLogCleanJobHandlerName = "jobLogCleanJob"
+	// ApiAccessLogCleanJobHandlerName API 访问日志清理任务的处理器名称
+	ApiAccessLogCleanJobHandlerName = "accessLogCleanJob"
+	// ApiErrorLogCleanJobHandlerName API 错误日志清理任务的处理器名称
+	ApiErrorLogCleanJobHandlerName = "errorLogCleanJob"
+
+	defaultLogRetentionDays  = 14
+	defaultLogCleanBatchSize = 1000
+	maxLogCleanBatchSize     = 10000
+)
+
+// ParseJobParam 解析 JSON 格式的任务参数，参数为空时保留 v 的默认值
+func ParseJobParam(param string, v any) error {
+	param = strings.TrimSpace(param)
+	if param == "" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(param)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("任务参数格式错误: %w", err)
+	}
+	return nil
+}
+
+// LogCleanJobParam 日志清理任务的参数，如 {"retentionDays": 30, "batchSize": 1000}
+type LogCleanJobParam struct {
+	RetentionDays int `json:"retentionDays"` // 保留天数，删除创建时间早于该天数的记录
+	BatchSize     int `json:"batchSize"`     // 每批删除的条数，分批删除避免长时间锁表
+}
+
+// LogPurgeFunc 物理删除 before 之前创建的至多 limit 条记录，返回删除的条数
+type LogPurgeFunc func(ctx context.Context, before time.Time, limit int) (int64, error)
+
+// LogCleanJob 日志清理任务，按保留天数分批物理删除过期的记录，跨租户执行
+type LogCleanJob struct {
+	handlerName          string
+	defaultRetentionDays int
+	purge                LogPurgeFunc
+}
+
+func NewLogCleanJob(handlerName string, defaultRetentionDays int, purge LogPurgeFunc) *LogCleanJob {
+	return &LogCleanJob{
+		handlerName:          handlerName,
+		defaultRetentionDays: defaultRetentionDays,
+		purge:                purge,
+	}
+}
+
+// GetHandlerName 返回处理器名称
+func (j *LogCleanJob) GetHandlerName() string {
+	return j.handlerName
+}
+
+// Execute 执行任务，param 见 LogCleanJobParam
+func (j *LogCleanJob) Execute(ctx context.Context, param string) error {
+	p := LogCleanJobParam{RetentionDays: j.defaultRetentionDays, BatchSize: defaultLogCleanBatchSize}
+	if err := ParseJobParam(param, &p); err != nil {
+		return err
+	}
+	if p.RetentionDays <= 0 {
+		return errors.New("保留天数必须大于 0")
+	}
+	if p.BatchSize <= 0 || p.BatchSize > maxLogCleanBatchSize {
+		return fmt.Errorf("每批删除的条数须在 1 到 %d 之间", maxLogCleanBatchSize)
+	}
+
+	ctx = pkgTenant.SkipTenant(ctx)
+	before := time.Now().AddDate(0, 0, -p.RetentionDays)
+	var deleted int64
+	for {
+		// 超时或取消时停止，已删除的批次不回滚
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		count, err := j.purge(ctx, before, p.BatchSize)
+		deleted += count
+		if err != nil {
+			return err
+		}
+		if count < int64(p.BatchSize) {
+			break
+		}
+	}
+	zap.L().Info("Log clean job finished", zap.String("handler", j.handlerName), zap.Int64("deleted", deleted), zap.Time("before", before))
+	return nil
+}
+
+// JobLogCleanJob 定时任务日志清理任务
+type JobLogCleanJob struct {
+	*LogCleanJob
+}
+
+func NewJobLogCleanJob(q *query.Query) *JobLogCleanJob {
+	return &JobLogCleanJob{NewLogCleanJob(JobLogCleanJobHandlerName, defaultLogRetentionDays,
+		func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			l := q.InfraJobLog
+			info, err := l.WithContext(ctx).Unscoped().Where(l.CreateTime.Lt(before)).Limit(limit).Delete()
+			return info.RowsAffected, err
+		})}
+}
+
+// ApiAccessLogCleanJob API 访问日志清理任务
+type ApiAccessLogCleanJob struct {
+	*LogCleanJob
+}
+
+func NewApiAccessLogCleanJob(q *query.Query) *ApiAccessLogCleanJob {
+	return &ApiAccessLogCleanJob{NewLogCleanJob(ApiAccessLogCleanJobHandlerName, defaultLogRetentionDays,
+		func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			l := q.InfraApiAccessLog
+			info, err := l.WithContext(ctx).Unscoped().Where(l.CreateTime.Lt(before)).Limit(limit).Delete()
+			return info.RowsAffected, err
+		})}
+}
+
+// ApiErrorLogCleanJob API 错误日志清理任务
+type ApiErrorLogCleanJob struct {
+	*LogCleanJob
+}
+
+func NewApiErrorLogCleanJob(q *query.Query) *ApiErrorLogCleanJob {
+	return &ApiErrorLogCleanJob{NewLogCleanJob(ApiErrorLogCleanJobHandlerName, defaultLogRetentionDays,
+		func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			l := q.InfraApiErrorLog
+			info, err := l.WithContext(ctx).Unscoped().Where(l.CreateTime.Lt(before)).Limit(limit).Delete()
+			return info.RowsAffected, err
+		})}
+}
@@ -0,0 +1,49 @@
+package infra
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseJobParam(t *testing.T) {
+	p := LogCleanJobParam{RetentionDays: 14, BatchSize: 1000}
+	if err := ParseJobParam("  ", &p); err != nil || p.RetentionDays != 14 {
+		t.Fatalf("empty param should keep defaults: %+v, %v", p, err)
+	}
+	if err := ParseJobParam(`{"retentionDays": 30}`, &p); err != nil || p.RetentionDays != 30 || p.BatchSize != 1000 {
+		t.Fatalf("unexpected param: %+v, %v", p, err)
+	}
+	// 拼写错误的参数名不能被静默忽略
+	if err := ParseJobParam(`{"retentionDay": 30}`, &p); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestLogCleanJobExecute(t *testing.T) {
+	remaining := int64(25)
+	var batches int
+	var before time.Time
+	job := NewLogCleanJob("testLogCleanJob", 14, func(ctx context.Context, b time.Time, limit int) (int64, error) {
+		batches++
+		before = b
+		count := min(remaining, int64(limit))
+		remaining -= count
+		return count, nil
+	})
+
+	// 分批删除直到不足一批
+	if err := job.Execute(context.Background(), `{"retentionDays": 7, "batchSize": 10}`); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 0 || batches != 3 {
+		t.Fatalf("expected 3 batches, got %d, remaining %d", batches, remaining)
+	}
+	if d := time.Since(before); d < 7*24*time.Hour || d > 7*24*time.Hour+time.Minute {
+		t.Fatalf("unexpected cutoff: %v", before)
+	}
+
+	if err := job.Execute(context.Background(), `{"retentionDays": 0}`); err == nil {
+		t.Fatal("expected error for non-positive retention")
+	}
+}
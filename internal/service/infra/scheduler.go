@@ -2,45 +2,88 @@ package infra
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/robfig/cron/v3"
 	"github.com/wxlbd/admin-go/internal/model"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 	"go.uber.org/zap"
 )
 
+// JobLogStatus 任务日志状态
+const (
+	JobLogStatusRunning = 0 // 运行中
+	JobLogStatusSuccess = 1 // 成功
+	JobLogStatusFailure = 2 // 失败
+)
+
+// cronParser 使用 robfig/cron 解析 cron 表达式，添加 Second 字段以支持 6 字段的 Quartz 格式
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
 // JobHandler 定时任务处理器接口
+// 任务配置了超时时间时，超时后 ctx 会被取消，处理器应据此尽快返回
 type JobHandler interface {
 	Execute(ctx context.Context, param string) error
 	GetHandlerName() string
 }
 
+// JobFailureHook 任务重试次数用尽后仍执行失败时的回调，jobLog 为最后一次执行的日志
+type JobFailureHook interface {
+	OnJobFailed(ctx context.Context, job *model.InfraJob, jobLog *model.InfraJobLog, err error)
+}
+
 // Scheduler 使用 gocron/v2 管理定时任务调度器
+// 每个实例都会启动调度器，定时触发时通过 Redis 触发锁保证只有一个实例执行，
+// 再按任务的并发策略通过 Redis 运行锁控制同一任务的并发执行
 type Scheduler struct {
-	scheduler gocron.Scheduler
-	q         *query.Query
-	log       *zap.Logger
-	handlers  map[string]JobHandler
-	jobMap    map[int64]gocron.Job
-	mu        sync.RWMutex
+	scheduler     gocron.Scheduler
+	q             *query.Query
+	rdb           *redis.Client
+	log           *zap.Logger
+	instanceID    string
+	runningLocker gocron.Locker
+	misfireLocker gocron.Locker
+	handlers      map[string]JobHandler
+	failureHooks  []JobFailureHook
+	jobMap        map[int64]gocron.Job
+	mu            sync.RWMutex
+
+	executions map[string]*jobExecution // 本实例中执行中（含排队等待）的任务
+	execWg     sync.WaitGroup
+	execMu     sync.Mutex
+	closed     bool // 调度器已停止，不再接受新的执行
+	stopCtx    context.Context
+	stop       context.CancelFunc
 }
 
 // NewScheduler 创建新的调度器实例
-func NewScheduler(q *query.Query, log *zap.Logger, handlers []JobHandler) (*Scheduler, error) {
-	s, err := gocron.NewScheduler()
+func NewScheduler(q *query.Query, rdb *redis.Client, log *zap.Logger, handlers []JobHandler, failureHooks []JobFailureHook) (*Scheduler, error) {
+	// 定时触发的任务异步执行，触发锁只在最短持有时间内去重同一次触发，不限制任务的执行时长
+	s, err := gocron.NewScheduler(gocron.WithDistributedLocker(newRedisJobLocker(rdb, log, jobLockMinHold)))
 	if err != nil {
 		return nil, err
 	}
 	scheduler := &Scheduler{
-		scheduler: s,
-		q:         q,
-		log:       log,
-		handlers:  make(map[string]JobHandler),
-		jobMap:    make(map[int64]gocron.Job),
+		scheduler:     s,
+		q:             q,
+		rdb:           rdb,
+		log:           log,
+		instanceID:    instanceID(),
+		runningLocker: newRedisJobLocker(rdb, log, 0),
+		misfireLocker: newRedisJobLocker(rdb, log, jobMisfireLockHold),
+		handlers:      make(map[string]JobHandler),
+		failureHooks:  failureHooks,
+		jobMap:        make(map[int64]gocron.Job),
+		executions:    make(map[string]*jobExecution),
+	}
+	scheduler.stopCtx, scheduler.stop = context.WithCancel(context.Background())
+	if rdb != nil {
+		go scheduler.watchExecutions(scheduler.stopCtx)
 	}
 
 	// 自动注册所有传入的任务处理器
@@ -91,10 +134,19 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		return err
 	}
 
+	// 先读取上次的存活时间再记录本次启动，用于计算停止期间错过的触发
+	lastAlive, err := s.lastAliveTime(ctx)
+	if err != nil {
+		s.log.Warn("Failed to get scheduler alive time, misfire skipped", zap.Error(err))
+	}
+	s.keepAlive(ctx)
+
 	for _, job := range jobs {
 		if err := s.scheduleJob(ctx, job); err != nil {
 			s.log.Error("Failed to schedule job", zap.Int64("jobId", job.ID), zap.Error(err))
+			continue
 		}
+		go s.handleMisfire(ctx, job, lastAlive)
 	}
 
 	s.scheduler.Start()
@@ -102,9 +154,49 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	return nil
 }
 
-// Shutdown 停止调度器
-func (s *Scheduler) Shutdown() error {
-	return s.scheduler.Shutdown()
+// Shutdown 停止调度器：不再触发新的执行，并等待执行中的任务结束
+// ctx 到期后取消仍在执行及排队等待的任务，并将本实例执行中的日志标记为执行中断
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.execMu.Lock()
+	s.closed = true
+	s.execMu.Unlock()
+	defer s.stop()
+
+	err := s.scheduler.Shutdown()
+	// 停止触发后记录存活时间，下次启动时从此处开始计算错过的触发
+	s.keepAlive(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.execWg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.log.Info("Scheduler stopped")
+		return err
+	case <-ctx.Done():
+	}
+
+	s.execMu.Lock()
+	for _, exec := range s.executions {
+		exec.cancel()
+	}
+	s.execMu.Unlock()
+	// 留出时间让被取消的任务自行写入日志，仍未结束的统一标记为执行中断
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+	l := s.q.InfraJobLog
+	info, updateErr := l.WithContext(context.Background()).
+		Where(l.InstanceID.Eq(s.instanceID), l.Status.Eq(JobLogStatusRunning)).
+		Updates(map[string]interface{}{
+			"end_time": time.Now(),
+			"status":   JobLogStatusFailure,
+			"result":   "服务停止，任务执行中断",
+		})
+	s.log.Warn("Scheduler stopped before running jobs finished", zap.Int64("interrupted", info.RowsAffected))
+	return errors.Join(err, ctx.Err(), updateErr)
 }
 
 // scheduleJob 将单个任务添加到调度器
@@ -120,8 +212,11 @@ func (s *Scheduler) scheduleJob(ctx context.Context, job *model.InfraJob) error
 	gocronJob, err := s.scheduler.NewJob(
 		gocron.CronJob(job.CronExpression, true),
 		gocron.NewTask(func() {
-			s.executeJob(ctx, job, handler)
+			if err := s.dispatch(job, handler, JobTriggerSchedule); err != nil {
+				s.log.Warn("Job trigger skipped", zap.Int64("jobId", job.ID), zap.Error(err))
+			}
 		}),
+		// 任务名称同时作为分布式执行锁的 Key
 		gocron.WithName(fmt.Sprintf("job-%d", job.ID)),
 	)
 	if err != nil {
@@ -133,42 +228,104 @@ func (s *Scheduler) scheduleJob(ctx context.Context, job *model.InfraJob) error
 	return nil
 }
 
-// executeJob 执行任务并记录结果
-func (s *Scheduler) executeJob(ctx context.Context, job *model.InfraJob, handler JobHandler) {
-	beginTime := time.Now()
+// executeJob 执行任务，失败时按任务的重试次数与重试间隔重试，每次执行记录一条日志
+// 重试次数用尽后仍失败时，调用失败回调发送告警
+// 执行被取消时不再重试，也不发送告警
+func (s *Scheduler) executeJob(exec *jobExecution) {
+	ctx, job := exec.ctx, exec.job
+
+	var logRecord *model.InfraJobLog
+	var err error
+	for attempt := 1; attempt <= job.RetryCount+1; attempt++ {
+		if attempt > 1 && job.RetryInterval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(job.RetryInterval) * time.Millisecond):
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.updateExecution(exec, func(e *JobExecution) { e.ExecuteIndex = attempt })
+		logRecord, err = s.executeOnce(ctx, job, exec.handler, attempt)
+		if err == nil {
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
 
-	logRecord := &model.InfraJobLog{
+	for _, hook := range s.failureHooks {
+		hook.OnJobFailed(ctx, job, logRecord, err)
+	}
+}
+
+// executeOnce 执行一次任务并记录日志，任务配置了超时时间时，超时后取消处理器的 Context
+func (s *Scheduler) executeOnce(ctx context.Context, job *model.InfraJob, handler JobHandler, attempt int) (logRecord *model.InfraJobLog, err error) {
+	beginTime := time.Now()
+	logRecord = &model.InfraJobLog{
 		JobID:        job.ID,
 		HandlerName:  job.HandlerName,
 		HandlerParam: job.HandlerParam,
-		ExecuteIndex: 1,
+		ExecuteIndex: attempt,
+		InstanceID:   s.instanceID,
 		BeginTime:    beginTime,
-		Status:       0,
+		Status:       JobLogStatusRunning,
+	}
+	// 执行被取消后仍需写入日志
+	dbCtx := context.WithoutCancel(ctx)
+	if err := s.q.InfraJobLog.WithContext(dbCtx).Create(logRecord); err != nil {
+		// 无法记录日志时不执行处理器，按执行失败处理，由重试及失败回调兜底
+		s.log.Error("Failed to create job log", zap.Int64("jobId", job.ID), zap.Int("executeIndex", attempt), zap.Error(err))
+		return logRecord, fmt.Errorf("记录任务日志失败: %w", err)
 	}
-	_ = s.q.InfraJobLog.WithContext(ctx).Create(logRecord)
 
-	var status int
-	var result string
-	err := handler.Execute(ctx, job.HandlerParam)
-	endTime := time.Now()
-	duration := int(endTime.Sub(beginTime).Milliseconds())
+	defer func() {
+		endTime := time.Now()
+		duration := int(endTime.Sub(beginTime).Milliseconds())
+		status, result := JobLogStatusSuccess, "success"
+		if err != nil {
+			status, result = JobLogStatusFailure, err.Error()
+			s.log.Error("Job execution failed", zap.Int64("jobId", job.ID), zap.Int("executeIndex", attempt), zap.Error(err))
+		} else {
+			s.log.Info("Job execution completed", zap.Int64("jobId", job.ID), zap.Int("executeIndex", attempt), zap.Int("duration", duration))
+		}
+		logRecord.EndTime, logRecord.Duration, logRecord.Status, logRecord.Result = &endTime, &duration, status, result
+		_, _ = s.q.InfraJobLog.WithContext(dbCtx).Where(s.q.InfraJobLog.ID.Eq(logRecord.ID)).Updates(map[string]interface{}{
+			"end_time": endTime,
+			"duration": duration,
+			"status":   status,
+			"result":   result,
+		})
+	}()
 
+	execCtx := ctx
+	if job.MonitorTimeout != nil && *job.MonitorTimeout > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, time.Duration(*job.MonitorTimeout)*time.Millisecond)
+		defer cancel()
+	}
+	err = runHandler(execCtx, handler, job.HandlerParam)
 	if err != nil {
-		status = 2
-		result = err.Error()
-		s.log.Error("Job execution failed", zap.Int64("jobId", job.ID), zap.Error(err))
-	} else {
-		status = 1
-		result = "success"
-		s.log.Info("Job execution completed", zap.Int64("jobId", job.ID), zap.Int("duration", duration))
+		switch {
+		case errors.Is(ctx.Err(), context.Canceled):
+			err = fmt.Errorf("任务已取消: %w", err)
+		case errors.Is(execCtx.Err(), context.DeadlineExceeded):
+			err = fmt.Errorf("任务执行超时（%dms）: %w", *job.MonitorTimeout, err)
+		}
 	}
+	return logRecord, err
+}
 
-	_, _ = s.q.InfraJobLog.WithContext(ctx).Where(s.q.InfraJobLog.ID.Eq(logRecord.ID)).Updates(map[string]interface{}{
-		"end_time": endTime,
-		"duration": duration,
-		"status":   status,
-		"result":   result,
-	})
+// runHandler 执行处理器，将 panic 转换为执行失败
+func runHandler(ctx context.Context, handler JobHandler, param string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler.Execute(ctx, param)
 }
 
 // AddJob 向调度器添加新任务
@@ -209,7 +366,7 @@ func (s *Scheduler) UpdateJobStatus(ctx context.Context, jobID int64, status int
 	return s.RemoveJob(jobID)
 }
 
-// TriggerJob 立即执行任务
+// TriggerJob 立即执行任务，按任务的并发策略处理正在执行的情况
 func (s *Scheduler) TriggerJob(ctx context.Context, jobID int64) error {
 	job, err := s.q.InfraJob.WithContext(ctx).Where(s.q.InfraJob.ID.Eq(jobID)).First()
 	if err != nil {
@@ -223,14 +380,12 @@ func (s *Scheduler) TriggerJob(ctx context.Context, jobID int64) error {
 		return fmt.Errorf("handler not found: %s", job.HandlerName)
 	}
 
-	go s.executeJob(ctx, job, handler)
-	return nil
+	return s.dispatch(job, handler, JobTriggerManual)
 }
 
 // ValidateCronExpression 校验 cron 表达式是否合法
 func (s *Scheduler) ValidateCronExpression(cronExpression string) error {
-	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
-	_, err := parser.Parse(cronExpression)
+	_, err := cronParser.Parse(cronExpression)
 	if err != nil {
 		return fmt.Errorf("无效的 cron 表达式: %w", err)
 	}
@@ -240,10 +395,7 @@ func (s *Scheduler) ValidateCronExpression(cronExpression string) error {
 // GetNextTimes 计算 cron 表达式的下 n 次执行时间
 // 支持标准 5 字段格式 (分 时 日 月 周) 和 Quartz 6 字段格式 (秒 分 时 日 月 周)
 func (s *Scheduler) GetNextTimes(cronExpression string, count int) ([]string, error) {
-	// 使用 robfig/cron 解析 cron 表达式
-	// 添加 Second 字段以支持 6 字段的 Quartz 格式
-	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
-	schedule, err := parser.Parse(cronExpression)
+	schedule, err := cronParser.Parse(cronExpression)
 	if err != nil {
 		return nil, fmt.Errorf("无效的 cron 表达式: %w", err)
 	}
@@ -0,0 +1,378 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/wxlbd/admin-go/internal/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// RedisKeyJobExecution 执行中的任务，格式：job_execution:{executionId}
+	// 执行所在的实例定期续期，实例宕机后自动过期
+	RedisKeyJobExecution = "job_execution:%s"
+	// JobExecutionCancelChannel 取消任务执行的 Redis 频道，消息为执行编号
+	JobExecutionCancelChannel = "job_execution:cancel"
+
+	jobExecutionTTL = 30 * time.Second
+	// jobQueueWaitInterval 排队等待的执行重新获取运行锁的间隔
+	jobQueueWaitInterval = time.Second
+	// jobQueueLimit 本实例中单个任务排队等待的执行数上限
+	jobQueueLimit = 10
+)
+
+// JobConcurrencyPolicy 任务并发策略
+const (
+	JobConcurrencySkip  = 0 // 任务执行中时跳过本次触发
+	JobConcurrencyAllow = 1 // 允许并发执行
+	JobConcurrencyQueue = 2 // 排队等待上一次执行结束
+)
+
+// JobTrigger 任务的触发方式
+const (
+	JobTriggerSchedule = 1 // 定时触发
+	JobTriggerManual   = 2 // 手动触发
+	JobTriggerMisfire  = 3 // 错过触发后的补偿执行
+)
+
+// JobExecutionStatus 执行状态
+const (
+	JobExecutionWaiting = 0 // 排队等待
+	JobExecutionRunning = 1 // 执行中
+)
+
+var (
+	// ErrJobRunning 任务执行中，按并发策略跳过本次触发
+	ErrJobRunning = errors.New("任务正在执行中")
+	// ErrJobQueueFull 任务排队等待的执行过多
+	ErrJobQueueFull = errors.New("任务排队等待的执行过多")
+	// ErrJobExecutionNotFound 执行不存在或已结束
+	ErrJobExecutionNotFound = errors.New("任务执行不存在或已结束")
+	// ErrSchedulerClosed 调度器已停止
+	ErrSchedulerClosed = errors.New("调度器已停止")
+)
+
+// JobExecution 执行中（含排队等待）的任务
+type JobExecution struct {
+	ID           string    `json:"id"`
+	JobID        int64     `json:"jobId"`
+	JobName      string    `json:"jobName"`
+	HandlerName  string    `json:"handlerName"`
+	Trigger      int       `json:"trigger"`
+	Status       int       `json:"status"`
+	ExecuteIndex int       `json:"executeIndex"` // 当前第几次执行，排队等待时为 0
+	InstanceID   string    `json:"instanceId"`
+	TriggerTime  time.Time `json:"triggerTime"`
+}
+
+// jobExecution 本实例中的一次执行，字段变更须持有 Scheduler.execMu
+type jobExecution struct {
+	JobExecution
+	job         *model.InfraJob
+	handler     JobHandler
+	ctx         context.Context
+	cancel      context.CancelFunc
+	runningLock gocron.Lock // 持有运行锁时不为 nil
+}
+
+// dispatch 按任务的并发策略异步执行任务
+func (s *Scheduler) dispatch(job *model.InfraJob, handler JobHandler, trigger int) error {
+	exec, err := s.startExecution(job, handler, trigger)
+	if err != nil {
+		return err
+	}
+	go s.runExecution(exec)
+	return nil
+}
+
+// startExecution 登记一次执行，执行中跳过的策略下在此获取运行锁
+func (s *Scheduler) startExecution(job *model.InfraJob, handler JobHandler, trigger int) (*jobExecution, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	exec := &jobExecution{
+		JobExecution: JobExecution{
+			ID:          uuid.NewString(),
+			JobID:       job.ID,
+			JobName:     job.Name,
+			HandlerName: job.HandlerName,
+			Trigger:     trigger,
+			Status:      JobExecutionWaiting,
+			InstanceID:  s.instanceID,
+			TriggerTime: time.Now(),
+		},
+		job:     job,
+		handler: handler,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	s.execMu.Lock()
+	if s.closed {
+		s.execMu.Unlock()
+		cancel()
+		return nil, ErrSchedulerClosed
+	}
+	if job.ConcurrencyPolicy == JobConcurrencyQueue && s.countWaiting(job.ID) >= jobQueueLimit {
+		s.execMu.Unlock()
+		cancel()
+		return nil, ErrJobQueueFull
+	}
+	s.executions[exec.ID] = exec
+	s.execWg.Add(1)
+	s.execMu.Unlock()
+
+	if job.ConcurrencyPolicy == JobConcurrencySkip {
+		if err := s.acquireRunning(exec); err != nil {
+			s.finishExecution(exec)
+			if errors.Is(err, ErrJobLocked) {
+				return nil, ErrJobRunning
+			}
+			return nil, err
+		}
+	}
+	s.saveExecution(exec)
+	return exec, nil
+}
+
+// runExecution 执行任务，排队等待的策略下先等待获取运行锁
+func (s *Scheduler) runExecution(exec *jobExecution) {
+	defer s.finishExecution(exec)
+	if exec.job.ConcurrencyPolicy == JobConcurrencyQueue && !s.waitRunning(exec) {
+		s.log.Info("Job execution cancelled while waiting", zap.Int64("jobId", exec.JobID), zap.String("executionId", exec.ID))
+		return
+	}
+	s.updateExecution(exec, func(e *JobExecution) { e.Status = JobExecutionRunning })
+	s.executeJob(exec)
+}
+
+// acquireRunning 获取任务的运行锁，本实例内与各实例之间同一任务同时只有一个执行持有
+func (s *Scheduler) acquireRunning(exec *jobExecution) error {
+	s.execMu.Lock()
+	for _, other := range s.executions {
+		if other != exec && other.JobID == exec.JobID && other.runningLock != nil {
+			s.execMu.Unlock()
+			return ErrJobLocked
+		}
+	}
+	// 先占位，避免获取 Redis 锁期间本实例的其他执行也获取成功
+	exec.runningLock = noopJobLock{}
+	s.execMu.Unlock()
+
+	lock, err := s.runningLocker.Lock(exec.ctx, fmt.Sprintf("running-%d", exec.JobID))
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+	if err != nil {
+		exec.runningLock = nil
+		return err
+	}
+	exec.runningLock = lock
+	return nil
+}
+
+// waitRunning 排队等待获取运行锁，执行被取消时返回 false
+func (s *Scheduler) waitRunning(exec *jobExecution) bool {
+	for {
+		err := s.acquireRunning(exec)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, ErrJobLocked) {
+			s.log.Warn("Failed to acquire job running lock", zap.Int64("jobId", exec.JobID), zap.Error(err))
+		}
+		select {
+		case <-exec.ctx.Done():
+			return false
+		case <-time.After(jobQueueWaitInterval):
+		}
+	}
+}
+
+// finishExecution 释放运行锁并移除执行记录
+func (s *Scheduler) finishExecution(exec *jobExecution) {
+	defer s.execWg.Done()
+	exec.cancel()
+	s.execMu.Lock()
+	delete(s.executions, exec.ID)
+	lock := exec.runningLock
+	exec.runningLock = nil
+	s.execMu.Unlock()
+
+	ctx := context.Background()
+	if lock != nil {
+		if err := lock.Unlock(ctx); err != nil {
+			s.log.Warn("Failed to release job running lock", zap.Int64("jobId", exec.JobID), zap.Error(err))
+		}
+	}
+	if s.rdb != nil {
+		_ = s.rdb.Del(ctx, fmt.Sprintf(RedisKeyJobExecution, exec.ID)).Err()
+	}
+}
+
+// countWaiting 统计本实例中任务排队等待的执行数，调用方须持有 execMu
+func (s *Scheduler) countWaiting(jobID int64) int {
+	count := 0
+	for _, exec := range s.executions {
+		if exec.JobID == jobID && exec.Status == JobExecutionWaiting {
+			count++
+		}
+	}
+	return count
+}
+
+// updateExecution 更新执行状态并同步到 Redis
+func (s *Scheduler) updateExecution(exec *jobExecution, fn func(e *JobExecution)) {
+	s.execMu.Lock()
+	fn(&exec.JobExecution)
+	s.execMu.Unlock()
+	s.saveExecution(exec)
+}
+
+// saveExecution 将执行记录写入 Redis，供其他实例查询
+func (s *Scheduler) saveExecution(exec *jobExecution) {
+	if s.rdb == nil {
+		return
+	}
+	s.execMu.Lock()
+	if _, ok := s.executions[exec.ID]; !ok {
+		// 执行已结束
+		s.execMu.Unlock()
+		return
+	}
+	data, err := json.Marshal(exec.JobExecution)
+	s.execMu.Unlock()
+	if err != nil {
+		return
+	}
+	if err := s.rdb.Set(context.Background(), fmt.Sprintf(RedisKeyJobExecution, exec.ID), data, jobExecutionTTL).Err(); err != nil {
+		s.log.Warn("Failed to save job execution", zap.String("executionId", exec.ID), zap.Error(err))
+	}
+}
+
+// localExecutions 返回本实例中的执行记录
+func (s *Scheduler) localExecutions() []*JobExecution {
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+	list := make([]*JobExecution, 0, len(s.executions))
+	for _, exec := range s.executions {
+		e := exec.JobExecution
+		list = append(list, &e)
+	}
+	return list
+}
+
+// GetExecutions 获取所有实例中执行中（含排队等待）的任务，Redis 不可用时只返回本实例的执行
+func (s *Scheduler) GetExecutions(ctx context.Context) []*JobExecution {
+	list, err := s.remoteExecutions(ctx)
+	if err != nil {
+		if s.rdb != nil {
+			s.log.Warn("Failed to get job executions", zap.Error(err))
+		}
+		list = s.localExecutions()
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].TriggerTime.Before(list[j].TriggerTime)
+	})
+	return list
+}
+
+func (s *Scheduler) remoteExecutions(ctx context.Context) ([]*JobExecution, error) {
+	if s.rdb == nil {
+		return nil, errors.New("redis is not configured")
+	}
+	var keys []string
+	iter := s.rdb.Scan(ctx, 0, fmt.Sprintf(RedisKeyJobExecution, "*"), 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	list := make([]*JobExecution, 0, len(keys))
+	if len(keys) == 0 {
+		return list, nil
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var exec JobExecution
+		if err := json.Unmarshal([]byte(data), &exec); err == nil {
+			list = append(list, &exec)
+		}
+	}
+	return list, nil
+}
+
+// CancelExecution 取消执行中（含排队等待）的任务，执行在其他实例时通过 Redis 通知该实例取消
+// 取消通过处理器的 Context 传递，处理器未响应取消时会执行到结束
+func (s *Scheduler) CancelExecution(ctx context.Context, executionID string) error {
+	if s.cancelLocal(executionID) {
+		return nil
+	}
+	if s.rdb == nil {
+		return ErrJobExecutionNotFound
+	}
+	exists, err := s.rdb.Exists(ctx, fmt.Sprintf(RedisKeyJobExecution, executionID)).Result()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrJobExecutionNotFound
+	}
+	return s.rdb.Publish(ctx, JobExecutionCancelChannel, executionID).Err()
+}
+
+func (s *Scheduler) cancelLocal(executionID string) bool {
+	s.execMu.Lock()
+	exec, ok := s.executions[executionID]
+	s.execMu.Unlock()
+	if !ok {
+		return false
+	}
+	exec.cancel()
+	s.log.Info("Job execution cancelled", zap.Int64("jobId", exec.JobID), zap.String("executionId", executionID))
+	return true
+}
+
+// watchExecutions 定期续期本实例执行记录的有效期并记录调度器的存活时间，同时订阅其他实例转发的取消请求
+func (s *Scheduler) watchExecutions(ctx context.Context) {
+	pubsub := s.rdb.Subscribe(ctx, JobExecutionCancelChannel)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	ticker := time.NewTicker(jobExecutionTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			s.cancelLocal(strings.TrimSpace(msg.Payload))
+		case <-ticker.C:
+			s.keepAlive(ctx)
+			s.execMu.Lock()
+			list := make([]*jobExecution, 0, len(s.executions))
+			for _, exec := range s.executions {
+				list = append(list, exec)
+			}
+			s.execMu.Unlock()
+			for _, exec := range list {
+				s.saveExecution(exec)
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/model"
+)
+
+// blockingJobHandler 执行时通知 started，并等待 release 关闭后返回
+type blockingJobHandler struct {
+	testJobHandler
+	started  chan struct{}
+	release  chan struct{}
+	executed atomic.Int32
+}
+
+func newBlockingJobHandler() *blockingJobHandler {
+	h := &blockingJobHandler{started: make(chan struct{}, 10), release: make(chan struct{})}
+	h.execute = func(ctx context.Context, param string) error {
+		h.executed.Add(1)
+		h.started <- struct{}{}
+		select {
+		case <-h.release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return h
+}
+
+func waitStarted(t *testing.T, h *blockingJobHandler) {
+	t.Helper()
+	select {
+	case <-h.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job execution did not start")
+	}
+}
+
+func TestDispatchSkip(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	q := newTestQuery(t)
+	s := newTestScheduler(t, q, rdb)
+	other := newTestScheduler(t, q, rdb)
+
+	h := newBlockingJobHandler()
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", ConcurrencyPolicy: JobConcurrencySkip}
+	if err := s.dispatch(job, h, JobTriggerManual); err != nil {
+		t.Fatal(err)
+	}
+	waitStarted(t, h)
+
+	// 执行中跳过本实例及其他实例的触发
+	if err := s.dispatch(job, h, JobTriggerSchedule); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("expected ErrJobRunning, got %v", err)
+	}
+	if err := other.dispatch(job, h, JobTriggerSchedule); !errors.Is(err, ErrJobRunning) {
+		t.Fatalf("expected ErrJobRunning on other instance, got %v", err)
+	}
+	if list := other.GetExecutions(context.Background()); len(list) != 1 || list[0].Status != JobExecutionRunning {
+		t.Fatalf("unexpected executions: %+v", list)
+	}
+
+	close(h.release)
+	s.execWg.Wait()
+	if executed := h.executed.Load(); executed != 1 {
+		t.Fatalf("expected 1 execution, got %d", executed)
+	}
+	// 执行结束后释放运行锁
+	if err := other.dispatch(job, h, JobTriggerSchedule); err != nil {
+		t.Fatal(err)
+	}
+	other.execWg.Wait()
+}
+
+func TestDispatchQueue(t *testing.T) {
+	q := newTestQuery(t)
+	s := newTestScheduler(t, q, nil)
+
+	h := newBlockingJobHandler()
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", ConcurrencyPolicy: JobConcurrencyQueue}
+	if err := s.dispatch(job, h, JobTriggerManual); err != nil {
+		t.Fatal(err)
+	}
+	waitStarted(t, h)
+	if err := s.dispatch(job, h, JobTriggerSchedule); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.dispatch(job, h, JobTriggerSchedule); err != nil {
+		t.Fatal(err)
+	}
+
+	// 排队等待的执行可以被取消
+	var waiting []*JobExecution
+	for _, exec := range s.GetExecutions(context.Background()) {
+		if exec.Status == JobExecutionWaiting {
+			waiting = append(waiting, exec)
+		}
+	}
+	if len(waiting) != 2 {
+		t.Fatalf("expected 2 waiting executions, got %d", len(waiting))
+	}
+	if err := s.CancelExecution(context.Background(), waiting[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 上一次执行结束后，排队的执行依次执行
+	close(h.release)
+	s.execWg.Wait()
+	if executed := h.executed.Load(); executed != 2 {
+		t.Fatalf("expected 2 executions, got %d", executed)
+	}
+	if logs := findJobLogs(t, q, job.ID); len(logs) != 2 {
+		t.Fatalf("expected 2 job logs, got %d", len(logs))
+	}
+}
+
+func TestDispatchQueueLimit(t *testing.T) {
+	q := newTestQuery(t)
+	s := newTestScheduler(t, q, nil)
+
+	h := newBlockingJobHandler()
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", ConcurrencyPolicy: JobConcurrencyQueue}
+	if err := s.dispatch(job, h, JobTriggerManual); err != nil {
+		t.Fatal(err)
+	}
+	waitStarted(t, h)
+	for i := 0; i < jobQueueLimit; i++ {
+		if err := s.dispatch(job, h, JobTriggerSchedule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.dispatch(job, h, JobTriggerSchedule); !errors.Is(err, ErrJobQueueFull) {
+		t.Fatalf("expected ErrJobQueueFull, got %v", err)
+	}
+
+	for _, exec := range s.GetExecutions(context.Background()) {
+		_ = s.CancelExecution(context.Background(), exec.ID)
+	}
+	s.execWg.Wait()
+}
+
+func TestDispatchAllow(t *testing.T) {
+	q := newTestQuery(t)
+	s := newTestScheduler(t, q, nil)
+
+	h := newBlockingJobHandler()
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", ConcurrencyPolicy: JobConcurrencyAllow}
+	for i := 0; i < 2; i++ {
+		if err := s.dispatch(job, h, JobTriggerManual); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 两次执行同时进行
+	waitStarted(t, h)
+	waitStarted(t, h)
+	close(h.release)
+	s.execWg.Wait()
+}
@@ -0,0 +1,141 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// RedisKeyJobLock 定时任务执行锁，格式：job_lock:{key}
+	// key 为 job-{jobId} 时是每次触发的触发锁，为 running-{jobId} 时是任务执行期间持有的运行锁
+	RedisKeyJobLock = "job_lock:%s"
+
+	// jobLockTTL 执行锁有效期，任务执行期间定期续期，实例宕机后自动释放
+	jobLockTTL = 30 * time.Second
+	// jobLockMinHold 触发锁的最短持有时间，吸收各实例间触发时间的偏差，避免其他实例再次执行同一次触发
+	jobLockMinHold = 5 * time.Second
+)
+
+// ErrJobLocked 执行锁已被持有：本次触发已由其他实例执行，或任务正在执行
+var ErrJobLocked = errors.New("job is running on another instance")
+
+var (
+	// unlockScript 仅持有者可释放锁，未到最短持有时间时缩短有效期而不是立即删除
+	unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+local hold = tonumber(ARGV[2])
+if hold > 0 then
+	return redis.call("PEXPIRE", KEYS[1], hold)
+end
+return redis.call("DEL", KEYS[1])
+`)
+	// renewScript 仅持有者可续期
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+`)
+)
+
+// instanceID 当前实例的标识，记录在任务日志中
+func instanceID() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// redisJobLocker 基于 Redis 的定时任务执行锁，用于保证多实例部署时每次触发只有一个实例执行，
+// 以及按并发策略保证同一任务同时只有一个执行
+// Redis 不可用时降级为本实例直接执行，避免单实例部署时任务停止运行
+type redisJobLocker struct {
+	rdb           *redis.Client
+	log           *zap.Logger
+	minHold       time.Duration // 锁的最短持有时间，释放时未到该时间则保留至到期
+	renewInterval time.Duration // 续期间隔
+}
+
+func newRedisJobLocker(rdb *redis.Client, log *zap.Logger, minHold time.Duration) gocron.Locker {
+	return &redisJobLocker{rdb: rdb, log: log, minHold: minHold, renewInterval: jobLockTTL / 3}
+}
+
+// Lock 获取任务的执行锁，已被持有时返回 ErrJobLocked
+func (l *redisJobLocker) Lock(ctx context.Context, key string) (gocron.Lock, error) {
+	if l.rdb == nil {
+		return noopJobLock{}, nil
+	}
+	redisKey := fmt.Sprintf(RedisKeyJobLock, key)
+	token := uuid.NewString()
+	ok, err := l.rdb.SetNX(ctx, redisKey, token, jobLockTTL).Result()
+	if err != nil {
+		l.log.Warn("Failed to acquire job lock, running locally", zap.String("job", key), zap.Error(err))
+		return noopJobLock{}, nil
+	}
+	if !ok {
+		return nil, ErrJobLocked
+	}
+
+	lock := &redisJobLock{
+		rdb:       l.rdb,
+		log:       l.log,
+		key:       redisKey,
+		token:     token,
+		minHold:   l.minHold,
+		beginTime: time.Now(),
+		stop:      make(chan struct{}),
+	}
+	go lock.renew(l.renewInterval)
+	return lock, nil
+}
+
+// redisJobLock 已获取的执行锁，任务执行期间定期续期
+type redisJobLock struct {
+	rdb       *redis.Client
+	log       *zap.Logger
+	key       string
+	token     string
+	minHold   time.Duration
+	beginTime time.Time
+	stop      chan struct{}
+}
+
+func (l *redisJobLock) renew(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := renewScript.Run(context.Background(), l.rdb, []string{l.key}, l.token, jobLockTTL.Milliseconds()).Err(); err != nil {
+				l.log.Warn("Failed to renew job lock", zap.String("key", l.key), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Unlock 释放执行锁
+func (l *redisJobLock) Unlock(ctx context.Context) error {
+	close(l.stop)
+	hold := l.minHold - time.Since(l.beginTime)
+	if hold < 0 {
+		hold = 0
+	}
+	return unlockScript.Run(ctx, l.rdb, []string{l.key}, l.token, hold.Milliseconds()).Err()
+}
+
+// noopJobLock Redis 不可用时的本地执行锁
+type noopJobLock struct{}
+
+func (noopJobLock) Unlock(context.Context) error {
+	return nil
+}
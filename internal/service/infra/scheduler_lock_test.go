@@ -0,0 +1,145 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// newTestRedis 创建基于 miniredis 的 Redis 客户端
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+func TestRedisJobLockerLock(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	locker := newRedisJobLocker(rdb, zap.NewNop(), 0)
+	ctx := context.Background()
+
+	lock, err := locker.Lock(ctx, "running-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locker.Lock(ctx, "running-1"); !errors.Is(err, ErrJobLocked) {
+		t.Fatalf("expected ErrJobLocked, got %v", err)
+	}
+	// 不同任务的锁互不影响
+	other, err := locker.Lock(ctx, "running-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未设置最短持有时间时立即释放
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists(fmt.Sprintf(RedisKeyJobLock, "running-1")) {
+		t.Fatal("expected lock to be released")
+	}
+	lock, err = locker.Lock(ctx, "running-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock(ctx)
+
+	// 锁已过期并被其他持有者获取时，不能释放其他持有者的锁
+	key := fmt.Sprintf(RedisKeyJobLock, "running-2")
+	mr.Set(key, "other")
+	if err := other.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := mr.Get(key); value != "other" {
+		t.Fatalf("expected lock of other holder to be kept, got %q", value)
+	}
+}
+
+func TestRedisJobLockerMinHold(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	locker := newRedisJobLocker(rdb, zap.NewNop(), jobLockMinHold)
+	ctx := context.Background()
+	key := fmt.Sprintf(RedisKeyJobLock, "job-1")
+
+	lock, err := locker.Lock(ctx, "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 未到最短持有时间，保留至到期，其他实例的同一次触发不再执行
+	if ttl := mr.TTL(key); ttl <= 0 || ttl > jobLockMinHold {
+		t.Fatalf("expected lock to be kept for min hold, got ttl %v", ttl)
+	}
+	if _, err := locker.Lock(ctx, "job-1"); !errors.Is(err, ErrJobLocked) {
+		t.Fatalf("expected ErrJobLocked, got %v", err)
+	}
+
+	mr.FastForward(jobLockMinHold)
+	lock, err = locker.Lock(ctx, "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = lock.Unlock(ctx)
+}
+
+func TestRedisJobLockerRenew(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	locker := &redisJobLocker{rdb: rdb, log: zap.NewNop(), renewInterval: 10 * time.Millisecond}
+	ctx := context.Background()
+	key := fmt.Sprintf(RedisKeyJobLock, "running-1")
+
+	lock, err := locker.Lock(ctx, "running-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock(ctx)
+
+	// 执行期间定期把有效期续回 jobLockTTL
+	mr.SetTTL(key, time.Second)
+	deadline := time.Now().Add(3 * time.Second)
+	for mr.TTL(key) != jobLockTTL {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected lock to be renewed, got ttl %v", mr.TTL(key))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedisJobLockerFallback(t *testing.T) {
+	ctx := context.Background()
+
+	// 未配置 Redis 时直接在本实例执行
+	lock, err := newRedisJobLocker(nil, zap.NewNop(), jobLockMinHold).Lock(ctx, "job-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Redis 不可用时降级为本实例执行，避免任务停止运行
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	mr.Close()
+	t.Cleanup(func() { _ = rdb.Close() })
+	locker := newRedisJobLocker(rdb, zap.NewNop(), jobLockMinHold)
+	for i := 0; i < 2; i++ {
+		lock, err := locker.Lock(ctx, "job-1")
+		if err != nil {
+			t.Fatalf("expected fallback lock, got %v", err)
+		}
+		if _, ok := lock.(noopJobLock); !ok {
+			t.Fatalf("expected noop lock, got %T", lock)
+		}
+	}
+}
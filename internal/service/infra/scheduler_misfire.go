@@ -0,0 +1,113 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+	"github.com/wxlbd/admin-go/internal/model"
+	"go.uber.org/zap"
+)
+
+// JobMisfirePolicy 调度器停止期间错过触发的补偿策略
+const (
+	JobMisfireIgnore   = 0 // 忽略错过的触发
+	JobMisfireFireOnce = 1 // 补偿执行一次
+	JobMisfireFireAll  = 2 // 按错过的次数逐次补偿执行
+)
+
+const (
+	// RedisKeyJobSchedulerAlive 调度器最近的存活时间（毫秒时间戳），各实例定期写入，作为计算错过触发的起点
+	RedisKeyJobSchedulerAlive = "job_scheduler:alive"
+
+	// jobMisfireLimit 补偿执行全部错过的触发时，最多补偿的次数
+	jobMisfireLimit = 100
+	// jobMisfireLockHold 补偿锁的最短持有时间，覆盖多个实例先后启动的间隔，避免其他实例重复补偿
+	jobMisfireLockHold = 10 * time.Minute
+	// jobMisfireAliveGap 各实例每 jobExecutionTTL/3 记录一次存活时间，超过该间隔（含余量）未记录时才认为所有实例均已停止
+	jobMisfireAliveGap = jobExecutionTTL
+)
+
+// handleMisfire 调度器启动时，按任务的补偿策略补偿停止期间错过的触发
+// 以调度器最近的存活时间与任务的更新时间中较晚者为起点计算错过的触发，
+// 没有存活记录（未配置 Redis 或首次启动）时无法确定停止期间，不补偿；
+// 存活时间为所有实例共用，距今不超过 jobMisfireAliveGap 时仍有其他实例在运行并已执行期间的触发，不补偿
+func (s *Scheduler) handleMisfire(ctx context.Context, job *model.InfraJob, lastAlive time.Time) {
+	if job.MisfirePolicy == JobMisfireIgnore || lastAlive.IsZero() || time.Since(lastAlive) <= jobMisfireAliveGap {
+		return
+	}
+	schedule, err := cronParser.Parse(job.CronExpression)
+	if err != nil {
+		return
+	}
+	since := lastAlive
+	if job.UpdateTime.After(since) {
+		// 停止期间修改过的任务，从修改时间开始计算
+		since = job.UpdateTime
+	}
+	missed := missedFireTimes(schedule, since, time.Now(), jobMisfireLimit)
+	if len(missed) == 0 {
+		return
+	}
+
+	// 多个实例同时重启时，只由一个实例补偿
+	lock, err := s.misfireLocker.Lock(ctx, fmt.Sprintf("misfire-%d-%d", job.ID, missed[len(missed)-1].Unix()))
+	if err != nil {
+		return
+	}
+	defer func() { _ = lock.Unlock(ctx) }()
+
+	s.log.Info("Job misfired", zap.Int64("jobId", job.ID), zap.Int("missed", len(missed)), zap.Time("since", since))
+	if job.MisfirePolicy == JobMisfireFireOnce {
+		missed = missed[:1]
+	}
+	s.mu.RLock()
+	handler := s.handlers[job.HandlerName]
+	s.mu.RUnlock()
+	for range missed {
+		exec, err := s.startExecution(job, handler, JobTriggerMisfire)
+		if err != nil {
+			s.log.Warn("Job misfire execution skipped", zap.Int64("jobId", job.ID), zap.Error(err))
+			return
+		}
+		// 逐次补偿，上一次执行结束后再执行下一次
+		s.runExecution(exec)
+	}
+}
+
+// lastAliveTime 获取调度器最近的存活时间，没有记录时返回零值
+func (s *Scheduler) lastAliveTime(ctx context.Context) (time.Time, error) {
+	if s.rdb == nil {
+		return time.Time{}, nil
+	}
+	millis, err := s.rdb.Get(ctx, RedisKeyJobSchedulerAlive).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// keepAlive 记录调度器的存活时间
+func (s *Scheduler) keepAlive(ctx context.Context) {
+	if s.rdb == nil {
+		return
+	}
+	if err := s.rdb.Set(ctx, RedisKeyJobSchedulerAlive, time.Now().UnixMilli(), 0).Err(); err != nil {
+		s.log.Warn("Failed to save scheduler alive time", zap.Error(err))
+	}
+}
+
+// missedFireTimes 返回 (since, now] 之间的触发时间，最多返回 limit 个
+func missedFireTimes(schedule cron.Schedule, since, now time.Time, limit int) []time.Time {
+	var times []time.Time
+	for next := schedule.Next(since); !next.IsZero() && !next.After(now) && len(times) < limit; next = schedule.Next(next) {
+		times = append(times, next)
+	}
+	return times
+}
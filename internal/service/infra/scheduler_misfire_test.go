@@ -0,0 +1,109 @@
+package infra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/model"
+)
+
+func TestMissedFireTimes(t *testing.T) {
+	// 每 10 分钟触发一次
+	schedule, err := cronParser.Parse("0 */10 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2024, 1, 1, 10, 0, 3, 0, time.Local)
+
+	// 错过 10:10、10:20、10:30，恰好在触发时间点的也算错过
+	missed := missedFireTimes(schedule, since, time.Date(2024, 1, 1, 10, 30, 0, 0, time.Local), 100)
+	if len(missed) != 3 || !missed[0].Equal(time.Date(2024, 1, 1, 10, 10, 0, 0, time.Local)) {
+		t.Fatalf("unexpected missed fire times: %v", missed)
+	}
+
+	// 超过上限时只返回最早的 limit 个
+	if missed := missedFireTimes(schedule, since, since.Add(24*time.Hour), 5); len(missed) != 5 {
+		t.Fatalf("expected 5 missed fire times, got %d", len(missed))
+	}
+
+	// 下一次触发尚未到达
+	if missed := missedFireTimes(schedule, since, since.Add(5*time.Minute), 100); len(missed) != 0 {
+		t.Fatalf("expected no missed fire times, got %v", missed)
+	}
+}
+
+func TestHandleMisfire(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	q := newTestQuery(t)
+	s := newTestScheduler(t, q, rdb)
+	s.RegisterHandler("testJob", testJobHandler{execute: func(ctx context.Context, param string) error { return nil }})
+
+	ctx := context.Background()
+	lastAlive := time.Now().Add(-35 * time.Minute)
+	schedule, err := cronParser.Parse("0 */10 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	missed := len(missedFireTimes(schedule, lastAlive, time.Now(), jobMisfireLimit))
+	newJob := func(id int64, policy int) *model.InfraJob {
+		return &model.InfraJob{ID: id, Name: "测试任务", HandlerName: "testJob", CronExpression: "0 */10 * * * *", MisfirePolicy: policy}
+	}
+
+	// 忽略错过的触发
+	s.handleMisfire(ctx, newJob(1, JobMisfireIgnore), lastAlive)
+	if logs := findJobLogs(t, q, 1); len(logs) != 0 {
+		t.Fatalf("expected no misfire execution, got %d", len(logs))
+	}
+
+	// 补偿执行一次
+	s.handleMisfire(ctx, newJob(2, JobMisfireFireOnce), lastAlive)
+	if logs := findJobLogs(t, q, 2); len(logs) != 1 {
+		t.Fatalf("expected 1 misfire execution, got %d", len(logs))
+	}
+
+	// 逐次补偿全部错过的触发，其他实例对同一批错过的触发不再补偿
+	job := newJob(3, JobMisfireFireAll)
+	s.handleMisfire(ctx, job, lastAlive)
+	s.handleMisfire(ctx, job, lastAlive)
+	if logs := findJobLogs(t, q, 3); len(logs) != missed {
+		t.Fatalf("expected %d misfire executions, got %d", missed, len(logs))
+	}
+
+	// 停止期间修改过的任务从修改时间开始计算
+	job = newJob(4, JobMisfireFireAll)
+	job.UpdateTime = time.Now()
+	s.handleMisfire(ctx, job, lastAlive)
+	if logs := findJobLogs(t, q, 4); len(logs) != 0 {
+		t.Fatalf("expected no misfire execution after update, got %d", len(logs))
+	}
+
+	// 没有存活记录时不补偿
+	s.handleMisfire(ctx, newJob(5, JobMisfireFireAll), time.Time{})
+	if logs := findJobLogs(t, q, 5); len(logs) != 0 {
+		t.Fatalf("expected no misfire execution without alive time, got %d", len(logs))
+	}
+
+	// 其他实例仍在运行（存活时间未超过间隔）时，期间的触发已由其他实例执行，不补偿
+	job = newJob(6, JobMisfireFireAll)
+	job.CronExpression = "* * * * * *"
+	s.handleMisfire(ctx, job, time.Now().Add(-jobMisfireAliveGap/2))
+	if logs := findJobLogs(t, q, 6); len(logs) != 0 {
+		t.Fatalf("expected no misfire execution while other instances are alive, got %d", len(logs))
+	}
+}
+
+func TestSchedulerAliveTime(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	s := newTestScheduler(t, newTestQuery(t), rdb)
+	ctx := context.Background()
+
+	if lastAlive, err := s.lastAliveTime(ctx); err != nil || !lastAlive.IsZero() {
+		t.Fatalf("expected no alive time, got %v, %v", lastAlive, err)
+	}
+	s.keepAlive(ctx)
+	lastAlive, err := s.lastAliveTime(ctx)
+	if err != nil || time.Since(lastAlive) > time.Second {
+		t.Fatalf("unexpected alive time: %v, %v", lastAlive, err)
+	}
+}
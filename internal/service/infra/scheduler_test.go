@@ -0,0 +1,341 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-co-op/gocron/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestQuery 创建基于内存 SQLite 的查询对象，除定时任务及其日志表外另建 models 的表
+func newTestQuery(t *testing.T, models ...any) *query.Query {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接相互独立，只使用一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(append([]any{&model.InfraJob{}, &model.InfraJobLog{}}, models...)...); err != nil {
+		t.Fatal(err)
+	}
+	return query.Use(db)
+}
+
+// newTestScheduler 创建未启动的调度器，不从数据库加载任务
+func newTestScheduler(t *testing.T, q *query.Query, rdb *redis.Client, hooks ...JobFailureHook) *Scheduler {
+	t.Helper()
+	gs, err := gocron.NewScheduler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Scheduler{
+		scheduler:     gs,
+		q:             q,
+		rdb:           rdb,
+		log:           zap.NewNop(),
+		instanceID:    "test-1",
+		runningLocker: newRedisJobLocker(rdb, zap.NewNop(), 0),
+		misfireLocker: newRedisJobLocker(rdb, zap.NewNop(), jobMisfireLockHold),
+		handlers:      make(map[string]JobHandler),
+		failureHooks:  hooks,
+		jobMap:        make(map[int64]gocron.Job),
+		executions:    make(map[string]*jobExecution),
+	}
+	s.stopCtx, s.stop = context.WithCancel(context.Background())
+	t.Cleanup(s.stop)
+	return s
+}
+
+type testJobHandler struct {
+	execute func(ctx context.Context, param string) error
+}
+
+func (h testJobHandler) Execute(ctx context.Context, param string) error {
+	return h.execute(ctx, param)
+}
+
+func (testJobHandler) GetHandlerName() string {
+	return "testJob"
+}
+
+type testFailureHook struct {
+	mu    sync.Mutex
+	calls []*model.InfraJobLog
+	errs  []error
+}
+
+func (h *testFailureHook) OnJobFailed(_ context.Context, _ *model.InfraJob, jobLog *model.InfraJobLog, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, jobLog)
+	h.errs = append(h.errs, err)
+}
+
+// runTestJob 同步执行一次任务
+func runTestJob(t *testing.T, s *Scheduler, job *model.InfraJob, handler JobHandler) {
+	t.Helper()
+	exec, err := s.startExecution(job, handler, JobTriggerManual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.runExecution(exec)
+}
+
+func findJobLogs(t *testing.T, q *query.Query, jobID int64) []*model.InfraJobLog {
+	t.Helper()
+	l := q.InfraJobLog
+	logs, err := l.WithContext(context.Background()).Where(l.JobID.Eq(jobID)).Order(l.ID).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logs
+}
+
+func TestExecuteJobRetry(t *testing.T) {
+	q := newTestQuery(t)
+	hook := &testFailureHook{}
+	s := newTestScheduler(t, q, nil, hook)
+
+	attempts := 0
+	handler := testJobHandler{execute: func(ctx context.Context, param string) error {
+		attempts++
+		return errors.New("boom")
+	}}
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", RetryCount: 2, RetryInterval: 1}
+	runTestJob(t, s, job, handler)
+
+	// 首次执行加重试次数，每次执行记录一条日志，执行序号递增
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+	logs := findJobLogs(t, q, job.ID)
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 job logs, got %d", len(logs))
+	}
+	for i, log := range logs {
+		if log.ExecuteIndex != i+1 || log.Status != JobLogStatusFailure || log.Result != "boom" || log.InstanceID != "test-1" {
+			t.Fatalf("unexpected job log %d: %+v", i, log)
+		}
+	}
+
+	// 重试次数用尽后只回调一次，日志为最后一次执行
+	if len(hook.calls) != 1 || hook.calls[0].ExecuteIndex != 3 || hook.errs[0].Error() != "boom" {
+		t.Fatalf("unexpected failure hook calls: %+v", hook.calls)
+	}
+}
+
+func TestExecuteJobRetrySucceeds(t *testing.T) {
+	q := newTestQuery(t)
+	hook := &testFailureHook{}
+	s := newTestScheduler(t, q, nil, hook)
+
+	attempts := 0
+	handler := testJobHandler{execute: func(ctx context.Context, param string) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	}}
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", RetryCount: 3}
+	runTestJob(t, s, job, handler)
+
+	logs := findJobLogs(t, q, job.ID)
+	if len(logs) != 2 || logs[0].Status != JobLogStatusFailure || logs[1].Status != JobLogStatusSuccess || logs[1].ExecuteIndex != 2 {
+		t.Fatalf("unexpected job logs: %+v", logs)
+	}
+	if len(hook.calls) != 0 {
+		t.Fatalf("expected no failure hook call, got %d", len(hook.calls))
+	}
+}
+
+func TestExecuteJobTimeout(t *testing.T) {
+	q := newTestQuery(t)
+	hook := &testFailureHook{}
+	s := newTestScheduler(t, q, nil, hook)
+
+	timeout := 20
+	handler := testJobHandler{execute: func(ctx context.Context, param string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", MonitorTimeout: &timeout}
+	begin := time.Now()
+	runTestJob(t, s, job, handler)
+
+	// 超时后取消处理器的 Context，按执行失败处理并回调
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Fatalf("expected handler to be cancelled on timeout, took %v", elapsed)
+	}
+	logs := findJobLogs(t, q, job.ID)
+	if len(logs) != 1 || logs[0].Status != JobLogStatusFailure || !strings.Contains(logs[0].Result, "任务执行超时") {
+		t.Fatalf("unexpected job logs: %+v", logs)
+	}
+	if len(hook.calls) != 1 || !errors.Is(hook.errs[0], context.DeadlineExceeded) {
+		t.Fatalf("unexpected failure hook calls: %+v", hook.errs)
+	}
+}
+
+func TestExecuteJobCancelled(t *testing.T) {
+	q := newTestQuery(t)
+	hook := &testFailureHook{}
+	s := newTestScheduler(t, q, nil, hook)
+
+	started := make(chan string, 1)
+	handler := testJobHandler{execute: func(ctx context.Context, param string) error {
+		started <- ""
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", RetryCount: 3}
+	exec, err := s.startExecution(job, handler, JobTriggerManual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		s.runExecution(exec)
+		close(done)
+	}()
+	<-started
+	if err := s.CancelExecution(context.Background(), exec.ID); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	// 取消后不再重试，也不回调
+	logs := findJobLogs(t, q, job.ID)
+	if len(logs) != 1 || !strings.Contains(logs[0].Result, "任务已取消") {
+		t.Fatalf("unexpected job logs: %+v", logs)
+	}
+	if len(hook.calls) != 0 {
+		t.Fatalf("expected no failure hook call, got %d", len(hook.calls))
+	}
+	if err := s.CancelExecution(context.Background(), exec.ID); !errors.Is(err, ErrJobExecutionNotFound) {
+		t.Fatalf("expected ErrJobExecutionNotFound, got %v", err)
+	}
+}
+
+func TestExecuteJobLogCreateFailed(t *testing.T) {
+	q := newTestQuery(t)
+	hook := &testFailureHook{}
+	s := newTestScheduler(t, q, nil, hook)
+	db := q.InfraJobLog.WithContext(context.Background()).UnderlyingDB()
+	if err := db.Migrator().DropTable(&model.InfraJobLog{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 无法记录日志时不执行处理器，按执行失败处理
+	executed := false
+	handler := testJobHandler{execute: func(ctx context.Context, param string) error {
+		executed = true
+		return nil
+	}}
+	runTestJob(t, s, &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob"}, handler)
+	if executed {
+		t.Fatal("expected handler not to be executed")
+	}
+	if len(hook.calls) != 1 || !strings.Contains(hook.errs[0].Error(), "记录任务日志失败") {
+		t.Fatalf("unexpected failure hook calls: %+v", hook.errs)
+	}
+}
+
+func TestSchedulerShutdown(t *testing.T) {
+	q := newTestQuery(t)
+	s := newTestScheduler(t, q, nil)
+
+	h := newBlockingJobHandler()
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob", ConcurrencyPolicy: JobConcurrencyAllow}
+	if err := s.dispatch(job, h, JobTriggerManual); err != nil {
+		t.Fatal(err)
+	}
+	waitStarted(t, h)
+
+	// 执行中的任务未结束时等待到期，返回超时错误
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	// 停止后不再接受新的执行
+	if err := s.dispatch(job, h, JobTriggerManual); !errors.Is(err, ErrSchedulerClosed) {
+		t.Fatalf("expected ErrSchedulerClosed, got %v", err)
+	}
+	// 到期后取消执行，处理器响应取消时记录为已取消
+	logs := findJobLogs(t, q, job.ID)
+	if len(logs) != 1 || logs[0].Status != JobLogStatusFailure || !strings.Contains(logs[0].Result, "任务已取消") {
+		t.Fatalf("unexpected job logs: %+v", logs)
+	}
+}
+
+func TestSchedulerShutdownMarksInterrupted(t *testing.T) {
+	q := newTestQuery(t)
+	s := newTestScheduler(t, q, nil)
+
+	// 处理器不响应取消
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := testJobHandler{execute: func(ctx context.Context, param string) error {
+		close(started)
+		<-release
+		return nil
+	}}
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob"}
+	if err := s.dispatch(job, handler, JobTriggerManual); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	// 仍未结束的执行日志标记为执行中断
+	logs := findJobLogs(t, q, job.ID)
+	if len(logs) != 1 || logs[0].Status != JobLogStatusFailure || logs[0].Result != "服务停止，任务执行中断" || logs[0].EndTime == nil {
+		t.Fatalf("unexpected job logs: %+v", logs)
+	}
+	close(release)
+	s.execWg.Wait()
+}
+
+func TestSchedulerShutdownWaitsForJobs(t *testing.T) {
+	q := newTestQuery(t)
+	s := newTestScheduler(t, q, nil)
+
+	h := newBlockingJobHandler()
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob"}
+	if err := s.dispatch(job, h, JobTriggerManual); err != nil {
+		t.Fatal(err)
+	}
+	waitStarted(t, h)
+	time.AfterFunc(20*time.Millisecond, func() { close(h.release) })
+
+	// 执行中的任务在到期前结束
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	logs := findJobLogs(t, q, job.ID)
+	if len(logs) != 1 || logs[0].Status != JobLogStatusSuccess {
+		t.Fatalf("unexpected job logs: %+v", logs)
+	}
+}
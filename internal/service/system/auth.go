@@ -6,10 +6,13 @@ import (
 
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
 	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	pkgTenant "github.com/wxlbd/admin-go/internal/pkg/tenant"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 	pkgContext "github.com/wxlbd/admin-go/pkg/context"
 	"github.com/wxlbd/admin-go/pkg/errors"
 	"github.com/wxlbd/admin-go/pkg/utils"
+	"go.uber.org/zap"
 )
 
 type AuthService struct {
@@ -22,6 +25,11 @@ type AuthService struct {
 	loginLogSvc   *LoginLogService
 	userSvc       *UserService
 	socialUserSvc *SocialUserService
+	captchaSvc    *CaptchaService
+	loginLimitSvc *LoginLimitService
+	twoFactorSvc  *TwoFactorService
+	passwordSvc   *PasswordPolicyService
+	tenantSvc     *TenantService
 }
 
 func NewAuthService(
@@ -34,6 +42,11 @@ func NewAuthService(
 	loginLogSvc *LoginLogService,
 	userSvc *UserService,
 	socialUserSvc *SocialUserService,
+	captchaSvc *CaptchaService,
+	loginLimitSvc *LoginLimitService,
+	twoFactorSvc *TwoFactorService,
+	passwordSvc *PasswordPolicyService,
+	tenantSvc *TenantService,
 ) *AuthService {
 	return &AuthService{
 		repo:          repo,
@@ -45,6 +58,11 @@ func NewAuthService(
 		loginLogSvc:   loginLogSvc,
 		userSvc:       userSvc,
 		socialUserSvc: socialUserSvc,
+		captchaSvc:    captchaSvc,
+		loginLimitSvc: loginLimitSvc,
+		twoFactorSvc:  twoFactorSvc,
+		passwordSvc:   passwordSvc,
+		tenantSvc:     tenantSvc,
 	}
 }
 
@@ -55,11 +73,13 @@ func NewAuthService(
 
 // SocialAuthRedirect 社交授权跳转
 func (s *AuthService) SocialAuthRedirect(ctx context.Context, socialType int, redirectUri string) (string, error) {
-	return s.socialUserSvc.GetAuthorizeUrl(ctx, socialType, consts.UserTypeAdmin, redirectUri)
+	return s.socialUserSvc.GetAuthorizeUrl(withRequestTenant(ctx), socialType, consts.UserTypeAdmin, redirectUri)
 }
 
 // SocialLogin 社交登录
 func (s *AuthService) SocialLogin(ctx context.Context, req *system.AuthSocialLoginReq) (*system.AuthLoginResp, error) {
+	ctx = withRequestTenant(ctx)
+
 	// 1. 获取社交用户及绑定用户ID
 	_, userId, err := s.socialUserSvc.GetSocialUserByCode(ctx, consts.UserTypeAdmin, req.Type, req.Code, req.State)
 	if err != nil {
@@ -83,18 +103,23 @@ func (s *AuthService) SocialLogin(ctx context.Context, req *system.AuthSocialLog
 		return nil, errors.NewBizError(1002000001, "用户已被禁用")
 	}
 
-	// 5. 构建用户信息
+	// 5. 开启二次验证时签发预认证票据
+	if challenge, err := s.twoFactorSvc.Challenge(ctx, user, user.TenantID, consts.LoginLogTypeSocial); err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	// 6. 构建用户信息
 	userInfo := map[string]string{
 		"nickname": user.Nickname,
 	}
 
-	// 6. 创建访问令牌
+	// 7. 创建访问令牌
 	tokenDO, err := s.tokenSvc.CreateAccessToken(ctx, user.ID, consts.UserTypeAdmin, user.TenantID, userInfo)
 	if err != nil {
 		return nil, errors.ErrUnknown
 	}
 
-	// 7. 记录登录日志
+	// 8. 记录登录日志
 	s.loginLogSvc.CreateLoginLog(ctx, user.ID, consts.UserTypeAdmin, user.Username, user.LoginIP, "", consts.LoginLogTypeSocial, consts.LoginResultSuccess)
 
 	return &system.AuthLoginResp{
@@ -102,6 +127,8 @@ func (s *AuthService) SocialLogin(ctx context.Context, req *system.AuthSocialLog
 		AccessToken:  tokenDO.AccessToken,
 		RefreshToken: tokenDO.RefreshToken,
 		ExpiresTime:  tokenDO.ExpiresTime,
+
+		PasswordChangeRequired: s.passwordSvc.IsPasswordChangeRequired(ctx, user),
 	}, nil
 }
 
@@ -177,7 +204,7 @@ func (s *AuthService) GetPermissionInfo(ctx context.Context) (*system.AuthPermis
 	// 7. 构建菜单树
 	menuTree := s.menuSvc.BuildMenuTree(enabledMenus)
 
-	return &system.AuthPermissionInfoResp{
+	resp := &system.AuthPermissionInfoResp{
 		User: system.UserVO{
 			ID:       user.ID,
 			Nickname: user.Nickname,
@@ -189,45 +216,123 @@ func (s *AuthService) GetPermissionInfo(ctx context.Context) (*system.AuthPermis
 		Roles:       roles,
 		Permissions: permissions,
 		Menus:       menuTree,
+	}
+
+	// 8. 平台管理员访问租户时，返回代登录的操作人
+	if loginUser, ok := ctx.Value(pkgContext.CtxLoginUserKey).(*pkgContext.LoginUser); ok && loginUser.ImpersonatorID > 0 {
+		resp.Impersonated = true
+		// 操作人属于平台租户，跨租户查询
+		if operator, err := uRepo.WithContext(pkgTenant.SkipTenant(ctx)).Where(uRepo.ID.Eq(loginUser.ImpersonatorID)).First(); err == nil {
+			resp.Impersonator = &system.UserVO{
+				ID:       operator.ID,
+				Nickname: operator.Nickname,
+				Avatar:   operator.Avatar,
+				DeptID:   operator.DeptID,
+				Username: operator.Username,
+				Email:    operator.Email,
+			}
+		}
+	}
+	return resp, nil
+}
+
+// VisitTenant 平台管理员访问租户：以租户联系人（租户管理员）的身份签发代登录令牌
+// 令牌记录操作人编号，此后的操作日志、访问日志均带有操作人；代登录令牌不能再次访问其它租户
+func (s *AuthService) VisitTenant(ctx context.Context, operator *pkgContext.LoginUser, tenantId int64) (*system.AuthLoginResp, error) {
+	// 1. 校验操作人属于平台租户
+	if operator == nil {
+		return nil, errors.NewBizError(401, "未登录")
+	}
+	if operator.ImpersonatorID > 0 {
+		return nil, errors.NewBizError(1002015010, "当前正在访问租户，请先退出后再切换")
+	}
+	t := s.repo.SystemTenant
+	operatorTenant, err := t.WithContext(ctx).Where(t.ID.Eq(operator.TenantID)).First()
+	if err != nil || !s.tenantSvc.isSystemTenant(operatorTenant) {
+		return nil, errors.NewBizError(1002015011, "仅平台租户的管理员可以访问其它租户")
+	}
+	if tenantId == operator.TenantID {
+		return nil, errors.NewBizError(1002015012, "不能访问当前所在的租户")
+	}
+
+	// 2. 校验目标租户状态与有效期
+	if err := s.tenantSvc.ValidTenant(ctx, tenantId); err != nil {
+		return nil, errors.NewBizError(1002000004, err.Error())
+	}
+	tenant, err := t.WithContext(ctx).Where(t.ID.Eq(tenantId)).First()
+	if err != nil {
+		return nil, errors.NewBizError(1002015000, "租户不存在")
+	}
+
+	// 3. 获取租户联系人账号
+	ctx = pkgContext.WithTenantID(ctx, tenantId)
+	u := s.repo.SystemUser
+	user, err := u.WithContext(ctx).Where(u.ID.Eq(tenant.ContactUserID)).First()
+	if err != nil {
+		return nil, errors.NewBizError(1002015013, "租户未设置管理员账号，无法访问")
+	}
+	if user.Status != consts.CommonStatusEnable {
+		return nil, errors.NewBizError(1002000001, "用户已被禁用")
+	}
+
+	// 4. 签发代登录令牌
+	userInfo := map[string]string{
+		"nickname": user.Nickname,
+	}
+	tokenDO, err := s.tokenSvc.CreateImpersonateAccessToken(ctx, user.ID, consts.UserTypeAdmin, tenantId, userInfo, operator.UserID)
+	if err != nil {
+		return nil, errors.ErrUnknown
+	}
+	zap.L().Info("Tenant visited by platform operator",
+		zap.Int64("operatorId", operator.UserID), zap.Int64("tenantId", tenantId), zap.Int64("userId", user.ID))
+
+	return &system.AuthLoginResp{
+		UserId:       user.ID,
+		AccessToken:  tokenDO.AccessToken,
+		RefreshToken: tokenDO.RefreshToken,
+		ExpiresTime:  tokenDO.ExpiresTime,
 	}, nil
 }
 
 // Login 登录业务
 func (s *AuthService) Login(ctx context.Context, req *system.AuthLoginReq) (*system.AuthLoginResp, error) {
-	// 0. 解析租户
-	var tenantId int64 = 1 // 默认租户ID
+	// 校验验证码，先于账号校验，避免撞库
+	if err := s.validateCaptcha(ctx, req.Username, req.CaptchaVerification); err != nil {
+		return nil, err
+	}
+	return s.login(ctx, req)
+}
+
+// login 账号密码登录，不校验验证码，由调用方在此之前完成校验
+func (s *AuthService) login(ctx context.Context, req *system.AuthLoginReq) (*system.AuthLoginResp, error) {
+	// 0. 解析租户：优先按租户名，其次为租户中间件解析的请求租户，都没有时使用默认租户
+	tenantId := requestTenantID(ctx)
 	if req.TenantName != "" {
 		tenantRepo := s.repo.SystemTenant
 		tenant, err := tenantRepo.WithContext(ctx).Where(tenantRepo.Name.Eq(req.TenantName)).First()
 		if err != nil {
 			return nil, errors.NewBizError(1002000003, "租户不存在")
 		}
-		if tenant.Status != 0 {
-			return nil, errors.NewBizError(1002000004, "租户已被禁用")
-		}
 		tenantId = tenant.ID
 	}
-
-	// 1. 查询用户
-	userRepo := s.repo.SystemUser
-	user, err := userRepo.WithContext(ctx).Where(userRepo.Username.Eq(req.Username), userRepo.TenantID.Eq(tenantId)).First()
-	if err != nil {
-		// 区分是否是 RecordNotFound (已在 InitDB 中忽略日志，这里 err 可能为 gorm.ErrRecordNotFound)
-		// 但为了安全，通常模糊返回
-		return nil, errors.NewBizError(1002000002, "账号或密码不正确")
+	if err := s.tenantSvc.ValidTenant(ctx, tenantId); err != nil {
+		return nil, errors.NewBizError(1002000004, err.Error())
 	}
+	ctx = pkgContext.WithTenantID(ctx, tenantId)
 
-	// 2. 校验状态
-	if user.Status != 0 { // 假设 0 是开启
-		return nil, errors.NewBizError(1002000001, "用户已被禁用")
+	// 1. 校验账号密码与状态
+	user, err := s.Authenticate(ctx, tenantId, req.Username, req.Password)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3. 校验密码
-	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		return nil, errors.NewBizError(1002000002, "账号或密码不正确")
+	// 2. 开启二次验证时签发预认证票据，令牌在验证通过后创建，失败次数在二次验证通过后清除
+	if challenge, err := s.twoFactorSvc.Challenge(ctx, user, tenantId, consts.LoginLogTypeUsername); err != nil || challenge != nil {
+		return challenge, err
 	}
+	s.loginLimitSvc.ClearFailure(ctx, tenantId, req.Username)
 
-	// 4. 构建用户信息
+	// 3. 构建用户信息
 	userInfo := map[string]string{
 		"nickname": user.Nickname,
 	}
@@ -235,21 +340,149 @@ func (s *AuthService) Login(ctx context.Context, req *system.AuthLoginReq) (*sys
 		userInfo["deptId"] = string(rune(user.DeptID))
 	}
 
-	// 5. 创建访问令牌（使用 OAuth2TokenService，与 Java 对齐）
+	// 4. 创建访问令牌（使用 OAuth2TokenService，与 Java 对齐）
 	tokenDO, err := s.tokenSvc.CreateAccessToken(ctx, user.ID, consts.UserTypeAdmin, tenantId, userInfo)
 	if err != nil {
 		return nil, errors.ErrUnknown
 	}
+	s.createLoginLog(ctx, user.ID, req.Username, consts.LoginLogTypeUsername, consts.LoginResultSuccess)
 
-	// 6. 返回结果
+	// 5. 返回结果
 	return &system.AuthLoginResp{
 		UserId:       user.ID,
 		AccessToken:  tokenDO.AccessToken,
 		RefreshToken: tokenDO.RefreshToken,
 		ExpiresTime:  tokenDO.ExpiresTime,
+
+		PasswordChangeRequired: s.passwordSvc.IsPasswordChangeRequired(ctx, user),
 	}, nil
 }
 
+// Authenticate 校验账号密码：登录锁定、密码、用户状态，失败时记录登录日志与失败次数
+// 校验通过后不清除失败次数，由调用方在整个登录流程（含二次验证）完成后清除
+func (s *AuthService) Authenticate(ctx context.Context, tenantId int64, username, password string) (*model.SystemUser, error) {
+	// 1. 校验账号与 IP 是否因失败次数过多被锁定
+	ip := clientIP(ctx)
+	if err := s.loginLimitSvc.CheckLocked(ctx, tenantId, username, ip); err != nil {
+		s.createLoginLog(ctx, 0, username, consts.LoginLogTypeUsername, consts.LoginResultLocked)
+		return nil, err
+	}
+
+	// 2. 查询用户
+	userRepo := s.repo.SystemUser
+	user, err := userRepo.WithContext(ctx).Where(userRepo.Username.Eq(username), userRepo.TenantID.Eq(tenantId)).First()
+	if err != nil {
+		// 区分是否是 RecordNotFound (已在 InitDB 中忽略日志，这里 err 可能为 gorm.ErrRecordNotFound)
+		// 但为了安全，通常模糊返回
+		return nil, s.loginFailed(ctx, tenantId, 0, username, ip)
+	}
+
+	// 3. 校验密码
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return nil, s.loginFailed(ctx, tenantId, user.ID, username, ip)
+	}
+
+	// 4. 校验状态
+	if user.Status != 0 { // 假设 0 是开启
+		s.createLoginLog(ctx, user.ID, username, consts.LoginLogTypeUsername, consts.LoginResultUserDisabled)
+		return nil, errors.NewBizError(1002000001, "用户已被禁用")
+	}
+	return user, nil
+}
+
+// validateCaptcha 校验登录验证码，未开启时跳过；校验失败记录登录日志
+func (s *AuthService) validateCaptcha(ctx context.Context, username, captchaVerification string) error {
+	if !s.captchaSvc.Enabled() {
+		return nil
+	}
+	err := s.captchaSvc.ValidateCaptcha(ctx, captchaVerification)
+	switch err {
+	case nil:
+		return nil
+	case ErrCaptchaNotFound:
+		s.createLoginLog(ctx, 0, username, consts.LoginLogTypeUsername, consts.LoginResultCaptchaNotFound)
+	case ErrCaptchaCodeError:
+		s.createLoginLog(ctx, 0, username, consts.LoginLogTypeUsername, consts.LoginResultCaptchaCodeError)
+	}
+	return err
+}
+
+// loginFailed 账号或密码不正确：记录登录日志与失败次数
+func (s *AuthService) loginFailed(ctx context.Context, tenantId, userId int64, username, ip string) error {
+	s.createLoginLog(ctx, userId, username, consts.LoginLogTypeUsername, consts.LoginResultBadCredentials)
+	s.loginLimitSvc.RecordFailure(ctx, tenantId, username, ip)
+	return errors.NewBizError(1002000002, "账号或密码不正确")
+}
+
+// createLoginLog 记录后台用户登录日志，IP 与 UA 取自当前请求
+func (s *AuthService) createLoginLog(ctx context.Context, userId int64, username string, logType int, result int) {
+	var userAgent string
+	if c := pkgContext.GetGinContext(ctx); c != nil {
+		userAgent = c.Request.UserAgent()
+	}
+	s.loginLogSvc.CreateLoginLog(ctx, userId, consts.UserTypeAdmin, username, clientIP(ctx), userAgent, logType, result)
+}
+
+// clientIP 获取当前请求的客户端 IP
+func clientIP(ctx context.Context) string {
+	if c := pkgContext.GetGinContext(ctx); c != nil {
+		return c.ClientIP()
+	}
+	return ""
+}
+
+// TwoFactorLogin 二次验证登录：校验预认证票据与 TOTP 验证码（或恢复码）后创建访问令牌
+func (s *AuthService) TwoFactorLogin(ctx context.Context, req *system.AuthTwoFactorLoginReq) (*system.AuthLoginResp, error) {
+	ticket, recoveryCodes, err := s.twoFactorSvc.CompleteLogin(ctx, req)
+	if err != nil {
+		if ticket != nil {
+			switch {
+			case err == ErrTwoFactorCodeError:
+				s.createLoginLog(ctx, ticket.UserID, ticket.Username, ticket.LogType, consts.LoginResultBadCredentials)
+			case isLoginLockedError(err):
+				s.createLoginLog(ctx, ticket.UserID, ticket.Username, ticket.LogType, consts.LoginResultLocked)
+			}
+		}
+		return nil, err
+	}
+
+	// 票据签发后用户可能被禁用，重新校验
+	ctx = pkgContext.WithTenantID(ctx, ticket.TenantID)
+	userRepo := s.repo.SystemUser
+	user, err := userRepo.WithContext(ctx).Where(userRepo.ID.Eq(ticket.UserID)).First()
+	if err != nil {
+		return nil, errors.NewBizError(1002000002, "用户不存在")
+	}
+	if user.Status != 0 {
+		s.createLoginLog(ctx, user.ID, user.Username, ticket.LogType, consts.LoginResultUserDisabled)
+		return nil, errors.NewBizError(1002000001, "用户已被禁用")
+	}
+
+	userInfo := map[string]string{
+		"nickname": user.Nickname,
+	}
+	tokenDO, err := s.tokenSvc.CreateAccessToken(ctx, user.ID, consts.UserTypeAdmin, ticket.TenantID, userInfo)
+	if err != nil {
+		return nil, errors.ErrUnknown
+	}
+	s.createLoginLog(ctx, user.ID, user.Username, ticket.LogType, consts.LoginResultSuccess)
+
+	return &system.AuthLoginResp{
+		UserId:        user.ID,
+		AccessToken:   tokenDO.AccessToken,
+		RefreshToken:  tokenDO.RefreshToken,
+		ExpiresTime:   tokenDO.ExpiresTime,
+		RecoveryCodes: recoveryCodes,
+
+		PasswordChangeRequired: s.passwordSvc.IsPasswordChangeRequired(ctx, user),
+	}, nil
+}
+
+// TwoFactorSetup 登录过程中绑定验证器，适用于租户策略强制开启但尚未绑定的用户
+func (s *AuthService) TwoFactorSetup(ctx context.Context, req *system.AuthTwoFactorSetupReq) (*system.TwoFactorSetupResp, error) {
+	return s.twoFactorSvc.SetupByTicket(ctx, req.Ticket)
+}
+
 // Logout 登出
 func (s *AuthService) Logout(ctx context.Context, token string) error {
 	// 1. 处理 token，移除 Bearer 前缀
@@ -266,20 +499,32 @@ func (s *AuthService) Logout(ctx context.Context, token string) error {
 		return err
 	}
 
-	// 3. 记录登出日志
+	// 3. 同时吊销刷新令牌，登出后不能再刷新
+	if tokenDO != nil {
+		if err := s.tokenSvc.RevokeTokenFamily(ctx, tokenDO.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	// 4. 记录登出日志
 	if tokenDO != nil {
 		s.loginLogSvc.CreateLogoutLog(ctx, tokenDO.UserID, tokenDO.UserType, "", "", "")
 	}
 	return nil
 }
 
-// RefreshToken 刷新令牌
+// RefreshToken 刷新令牌，旧的访问令牌与刷新令牌立即失效
 func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*system.AuthLoginResp, error) {
-	// 1. 验证 refreshToken（从 Redis 获取原令牌信息）
-	oldToken, err := s.tokenSvc.GetAccessToken(ctx, refreshToken)
-	if err != nil || oldToken == nil {
-		return nil, errors.NewBizError(1002000005, "刷新令牌无效或已过期")
+	// 1. 消费刷新令牌，只接受后台登录签发的令牌，重复使用时吊销整个令牌族并记录安全日志
+	oldToken, err := s.tokenSvc.ConsumeRefreshToken(ctx, refreshToken, consts.OAuth2ClientIDDefault)
+	if err == ErrRefreshTokenReused {
+		s.createTokenReusedLog(ctx, oldToken)
+		return nil, err
 	}
+	if err != nil {
+		return nil, err
+	}
+	ctx = pkgContext.WithTenantID(ctx, oldToken.TenantID)
 
 	// 2. 获取用户信息
 	userRepo := s.repo.SystemUser
@@ -298,8 +543,8 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*s
 		"nickname": user.Nickname,
 	}
 
-	// 5. 创建新的访问令牌
-	tokenDO, err := s.tokenSvc.CreateAccessToken(ctx, user.ID, oldToken.UserType, oldToken.TenantID, userInfo)
+	// 5. 在同一令牌族内创建新的访问令牌
+	tokenDO, err := s.tokenSvc.RefreshAccessToken(ctx, oldToken, userInfo, nil)
 	if err != nil {
 		return nil, errors.ErrUnknown
 	}
@@ -310,11 +555,35 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*s
 		AccessToken:  tokenDO.AccessToken,
 		RefreshToken: tokenDO.RefreshToken,
 		ExpiresTime:  tokenDO.ExpiresTime,
+
+		PasswordChangeRequired: s.passwordSvc.IsPasswordChangeRequired(ctx, user),
 	}, nil
 }
 
+// createTokenReusedLog 记录刷新令牌重复使用的安全日志
+func (s *AuthService) createTokenReusedLog(ctx context.Context, tokenDO *OAuth2AccessToken) {
+	if tokenDO == nil {
+		return
+	}
+	var username string
+	if tokenDO.UserType == consts.UserTypeAdmin && tokenDO.UserID > 0 {
+		userRepo := s.repo.SystemUser
+		if user, err := userRepo.WithContext(pkgContext.WithTenantID(ctx, tokenDO.TenantID)).Where(userRepo.ID.Eq(tokenDO.UserID)).First(); err == nil {
+			username = user.Username
+		}
+	}
+	var userAgent string
+	if c := pkgContext.GetGinContext(ctx); c != nil {
+		userAgent = c.Request.UserAgent()
+	}
+	s.loginLogSvc.CreateLoginLog(ctx, tokenDO.UserID, tokenDO.UserType, username, clientIP(ctx), userAgent,
+		consts.LogoutLogTypeTokenRevoked, consts.LoginResultTokenReused)
+}
+
 // SmsLogin 短信登录
 func (s *AuthService) SmsLogin(ctx context.Context, req *system.AuthSmsLoginReq) (*system.AuthLoginResp, error) {
+	ctx = withRequestTenant(ctx)
+
 	// 1. 验证短信验证码 (场景: 1-登录)
 	if err := s.smsCodeSvc.ValidateSmsCode(ctx, req.Mobile, 1, req.Code); err != nil {
 		return nil, err
@@ -332,18 +601,23 @@ func (s *AuthService) SmsLogin(ctx context.Context, req *system.AuthSmsLoginReq)
 		return nil, errors.NewBizError(1002000001, "用户已被禁用")
 	}
 
-	// 4. 构建用户信息
+	// 4. 开启二次验证时签发预认证票据
+	if challenge, err := s.twoFactorSvc.Challenge(ctx, user, user.TenantID, consts.LoginLogTypeSms); err != nil || challenge != nil {
+		return challenge, err
+	}
+
+	// 5. 构建用户信息
 	userInfo := map[string]string{
 		"nickname": user.Nickname,
 	}
 
-	// 5. 创建访问令牌
+	// 6. 创建访问令牌
 	tokenDO, err := s.tokenSvc.CreateAccessToken(ctx, user.ID, consts.UserTypeAdmin, user.TenantID, userInfo)
 	if err != nil {
 		return nil, errors.ErrUnknown
 	}
 
-	// 6. 记录登录日志
+	// 7. 记录登录日志
 	s.loginLogSvc.CreateLoginLog(ctx, user.ID, consts.UserTypeAdmin, user.Username, user.LoginIP, "", consts.LoginLogTypeSms, consts.LoginResultSuccess)
 
 	return &system.AuthLoginResp{
@@ -351,6 +625,8 @@ func (s *AuthService) SmsLogin(ctx context.Context, req *system.AuthSmsLoginReq)
 		AccessToken:  tokenDO.AccessToken,
 		RefreshToken: tokenDO.RefreshToken,
 		ExpiresTime:  tokenDO.ExpiresTime,
+
+		PasswordChangeRequired: s.passwordSvc.IsPasswordChangeRequired(ctx, user),
 	}, nil
 }
 
@@ -361,10 +637,14 @@ func (s *AuthService) SendSmsCode(ctx context.Context, req *system.AuthSmsSendRe
 
 // Register 注册
 func (s *AuthService) Register(ctx context.Context, r *system.AuthRegisterReq) (*system.AuthLoginResp, error) {
-	// 0. 参数校验
-	// TODO: 校验密码强度等 (Java: Validator)
+	ctx = withRequestTenant(ctx)
 
-	// 1. 创建用户
+	// 0. 校验验证码，须在创建用户之前，避免校验失败时账号已创建
+	if err := s.validateCaptcha(ctx, r.Username, r.CaptchaVerification); err != nil {
+		return nil, err
+	}
+
+	// 1. 创建用户（按密码策略校验密码）
 	createReq := &system.UserSaveReq{
 		Username: r.Username,
 		Password: r.Password,
@@ -379,17 +659,18 @@ func (s *AuthService) Register(ctx context.Context, r *system.AuthRegisterReq) (
 		return nil, err
 	}
 
-	// 2. 自动登录
-	// 构造登录请求 Mock
+	// 2. 自动登录，验证码已校验且一次性使用，不再重复校验
 	loginReq := &system.AuthLoginReq{
 		Username: r.Username,
 		Password: r.Password,
 	}
-	return s.Login(ctx, loginReq)
+	return s.login(ctx, loginReq)
 }
 
 // ResetPassword 重置密码
 func (s *AuthService) ResetPassword(ctx context.Context, req *system.AuthResetPasswordReq) error {
+	ctx = withRequestTenant(ctx)
+
 	// 1. 验证短信验证码 (场景: 3-重置密码)
 	if err := s.smsCodeSvc.ValidateSmsCode(ctx, req.Mobile, 3, req.Code); err != nil {
 		return err
@@ -402,14 +683,8 @@ func (s *AuthService) ResetPassword(ctx context.Context, req *system.AuthResetPa
 		return errors.NewBizError(1002000002, "用户不存在")
 	}
 
-	// 3. 更新密码
-	hashedPassword, err := utils.HashPassword(req.Password)
-	if err != nil {
-		return errors.ErrUnknown
-	}
-
-	_, err = userRepo.WithContext(ctx).Where(userRepo.ID.Eq(user.ID)).Update(userRepo.Password, hashedPassword)
-	return err
+	// 3. 按密码策略校验并更新密码
+	return s.passwordSvc.ChangePassword(ctx, user, req.Password)
 }
 
 // End of file
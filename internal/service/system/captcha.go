@@ -0,0 +1,219 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/pkg/captcha"
+	"github.com/wxlbd/admin-go/pkg/config"
+	bzErr "github.com/wxlbd/admin-go/pkg/errors"
+	"github.com/wxlbd/admin-go/pkg/utils"
+)
+
+// ========== 验证码配置常量 ==========
+
+const (
+	// CaptchaCacheKeyPrefix 验证码 Redis key 前缀，一次性使用
+	CaptchaCacheKeyPrefix = "captcha:running:"
+
+	// CaptchaSecondCacheKeyPrefix 二次校验串 Redis key 前缀，登录时一次性使用
+	CaptchaSecondCacheKeyPrefix = "captcha:second:"
+
+	// CaptchaSecondExpire 二次校验串有效期（对齐 AJ-Captcha）
+	CaptchaSecondExpire = 3 * time.Minute
+
+	defaultCaptchaExpire       = 2 * time.Minute
+	defaultCaptchaSliderOffset = 5
+)
+
+// AJ-Captcha 响应码
+const (
+	CaptchaRepCodeSuccess    = "0000"
+	CaptchaRepCodeParamEmpty = "0011"
+	CaptchaRepCodeExpired    = "6110"
+	CaptchaRepCodeCheckFail  = "6111"
+	CaptchaRepCodeGetFail    = "6112"
+)
+
+// ========== 错误码定义 ==========
+
+var (
+	// ErrCaptchaNotFound 登录时未携带验证码
+	ErrCaptchaNotFound = bzErr.NewBizError(400, "验证码不能为空")
+
+	// ErrCaptchaCodeError 验证码不正确或已失效
+	ErrCaptchaCodeError = bzErr.NewBizError(1_002_000_007, "验证码不正确，请重新验证")
+)
+
+// captchaCache 验证码缓存内容
+type captchaCache struct {
+	Type      string `json:"type"`
+	X         int    `json:"x,omitempty"`
+	SecretKey string `json:"secretKey,omitempty"`
+	Code      string `json:"code,omitempty"`
+}
+
+// captchaPoint 滑块坐标，拼图块与底图等高，前端固定提交 y = 5，只校验横坐标
+type captchaPoint struct {
+	X float64 `json:"x"`
+}
+
+// ========== 验证码服务 ==========
+
+type CaptchaService struct {
+	rdb *redis.Client
+}
+
+func NewCaptchaService(rdb *redis.Client) *CaptchaService {
+	return &CaptchaService{rdb: rdb}
+}
+
+// Enabled 是否开启登录验证码，按环境配置
+func (s *CaptchaService) Enabled() bool {
+	return config.C.Captcha.Enable
+}
+
+// GetCaptcha 获取验证码
+func (s *CaptchaService) GetCaptcha(ctx context.Context, req *system.CaptchaReq) (*system.CaptchaResp, error) {
+	captchaType := s.resolveType(req.CaptchaType)
+	if !captcha.IsValidType(captchaType) {
+		return captchaFail(CaptchaRepCodeGetFail, "验证码类型无效"), nil
+	}
+
+	token := strings.ReplaceAll(uuid.NewString(), "-", "")
+	data := &system.CaptchaRespData{CaptchaType: captchaType, Token: token}
+	cache := captchaCache{Type: captchaType}
+	switch captchaType {
+	case captcha.TypeBlockPuzzle:
+		puzzle, err := captcha.NewBlockPuzzle()
+		if err != nil {
+			return nil, err
+		}
+		cache.X = puzzle.X
+		cache.SecretKey = utils.GenerateRandomString(16)
+		data.OriginalImageBase64 = puzzle.OriginalImage
+		data.JigsawImageBase64 = puzzle.JigsawImage
+		data.SecretKey = cache.SecretKey
+	case captcha.TypeImageText:
+		text, err := captcha.NewImageText()
+		if err != nil {
+			return nil, err
+		}
+		cache.Code = text.Code
+		data.OriginalImageBase64 = text.Image
+	}
+
+	value, _ := json.Marshal(cache)
+	if err := s.rdb.Set(ctx, CaptchaCacheKeyPrefix+token, value, s.expire()).Err(); err != nil {
+		return nil, err
+	}
+	return &system.CaptchaResp{RepCode: CaptchaRepCodeSuccess, RepData: data, Success: true}, nil
+}
+
+// CheckCaptcha 校验验证码，验证码无论成功与否只能校验一次
+// 校验通过后生成二次校验串，登录时通过 captchaVerification 提交
+func (s *CaptchaService) CheckCaptcha(ctx context.Context, req *system.CaptchaReq) (*system.CaptchaResp, error) {
+	if req.Token == "" || req.PointJson == "" {
+		return captchaFail(CaptchaRepCodeParamEmpty, "参数不能为空"), nil
+	}
+
+	value, err := s.rdb.GetDel(ctx, CaptchaCacheKeyPrefix+req.Token).Result()
+	if err == redis.Nil {
+		return captchaFail(CaptchaRepCodeExpired, "验证码已失效，请重新获取"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cache captchaCache
+	if err := json.Unmarshal([]byte(value), &cache); err != nil {
+		return nil, err
+	}
+	if req.CaptchaType != "" && req.CaptchaType != cache.Type {
+		return captchaFail(CaptchaRepCodeCheckFail, "验证失败"), nil
+	}
+
+	var verification string
+	switch cache.Type {
+	case captcha.TypeBlockPuzzle:
+		pointJson, err := captcha.AesDecrypt(req.PointJson, cache.SecretKey)
+		if err != nil {
+			return captchaFail(CaptchaRepCodeCheckFail, "验证失败"), nil
+		}
+		// 与 AJ-Captcha 一致，只校验横坐标是否落在允许的误差范围内
+		var point captchaPoint
+		if err := json.Unmarshal([]byte(pointJson), &point); err != nil ||
+			math.Abs(point.X-float64(cache.X)) > float64(s.sliderOffset()) {
+			return captchaFail(CaptchaRepCodeCheckFail, "验证失败"), nil
+		}
+		if verification, err = captcha.AesEncrypt(req.Token+"---"+pointJson, cache.SecretKey); err != nil {
+			return nil, err
+		}
+	case captcha.TypeImageText:
+		if !strings.EqualFold(strings.TrimSpace(req.PointJson), cache.Code) {
+			return captchaFail(CaptchaRepCodeCheckFail, "验证失败"), nil
+		}
+		verification = req.Token + "---" + req.PointJson
+	default:
+		return captchaFail(CaptchaRepCodeCheckFail, "验证失败"), nil
+	}
+
+	if err := s.rdb.Set(ctx, CaptchaSecondCacheKeyPrefix+verification, req.Token, CaptchaSecondExpire).Err(); err != nil {
+		return nil, err
+	}
+	return &system.CaptchaResp{
+		RepCode: CaptchaRepCodeSuccess,
+		RepData: &system.CaptchaRespData{CaptchaType: cache.Type, Token: req.Token, Result: true},
+		Success: true,
+	}, nil
+}
+
+// ValidateCaptcha 登录时校验二次校验串，一次性使用
+func (s *CaptchaService) ValidateCaptcha(ctx context.Context, captchaVerification string) error {
+	if captchaVerification == "" {
+		return ErrCaptchaNotFound
+	}
+	deleted, err := s.rdb.Del(ctx, CaptchaSecondCacheKeyPrefix+captchaVerification).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrCaptchaCodeError
+	}
+	return nil
+}
+
+// resolveType 未指定类型时使用配置的默认类型
+func (s *CaptchaService) resolveType(captchaType string) string {
+	if captchaType != "" {
+		return captchaType
+	}
+	if config.C.Captcha.Type != "" {
+		return config.C.Captcha.Type
+	}
+	return captcha.TypeBlockPuzzle
+}
+
+func (s *CaptchaService) expire() time.Duration {
+	if seconds := config.C.Captcha.ExpireSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultCaptchaExpire
+}
+
+func (s *CaptchaService) sliderOffset() int {
+	if offset := config.C.Captcha.SliderOffset; offset > 0 {
+		return offset
+	}
+	return defaultCaptchaSliderOffset
+}
+
+// captchaFail 构建校验失败响应
+func captchaFail(repCode, repMsg string) *system.CaptchaResp {
+	return &system.CaptchaResp{RepCode: repCode, RepMsg: repMsg}
+}
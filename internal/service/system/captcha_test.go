@@ -0,0 +1,53 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/pkg/captcha"
+)
+
+func TestCheckCaptchaBlockPuzzle(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	s := NewCaptchaService(rdb)
+
+	tests := []struct {
+		name    string
+		dx      float64
+		success bool
+	}{
+		{"exact", 0, true},
+		{"within offset", -3, true},
+		{"wrong x", defaultCaptchaSliderOffset + 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.GetCaptcha(ctx, &system.CaptchaReq{CaptchaType: captcha.TypeBlockPuzzle})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var cache captchaCache
+			value, _ := rdb.Get(ctx, CaptchaCacheKeyPrefix+resp.RepData.Token).Result()
+			if err := json.Unmarshal([]byte(value), &cache); err != nil {
+				t.Fatal(err)
+			}
+
+			// AJ-Captcha 前端固定提交 y = 5
+			point, _ := json.Marshal(map[string]float64{"x": float64(cache.X) + tt.dx, "y": 5})
+			pointJson, err := captcha.AesEncrypt(string(point), resp.RepData.SecretKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := s.CheckCaptcha(ctx, &system.CaptchaReq{Token: resp.RepData.Token, PointJson: pointJson})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Success != tt.success {
+				t.Errorf("success = %v, want %v (repCode %s)", result.Success, tt.success, result.RepCode)
+			}
+		})
+	}
+}
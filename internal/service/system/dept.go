@@ -6,16 +6,19 @@ import (
 
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 )
 
 type DeptService struct {
-	q *query.Query
+	q          *query.Query
+	scopeCache *datascope.Cache
 }
 
-func NewDeptService(q *query.Query) *DeptService {
+func NewDeptService(q *query.Query, scopeCache *datascope.Cache) *DeptService {
 	return &DeptService{
-		q: q,
+		q:          q,
+		scopeCache: scopeCache,
 	}
 }
 
@@ -37,8 +40,12 @@ func (s *DeptService) CreateDept(ctx context.Context, req *system.DeptSaveReq) (
 		Email:        req.Email,
 		Status:       int32(*req.Status),
 	}
-	err := d.WithContext(ctx).Create(dept)
-	return dept.ID, err
+	if err := d.WithContext(ctx).Create(dept); err != nil {
+		return 0, err
+	}
+	// 部门树变更影响「本部门及以下」的数据范围
+	s.scopeCache.InvalidateAll(ctx)
+	return dept.ID, nil
 }
 
 func (s *DeptService) UpdateDept(ctx context.Context, req *system.DeptSaveReq) error {
@@ -67,7 +74,11 @@ func (s *DeptService) UpdateDept(ctx context.Context, req *system.DeptSaveReq) e
 		Email:        req.Email,
 		Status:       int32(*req.Status),
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	s.scopeCache.InvalidateAll(ctx)
+	return nil
 }
 
 func (s *DeptService) DeleteDept(ctx context.Context, id int64) error {
@@ -89,8 +100,11 @@ func (s *DeptService) DeleteDept(ctx context.Context, id int64) error {
 		return errors.New("部门下存在用户，无法删除")
 	}
 
-	_, err = d.WithContext(ctx).Where(d.ID.Eq(id)).Delete()
-	return err
+	if _, err = d.WithContext(ctx).Where(d.ID.Eq(id)).Delete(); err != nil {
+		return err
+	}
+	s.scopeCache.InvalidateAll(ctx)
+	return nil
 }
 
 func (s *DeptService) GetDept(ctx context.Context, id int64) (*system.DeptRespVO, error) {
@@ -0,0 +1,89 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	pkgTenant "github.com/wxlbd/admin-go/internal/pkg/tenant"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	"go.uber.org/zap"
+)
+
+// JobFailedTemplateCode 定时任务执行失败告警的站内信模板编码
+// 模板参数：jobName、handlerName、executeIndex、beginTime、result
+// 模板需手动创建（见 README 中的 SQL），未创建时只记录错误日志
+const JobFailedTemplateCode = "job_execute_failed"
+
+// JobFailureNotifier 定时任务重试次数用尽后仍执行失败时，向超级管理员发送站内信告警
+type JobFailureNotifier struct {
+	q         *query.Query
+	notifySvc *NotifyService
+}
+
+func NewJobFailureNotifier(q *query.Query, notifySvc *NotifyService) *JobFailureNotifier {
+	return &JobFailureNotifier{q: q, notifySvc: notifySvc}
+}
+
+// OnJobFailed 向启用中的超级管理员发送告警，模板不存在或发送失败时只记录日志
+func (n *JobFailureNotifier) OnJobFailed(ctx context.Context, job *model.InfraJob, jobLog *model.InfraJobLog, err error) {
+	if !n.notifySvc.hasTemplate(JobFailedTemplateCode) {
+		zap.L().Error("Job failed after retries, notify template not found",
+			zap.String("templateCode", JobFailedTemplateCode),
+			zap.Int64("jobId", job.ID),
+			zap.String("jobName", job.Name),
+			zap.Int("executeIndex", jobLog.ExecuteIndex),
+			zap.Error(err))
+		return
+	}
+	users, findErr := n.findSuperAdmins(ctx)
+	if findErr != nil {
+		zap.L().Error("Failed to find super admins for job failure notify", zap.Int64("jobId", job.ID), zap.Error(findErr))
+		return
+	}
+
+	params := map[string]interface{}{
+		"jobName":      job.Name,
+		"handlerName":  job.HandlerName,
+		"executeIndex": jobLog.ExecuteIndex,
+		"beginTime":    jobLog.BeginTime.Format(time.DateTime),
+		"result":       err.Error(),
+	}
+	var errs []error
+	for _, user := range users {
+		userCtx := pkgContext.WithTenantID(ctx, user.TenantID)
+		if _, sendErr := n.notifySvc.SendNotify(userCtx, user.ID, consts.UserTypeAdmin, JobFailedTemplateCode, params); sendErr != nil {
+			errs = append(errs, sendErr)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		zap.L().Error("Failed to send job failure notify", zap.Int64("jobId", job.ID), zap.Error(err))
+	}
+}
+
+// findSuperAdmins 查询拥有超级管理员角色且启用中的用户
+func (n *JobFailureNotifier) findSuperAdmins(ctx context.Context) ([]*model.SystemUser, error) {
+	// 定时任务没有租户上下文，跨租户查询
+	ctx = pkgTenant.SkipTenant(ctx)
+	r, ur, u := n.q.SystemRole, n.q.SystemUserRole, n.q.SystemUser
+	roles, err := r.WithContext(ctx).Where(r.Code.Eq(consts.RoleCodeSuperAdmin), r.Status.Eq(consts.CommonStatusEnable)).Find()
+	if err != nil || len(roles) == 0 {
+		return nil, err
+	}
+	roleIds := make([]int64, len(roles))
+	for i, role := range roles {
+		roleIds[i] = role.ID
+	}
+	userRoles, err := ur.WithContext(ctx).Where(ur.RoleID.In(roleIds...)).Find()
+	if err != nil || len(userRoles) == 0 {
+		return nil, err
+	}
+	userIds := make([]int64, len(userRoles))
+	for i, userRole := range userRoles {
+		userIds[i] = userRole.UserID
+	}
+	return u.WithContext(ctx).Where(u.ID.In(userIds...), u.Status.Eq(consts.CommonStatusEnable)).Find()
+}
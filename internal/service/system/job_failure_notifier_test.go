@@ -0,0 +1,62 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+)
+
+func TestJobFailureNotifier(t *testing.T) {
+	q := newTestQuery(t, &model.SystemNotifyTemplate{}, &model.SystemNotifyMessage{},
+		&model.SystemRole{}, &model.SystemUserRole{}, &model.SystemUser{})
+	ctx := context.Background()
+	if err := q.SystemRole.WithContext(ctx).Create(&model.SystemRole{ID: 1, Name: "超级管理员", Code: consts.RoleCodeSuperAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SystemUserRole.WithContext(ctx).Create(&model.SystemUserRole{UserID: 1, RoleID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SystemUser.WithContext(ctx).Create(&model.SystemUser{ID: 1, Username: "admin", Nickname: "管理员"}); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &model.InfraJob{ID: 1, Name: "测试任务", HandlerName: "testJob"}
+	jobLog := &model.InfraJobLog{JobID: 1, ExecuteIndex: 3, BeginTime: time.Now()}
+	countMessages := func() int64 {
+		count, err := q.SystemNotifyMessage.WithContext(ctx).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	// 模板未创建时只记录日志
+	notifySvc := NewNotifyService(q)
+	NewJobFailureNotifier(q, notifySvc).OnJobFailed(ctx, job, jobLog, errors.New("boom"))
+	if count := countMessages(); count != 0 {
+		t.Fatalf("expected no message without template, got %d", count)
+	}
+
+	if err := q.SystemNotifyTemplate.WithContext(ctx).Create(&model.SystemNotifyTemplate{
+		Name:    "定时任务执行失败",
+		Code:    JobFailedTemplateCode,
+		Content: "定时任务【{jobName}】第 {executeIndex} 次执行失败：{result}",
+		Type:    2,
+		Params:  `["jobName","handlerName","executeIndex","beginTime","result"]`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	notifySvc.RefreshCache()
+	NewJobFailureNotifier(q, notifySvc).OnJobFailed(ctx, job, jobLog, errors.New("boom"))
+	message, err := q.SystemNotifyMessage.WithContext(ctx).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.UserID != 1 || message.TemplateContent != "定时任务【测试任务】第 3 次执行失败：boom" {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+}
@@ -0,0 +1,88 @@
+package system
+
+import (
+	"context"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"github.com/wxlbd/admin-go/internal/service/infra"
+	"gorm.io/gorm"
+)
+
+const (
+	// LoginLogCleanJobHandlerName 登录日志清理任务的处理器名称
+	LoginLogCleanJobHandlerName = "loginLogCleanJob"
+	// SmsLogCleanJobHandlerName 短信日志清理任务的处理器名称
+	SmsLogCleanJobHandlerName = "smsLogCleanJob"
+	// MailLogCleanJobHandlerName 邮件日志清理任务的处理器名称
+	MailLogCleanJobHandlerName = "mailLogCleanJob"
+	// SmsCodeExpireJobHandlerName 短信验证码清理任务的处理器名称
+	SmsCodeExpireJobHandlerName = "smsCodeExpireJob"
+
+	defaultLogRetentionDays     = 30
+	defaultSmsCodeRetentionDays = 1
+)
+
+// LoginLogCleanJob 登录日志清理任务
+type LoginLogCleanJob struct {
+	*infra.LogCleanJob
+}
+
+func NewLoginLogCleanJob(q *query.Query) *LoginLogCleanJob {
+	return &LoginLogCleanJob{infra.NewLogCleanJob(LoginLogCleanJobHandlerName, defaultLogRetentionDays,
+		func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			l := q.SystemLoginLog
+			info, err := l.WithContext(ctx).Unscoped().Where(l.CreateTime.Lt(before)).Limit(limit).Delete()
+			return info.RowsAffected, err
+		})}
+}
+
+// SmsLogCleanJob 短信日志清理任务
+type SmsLogCleanJob struct {
+	*infra.LogCleanJob
+}
+
+func NewSmsLogCleanJob(q *query.Query) *SmsLogCleanJob {
+	return &SmsLogCleanJob{infra.NewLogCleanJob(SmsLogCleanJobHandlerName, defaultLogRetentionDays,
+		func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			l := q.SystemSmsLog
+			info, err := l.WithContext(ctx).Unscoped().Where(l.CreateTime.Lt(before)).Limit(limit).Delete()
+			return info.RowsAffected, err
+		})}
+}
+
+// MailLogCleanJob 邮件日志清理任务
+type MailLogCleanJob struct {
+	*infra.LogCleanJob
+}
+
+func NewMailLogCleanJob(db *gorm.DB) *MailLogCleanJob {
+	return &MailLogCleanJob{infra.NewLogCleanJob(MailLogCleanJobHandlerName, defaultLogRetentionDays,
+		func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			result := db.WithContext(ctx).Unscoped().Where("create_time < ?", before).Limit(limit).Delete(&model.SystemMailLog{})
+			return result.RowsAffected, result.Error
+		})}
+}
+
+// SmsCodeExpireJob 短信验证码清理任务，删除过期未使用的验证码
+// 验证码的有效性由 Redis 控制，数据库记录用于统计当日发送次数，因此不删除当日的记录
+type SmsCodeExpireJob struct {
+	*infra.LogCleanJob
+}
+
+func NewSmsCodeExpireJob(q *query.Query) *SmsCodeExpireJob {
+	return &SmsCodeExpireJob{infra.NewLogCleanJob(SmsCodeExpireJobHandlerName, defaultSmsCodeRetentionDays,
+		func(ctx context.Context, before time.Time, limit int) (int64, error) {
+			now := time.Now()
+			if today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()); before.After(today) {
+				before = today
+			}
+			if expired := now.Add(-SmsCodeExpire); before.After(expired) {
+				before = expired
+			}
+			c := q.SystemSmsCode
+			info, err := c.WithContext(ctx).Unscoped().Where(c.Used.Is(false), c.CreateTime.Lt(before)).Limit(limit).Delete()
+			return info.RowsAffected, err
+		})}
+}
@@ -0,0 +1,199 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"github.com/wxlbd/admin-go/pkg/config"
+	bzErr "github.com/wxlbd/admin-go/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ========== 登录失败限制配置常量 ==========
+
+const (
+	// LoginFailUserKeyPrefix 账号失败次数 key 前缀，格式：login:fail:user:{tenantId}:{username}
+	LoginFailUserKeyPrefix = "login:fail:user:"
+
+	// LoginFailIPKeyPrefix IP 失败次数 key 前缀，格式：login:fail:ip:{ip}
+	LoginFailIPKeyPrefix = "login:fail:ip:"
+
+	// LoginLockUserKeyPrefix 账号锁定 key 前缀
+	LoginLockUserKeyPrefix = "login:lock:user:"
+
+	// LoginLockIPKeyPrefix IP 锁定 key 前缀
+	LoginLockIPKeyPrefix = "login:lock:ip:"
+
+	defaultLoginFailureWindow = 15 * time.Minute
+	defaultLoginLockDuration  = 30 * time.Minute
+)
+
+// ========== 错误码定义 ==========
+
+// ErrLoginLockedCode 登录失败次数过多被锁定
+const ErrLoginLockedCode = 1_002_000_008
+
+// newLoginLockedError 构建锁定错误，提示剩余锁定时间
+func newLoginLockedError(ttl time.Duration) *bzErr.BizError {
+	minutes := int((ttl + time.Minute - 1) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return bzErr.NewBizError(ErrLoginLockedCode, fmt.Sprintf("登录失败次数过多，请 %d 分钟后再试", minutes))
+}
+
+// isLoginLockedError 是否为登录锁定错误
+func isLoginLockedError(err error) bool {
+	bizErr, ok := err.(*bzErr.BizError)
+	return ok && bizErr.Code == ErrLoginLockedCode
+}
+
+// ========== 登录失败限制服务 ==========
+
+// LoginLimitService 登录失败限制：按账号与 IP 分别计数，达到阈值后在锁定时长内拒绝登录
+// Redis 不可用时放行，不影响正常登录
+type LoginLimitService struct {
+	q   *query.Query
+	rdb *redis.Client
+}
+
+func NewLoginLimitService(q *query.Query, rdb *redis.Client) *LoginLimitService {
+	return &LoginLimitService{q: q, rdb: rdb}
+}
+
+// CheckLocked 校验账号或 IP 是否被锁定
+func (s *LoginLimitService) CheckLocked(ctx context.Context, tenantId int64, username, ip string) error {
+	keys := []string{LoginLockUserKeyPrefix + userLimitKey(tenantId, username)}
+	if ip != "" {
+		keys = append(keys, LoginLockIPKeyPrefix+ip)
+	}
+	for _, key := range keys {
+		ttl, err := s.rdb.TTL(ctx, key).Result()
+		if err != nil {
+			zap.L().Warn("Failed to check login lock", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		// 不存在返回 -2，未设置过期返回 -1
+		if ttl > 0 || ttl == -1 {
+			return newLoginLockedError(ttl)
+		}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次登录失败，达到阈值时锁定账号或 IP
+func (s *LoginLimitService) RecordFailure(ctx context.Context, tenantId int64, username, ip string) {
+	cfg := config.C.LoginLimit
+	userKey := userLimitKey(tenantId, username)
+	s.incrFailure(ctx, LoginFailUserKeyPrefix+userKey, LoginLockUserKeyPrefix+userKey, cfg.UserMaxFailures)
+	if ip != "" {
+		s.incrFailure(ctx, LoginFailIPKeyPrefix+ip, LoginLockIPKeyPrefix+ip, cfg.IPMaxFailures)
+	}
+}
+
+// ClearFailure 登录成功后清除账号失败次数，IP 计数保留至窗口过期
+func (s *LoginLimitService) ClearFailure(ctx context.Context, tenantId int64, username string) {
+	if err := s.rdb.Del(ctx, LoginFailUserKeyPrefix+userLimitKey(tenantId, username)).Err(); err != nil {
+		zap.L().Warn("Failed to clear login failures", zap.String("username", username), zap.Error(err))
+	}
+}
+
+// GetUserLoginLock 获取用户的登录锁定状态
+func (s *LoginLimitService) GetUserLoginLock(ctx context.Context, userId int64) (*system.UserLoginLockResp, error) {
+	userKey, err := s.userLimitKeyByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	failureCount, err := s.rdb.Get(ctx, LoginFailUserKeyPrefix+userKey).Int()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	ttl, err := s.rdb.TTL(ctx, LoginLockUserKeyPrefix+userKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &system.UserLoginLockResp{
+		UserID:       userId,
+		FailureCount: failureCount,
+		MaxFailures:  config.C.LoginLimit.UserMaxFailures,
+	}
+	if ttl > 0 {
+		unlockTime := time.Now().Add(ttl)
+		resp.Locked = true
+		resp.UnlockTime = &unlockTime
+	} else if ttl == -1 {
+		resp.Locked = true
+	}
+	return resp, nil
+}
+
+// ClearUserLoginLock 解除用户的登录锁定并清空失败次数
+func (s *LoginLimitService) ClearUserLoginLock(ctx context.Context, userId int64) error {
+	userKey, err := s.userLimitKeyByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Del(ctx, LoginFailUserKeyPrefix+userKey, LoginLockUserKeyPrefix+userKey).Err()
+}
+
+// incrFailureScript 失败次数 +1，计数没有过期时间时设置计数窗口；达到阈值后锁定并重置计数
+// 计数、设置窗口与锁定在同一脚本中完成，避免中途失败留下永不过期的计数
+// KEYS[1] 失败次数 key，KEYS[2] 锁定 key；ARGV[1] 阈值，ARGV[2] 计数窗口（毫秒），ARGV[3] 锁定时长（毫秒）
+var incrFailureScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if count >= tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[2], count, 'PX', ARGV[3])
+	redis.call('DEL', KEYS[1])
+end
+return count
+`)
+
+// incrFailure 失败次数 +1，达到阈值后锁定并重置计数
+func (s *LoginLimitService) incrFailure(ctx context.Context, failKey, lockKey string, maxFailures int) {
+	if maxFailures <= 0 {
+		return
+	}
+	err := incrFailureScript.Run(ctx, s.rdb, []string{failKey, lockKey},
+		maxFailures, loginFailureWindow().Milliseconds(), loginLockDuration().Milliseconds()).Err()
+	if err != nil {
+		zap.L().Warn("Failed to record login failure", zap.String("key", failKey), zap.Error(err))
+	}
+}
+
+// userLimitKeyByID 根据用户编号获取账号维度的 key 后缀
+func (s *LoginLimitService) userLimitKeyByID(ctx context.Context, userId int64) (string, error) {
+	u := s.q.SystemUser
+	user, err := u.WithContext(ctx).Where(u.ID.Eq(userId)).First()
+	if err != nil {
+		return "", bzErr.NewBizError(1002003003, "用户不存在")
+	}
+	return userLimitKey(user.TenantID, user.Username), nil
+}
+
+// userLimitKey 账号维度的 key 后缀，用户名按租户隔离
+func userLimitKey(tenantId int64, username string) string {
+	return fmt.Sprintf("%d:%s", tenantId, username)
+}
+
+func loginFailureWindow() time.Duration {
+	if seconds := config.C.LoginLimit.FailureWindow; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultLoginFailureWindow
+}
+
+func loginLockDuration() time.Duration {
+	if seconds := config.C.LoginLimit.LockDuration; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultLoginLockDuration
+}
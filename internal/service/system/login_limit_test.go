@@ -0,0 +1,75 @@
+package system
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/pkg/config"
+)
+
+func TestLoginLimitLocksAfterMaxFailures(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	s := NewLoginLimitService(nil, rdb)
+	origin := config.C.LoginLimit
+	config.C.LoginLimit = config.LoginLimitConfig{UserMaxFailures: 3, IPMaxFailures: 5}
+	t.Cleanup(func() { config.C.LoginLimit = origin })
+
+	failKey := LoginFailUserKeyPrefix + userLimitKey(1, "admin")
+	for i := 0; i < 2; i++ {
+		s.RecordFailure(ctx, 1, "admin", "127.0.0.1")
+	}
+	if err := s.CheckLocked(ctx, 1, "admin", "127.0.0.1"); err != nil {
+		t.Fatalf("expected not locked after 2 failures, got %v", err)
+	}
+	if ttl := mr.TTL(failKey); ttl <= 0 || ttl > defaultLoginFailureWindow {
+		t.Fatalf("failure counter ttl = %v, want within the failure window", ttl)
+	}
+
+	s.RecordFailure(ctx, 1, "admin", "127.0.0.1")
+	if err := s.CheckLocked(ctx, 1, "admin", "127.0.0.1"); err == nil {
+		t.Fatal("expected locked after 3 failures")
+	}
+	if mr.Exists(failKey) {
+		t.Fatal("expected failure counter reset after locking")
+	}
+	if ttl := mr.TTL(LoginLockUserKeyPrefix + userLimitKey(1, "admin")); ttl <= 0 || ttl > defaultLoginLockDuration {
+		t.Fatalf("lock ttl = %v, want within the lock duration", ttl)
+	}
+
+	// IP 计数独立，未达到阈值
+	if err := s.CheckLocked(ctx, 1, "other", "127.0.0.1"); err != nil {
+		t.Fatalf("expected ip not locked, got %v", err)
+	}
+
+	// 锁定到期后恢复
+	mr.FastForward(defaultLoginLockDuration + time.Second)
+	if err := s.CheckLocked(ctx, 1, "admin", "127.0.0.1"); err != nil {
+		t.Fatalf("expected unlocked after the lock expires, got %v", err)
+	}
+}
+
+func TestLoginLimitRepairsCounterWithoutTTL(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	s := NewLoginLimitService(nil, rdb)
+	origin := config.C.LoginLimit
+	config.C.LoginLimit = config.LoginLimitConfig{UserMaxFailures: 5}
+	t.Cleanup(func() { config.C.LoginLimit = origin })
+
+	// 旧版本中途失败遗留的没有过期时间的计数
+	failKey := LoginFailUserKeyPrefix + userLimitKey(1, "admin")
+	if err := mr.Set(failKey, "2"); err != nil {
+		t.Fatal(err)
+	}
+	s.RecordFailure(ctx, 1, "admin", "")
+	if ttl := mr.TTL(failKey); ttl <= 0 {
+		t.Fatalf("failure counter ttl = %v, want a ttl", ttl)
+	}
+
+	s.ClearFailure(ctx, 1, "admin")
+	if mr.Exists(failKey) {
+		t.Fatal("expected failure counter cleared")
+	}
+}
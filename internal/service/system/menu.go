@@ -6,16 +6,19 @@ import (
 
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/permission"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 )
 
 type MenuService struct {
-	q *query.Query
+	q            *query.Query
+	policySyncer *permission.PolicySyncer
 }
 
-func NewMenuService(q *query.Query) *MenuService {
+func NewMenuService(q *query.Query, policySyncer *permission.PolicySyncer) *MenuService {
 	return &MenuService{
-		q: q,
+		q:            q,
+		policySyncer: policySyncer,
 	}
 }
 
@@ -56,7 +59,8 @@ func (s *MenuService) CreateMenu(ctx context.Context, req *system.MenuCreateReq)
 func (s *MenuService) UpdateMenu(ctx context.Context, req *system.MenuUpdateReq) error {
 	m := s.q.SystemMenu
 	// 1. 校验存在
-	if _, err := m.WithContext(ctx).Where(m.ID.Eq(req.ID)).First(); err != nil {
+	menu, err := m.WithContext(ctx).Where(m.ID.Eq(req.ID)).First()
+	if err != nil {
 		return errors.New("菜单不存在")
 	}
 	// 2. 校验父菜单 (不能设置为自己)
@@ -95,8 +99,14 @@ func (s *MenuService) UpdateMenu(ctx context.Context, req *system.MenuUpdateReq)
 		updateData["always_show"] = model.BitBool(*req.AlwaysShow)
 	}
 
-	_, err := m.WithContext(ctx).Where(m.ID.Eq(req.ID)).Updates(updateData)
-	return err
+	if _, err := m.WithContext(ctx).Where(m.ID.Eq(req.ID)).Updates(updateData); err != nil {
+		return err
+	}
+	// 权限标识变更影响所有分配了该菜单的角色
+	if menu.Permission != req.Permission {
+		s.policySyncer.Reload(ctx)
+	}
+	return nil
 }
 
 // DeleteMenu 删除菜单
@@ -45,6 +45,13 @@ func (s *NotifyService) RefreshCache() {
 	s.mu.Unlock()
 }
 
+// hasTemplate 模板是否存在于缓存中
+func (s *NotifyService) hasTemplate(code string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.templateCache[code] != nil
+}
+
 // ================= Template CRUD =================
 
 func (s *NotifyService) CreateNotifyTemplate(ctx context.Context, r *system.NotifyTemplateCreateReq) (int64, error) {
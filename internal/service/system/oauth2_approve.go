@@ -0,0 +1,103 @@
+package system
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+)
+
+// OAuth2ApproveTimeout 批准记录有效期，过期后需用户重新批准
+const OAuth2ApproveTimeout = 30 * 24 * time.Hour
+
+// OAuth2ApproveService OAuth2 批准服务，与 Java OAuth2ApproveServiceImpl 对齐
+type OAuth2ApproveService struct {
+	q *query.Query
+}
+
+func NewOAuth2ApproveService(q *query.Query) *OAuth2ApproveService {
+	return &OAuth2ApproveService{q: q}
+}
+
+// CheckForPreApproval 授权前检查是否已批准：请求的授权范围均为自动批准，或均已被用户批准且未过期
+func (s *OAuth2ApproveService) CheckForPreApproval(ctx context.Context, userId int64, userType int, client *model.SystemOAuth2Client, requestedScopes []string) (bool, error) {
+	// 1. 全部为自动批准的授权范围，记录批准后直接通过
+	if lo.Every(ParseOAuth2ClientList(client.AutoApproveScopes), requestedScopes) {
+		expiresTime := time.Now().Add(OAuth2ApproveTimeout)
+		for _, scope := range requestedScopes {
+			if err := s.saveApprove(ctx, userId, userType, client.ClientID, scope, true, expiresTime); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	// 2. 检查已有的批准记录
+	approves, err := s.GetApproveList(ctx, userId, userType, client.ClientID)
+	if err != nil {
+		return false, err
+	}
+	approvedScopes := lo.FilterMap(approves, func(item *model.SystemOAuth2Approve, _ int) (string, bool) {
+		return item.Scope, bool(item.Approved)
+	})
+	return lo.Every(approvedScopes, requestedScopes), nil
+}
+
+// UpdateAfterApproval 用户在授权页确认后保存批准结果，请求的授权范围全部被批准才返回 true
+func (s *OAuth2ApproveService) UpdateAfterApproval(ctx context.Context, userId int64, userType int, clientId string, requestedScopes map[string]bool) (bool, error) {
+	if len(requestedScopes) == 0 {
+		return true, nil
+	}
+	success := true
+	expiresTime := time.Now().Add(OAuth2ApproveTimeout)
+	for scope, approved := range requestedScopes {
+		if !approved {
+			success = false
+		}
+		if err := s.saveApprove(ctx, userId, userType, clientId, scope, approved, expiresTime); err != nil {
+			return false, err
+		}
+	}
+	return success, nil
+}
+
+// GetApproveList 获得用户对客户端未过期的批准记录
+func (s *OAuth2ApproveService) GetApproveList(ctx context.Context, userId int64, userType int, clientId string) ([]*model.SystemOAuth2Approve, error) {
+	a := s.q.SystemOAuth2Approve
+	return a.WithContext(ctx).Where(
+		a.UserID.Eq(userId),
+		a.UserType.Eq(userType),
+		a.ClientID.Eq(clientId),
+		a.ExpiresTime.Gt(time.Now()),
+	).Find()
+}
+
+// saveApprove 保存批准记录，已存在则更新
+func (s *OAuth2ApproveService) saveApprove(ctx context.Context, userId int64, userType int, clientId, scope string, approved bool, expiresTime time.Time) error {
+	a := s.q.SystemOAuth2Approve
+	info, err := a.WithContext(ctx).Where(
+		a.UserID.Eq(userId),
+		a.UserType.Eq(userType),
+		a.ClientID.Eq(clientId),
+		a.Scope.Eq(scope),
+	).Updates(map[string]any{
+		"approved":     model.NewBitBool(approved),
+		"expires_time": expiresTime,
+	})
+	if err != nil {
+		return err
+	}
+	if info.RowsAffected > 0 {
+		return nil
+	}
+	return a.WithContext(ctx).Create(&model.SystemOAuth2Approve{
+		UserID:      userId,
+		UserType:    userType,
+		ClientID:    clientId,
+		Scope:       scope,
+		Approved:    model.NewBitBool(approved),
+		ExpiresTime: expiresTime,
+	})
+}
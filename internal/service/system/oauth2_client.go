@@ -2,15 +2,44 @@ package system
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"fmt"
 
+	"github.com/samber/lo"
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/consts"
 	"github.com/wxlbd/admin-go/internal/model"
+	bzErr "github.com/wxlbd/admin-go/pkg/errors"
 	"github.com/wxlbd/admin-go/pkg/pagination"
 
 	"gorm.io/gorm"
 )
 
+// ========== 错误码定义 ==========
+
+var (
+	// ErrOAuth2ClientNotExists OAuth2 客户端不存在
+	ErrOAuth2ClientNotExists = bzErr.NewBizError(1_002_020_000, "OAuth2 客户端不存在")
+
+	// ErrOAuth2ClientDisable OAuth2 客户端已禁用
+	ErrOAuth2ClientDisable = bzErr.NewBizError(1_002_020_002, "OAuth2 客户端已禁用")
+
+	// ErrOAuth2ClientGrantTypeNotExists 客户端不支持该授权类型
+	ErrOAuth2ClientGrantTypeNotExists = bzErr.NewBizError(1_002_020_003, "不支持该授权类型")
+
+	// ErrOAuth2ClientScopeOver 授权范围超出客户端配置
+	ErrOAuth2ClientScopeOver = bzErr.NewBizError(1_002_020_004, "授权范围过大")
+
+	// ErrOAuth2ClientSecretInvalid 客户端密钥不正确，不回显提交的密钥
+	ErrOAuth2ClientSecretInvalid = bzErr.NewBizError(1_002_020_006, "无效 client_secret")
+)
+
+// newOAuth2ClientRedirectUriError 重定向地址未在客户端中登记
+func newOAuth2ClientRedirectUriError(redirectUri string) *bzErr.BizError {
+	return bzErr.NewBizError(1_002_020_005, fmt.Sprintf("无效 redirect_uri: %s", redirectUri))
+}
+
 type OAuth2ClientService struct {
 	db *gorm.DB
 }
@@ -112,3 +141,60 @@ func (s *OAuth2ClientService) GetOAuth2ClientPage(ctx context.Context, r *system
 	}
 	return &pagination.PageResult[*model.SystemOAuth2Client]{List: list, Total: total}, nil
 }
+
+// GetOAuth2ClientByClientID 根据客户端编号获取客户端
+func (s *OAuth2ClientService) GetOAuth2ClientByClientID(ctx context.Context, clientId string) (*model.SystemOAuth2Client, error) {
+	var c model.SystemOAuth2Client
+	err := s.db.WithContext(ctx).Where("client_id = ?", clientId).First(&c).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ValidOAuthClient 校验客户端是否合法，与 Java validOAuthClientFromCache 对齐
+// clientSecret、grantType、redirectUri 为空时跳过对应校验
+func (s *OAuth2ClientService) ValidOAuthClient(ctx context.Context, clientId, clientSecret, grantType string, scopes []string, redirectUri string) (*model.SystemOAuth2Client, error) {
+	// 1. 校验客户端存在且开启
+	client, err := s.GetOAuth2ClientByClientID(ctx, clientId)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, ErrOAuth2ClientNotExists
+	}
+	if client.Status != consts.CommonStatusEnable {
+		return nil, ErrOAuth2ClientDisable
+	}
+
+	// 2. 校验客户端密钥，使用常量时间比较避免计时攻击
+	if clientSecret != "" && subtle.ConstantTimeCompare([]byte(clientSecret), []byte(client.ClientSecret)) != 1 {
+		return nil, ErrOAuth2ClientSecretInvalid
+	}
+	// 3. 校验授权方式
+	if grantType != "" && !lo.Contains(ParseOAuth2ClientList(client.AuthorizedGrantTypes), grantType) {
+		return nil, ErrOAuth2ClientGrantTypeNotExists
+	}
+	// 4. 校验授权范围
+	if len(scopes) > 0 && !lo.Every(ParseOAuth2ClientList(client.Scopes), scopes) {
+		return nil, ErrOAuth2ClientScopeOver
+	}
+	// 5. 校验回调地址，必须与登记的地址完全一致
+	if redirectUri != "" && !lo.Contains(ParseOAuth2ClientList(client.RedirectUris), redirectUri) {
+		return nil, newOAuth2ClientRedirectUriError(redirectUri)
+	}
+	return client, nil
+}
+
+// ParseOAuth2ClientList 解析客户端中以 JSON 数组存储的字段
+func ParseOAuth2ClientList(value string) []string {
+	var list []string
+	if value == "" {
+		return list
+	}
+	_ = json.Unmarshal([]byte(value), &list)
+	return list
+}
@@ -0,0 +1,456 @@
+package system
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/samber/lo"
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	bzErr "github.com/wxlbd/admin-go/pkg/errors"
+)
+
+// ========== OAuth2 授权配置常量 ==========
+
+const (
+	// RedisKeyOAuth2Code 授权码 Redis key，一次性使用
+	RedisKeyOAuth2Code = "oauth2_code:%s"
+
+	// OAuth2CodeExpire 授权码有效期
+	OAuth2CodeExpire = 5 * time.Minute
+
+	// OAuth2TokenTypeBearer 令牌类型
+	OAuth2TokenTypeBearer = "bearer"
+)
+
+// ========== 错误码定义 ==========
+
+var (
+	// ErrOAuth2GrantClientIdMismatch 授权码或令牌不属于当前客户端
+	ErrOAuth2GrantClientIdMismatch = bzErr.NewBizError(1_002_021_000, "client_id 不匹配")
+
+	// ErrOAuth2GrantRedirectUriMismatch 重定向地址与申请授权码时不一致
+	ErrOAuth2GrantRedirectUriMismatch = bzErr.NewBizError(1_002_021_001, "redirect_uri 不匹配")
+
+	// ErrOAuth2GrantStateMismatch state 与申请授权码时不一致
+	ErrOAuth2GrantStateMismatch = bzErr.NewBizError(1_002_021_002, "state 不匹配")
+
+	// ErrOAuth2GrantCodeVerifierMismatch PKCE 校验失败
+	ErrOAuth2GrantCodeVerifierMismatch = bzErr.NewBizError(1_002_021_003, "code_verifier 不匹配")
+
+	// ErrOAuth2GrantTwoFactorRequired 开启二次验证的账号无法通过密码模式获取令牌
+	ErrOAuth2GrantTwoFactorRequired = bzErr.NewBizError(1_002_021_004, "账号已开启二次验证，请使用授权码模式")
+
+	// ErrOAuth2CodeNotExists 授权码不存在或已过期
+	ErrOAuth2CodeNotExists = bzErr.NewBizError(1_002_022_000, "code 不存在")
+
+	// ErrOAuth2ResponseTypeNotSupported 不支持的 response_type
+	ErrOAuth2ResponseTypeNotSupported = bzErr.NewBizError(1_002_022_002, "response_type 参数值只允许 code")
+
+	// ErrOAuth2CodeChallengeMethodInvalid 不支持的 PKCE code_challenge_method
+	ErrOAuth2CodeChallengeMethodInvalid = bzErr.NewBizError(1_002_022_003, "code_challenge_method 参数值只允许 plain、S256")
+)
+
+// OAuth2Code 授权码，存储在 Redis 中
+type OAuth2Code struct {
+	UserID              int64    `json:"userId"`
+	UserType            int      `json:"userType"`
+	TenantID            int64    `json:"tenantId"`
+	ClientID            string   `json:"clientId"`
+	Scopes              []string `json:"scopes"`
+	RedirectURI         string   `json:"redirectUri"`
+	State               string   `json:"state"`
+	CodeChallenge       string   `json:"codeChallenge,omitempty"`
+	CodeChallengeMethod string   `json:"codeChallengeMethod,omitempty"`
+}
+
+// ========== OAuth2 授权服务 ==========
+
+// OAuth2GrantService OAuth2 授权服务：授权码申请与各 grant_type 的令牌签发，与 Java OAuth2OpenController 对齐
+type OAuth2GrantService struct {
+	q          *query.Query
+	rdb        *redis.Client
+	clientSvc  *OAuth2ClientService
+	approveSvc *OAuth2ApproveService
+	tokenSvc   *OAuth2TokenService
+	authSvc    *AuthService
+}
+
+func NewOAuth2GrantService(
+	q *query.Query,
+	rdb *redis.Client,
+	clientSvc *OAuth2ClientService,
+	approveSvc *OAuth2ApproveService,
+	tokenSvc *OAuth2TokenService,
+	authSvc *AuthService,
+) *OAuth2GrantService {
+	return &OAuth2GrantService{
+		q:          q,
+		rdb:        rdb,
+		clientSvc:  clientSvc,
+		approveSvc: approveSvc,
+		tokenSvc:   tokenSvc,
+		authSvc:    authSvc,
+	}
+}
+
+// GetAuthorizeInfo 获得授权页信息：客户端信息，以及各授权范围是否已被当前用户批准
+func (s *OAuth2GrantService) GetAuthorizeInfo(ctx context.Context, userId int64, userType int, clientId string) (*system.OAuth2OpenAuthorizeInfoResp, error) {
+	client, err := s.clientSvc.ValidOAuthClient(ctx, clientId, "", "", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	approves, err := s.approveSvc.GetApproveList(ctx, userId, userType, clientId)
+	if err != nil {
+		return nil, err
+	}
+	approved := lo.SliceToMap(approves, func(item *model.SystemOAuth2Approve) (string, bool) {
+		return item.Scope, bool(item.Approved)
+	})
+
+	scopes := lo.Map(ParseOAuth2ClientList(client.Scopes), func(scope string, _ int) system.OAuth2OpenAuthorizeScope {
+		return system.OAuth2OpenAuthorizeScope{Key: scope, Value: approved[scope]}
+	})
+	return &system.OAuth2OpenAuthorizeInfoResp{
+		Client: system.OAuth2OpenAuthorizeClient{Name: client.Name, Logo: client.Logo},
+		Scopes: scopes,
+	}, nil
+}
+
+// Authorize 申请授权，返回携带授权码的重定向地址
+// autoApprove 为 true 且尚未批准时返回空字符串，由前端展示授权页
+// 用户拒绝任一授权范围时返回携带 error=access_denied 的重定向地址
+func (s *OAuth2GrantService) Authorize(ctx context.Context, userId int64, userType int, tenantId int64, req *system.OAuth2OpenAuthorizeReq) (string, error) {
+	// 1.1 校验 response_type 与 PKCE 参数
+	if req.ResponseType != "code" {
+		return "", ErrOAuth2ResponseTypeNotSupported
+	}
+	if req.CodeChallenge != "" && req.CodeChallengeMethod == "" {
+		req.CodeChallengeMethod = consts.OAuth2CodeChallengeMethodPlain
+	}
+	if req.CodeChallengeMethod != "" && req.CodeChallengeMethod != consts.OAuth2CodeChallengeMethodPlain &&
+		req.CodeChallengeMethod != consts.OAuth2CodeChallengeMethodS256 {
+		return "", ErrOAuth2CodeChallengeMethodInvalid
+	}
+	// 1.2 解析授权范围
+	scopes := make(map[string]bool)
+	if req.Scope != "" {
+		if err := json.Unmarshal([]byte(req.Scope), &scopes); err != nil {
+			return "", bzErr.ErrParam
+		}
+	}
+	// 1.3 校验客户端
+	client, err := s.clientSvc.ValidOAuthClient(ctx, req.ClientID, "", consts.OAuth2GrantTypeAuthorizationCode, lo.Keys(scopes), req.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	// 2. 处理批准：自动批准场景下未批准则交由授权页，否则保存用户的批准结果
+	if req.AutoApprove {
+		approved, err := s.approveSvc.CheckForPreApproval(ctx, userId, userType, client, lo.Keys(scopes))
+		if err != nil {
+			return "", err
+		}
+		if !approved {
+			return "", nil
+		}
+	} else {
+		approved, err := s.approveSvc.UpdateAfterApproval(ctx, userId, userType, client.ClientID, scopes)
+		if err != nil {
+			return "", err
+		}
+		if !approved {
+			return buildOAuth2Redirect(req.RedirectURI, url.Values{
+				"error":             {"access_denied"},
+				"error_description": {"User denied access"},
+				"state":             {req.State},
+			}), nil
+		}
+	}
+
+	// 3. 签发授权码，仅包含已批准的授权范围
+	approvedScopes := lo.Filter(lo.Keys(scopes), func(scope string, _ int) bool { return scopes[scope] })
+	code := strings.ReplaceAll(uuid.NewString(), "-", "")
+	data, _ := json.Marshal(&OAuth2Code{
+		UserID:              userId,
+		UserType:            userType,
+		TenantID:            tenantId,
+		ClientID:            client.ClientID,
+		Scopes:              approvedScopes,
+		RedirectURI:         req.RedirectURI,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	})
+	if err := s.rdb.Set(ctx, fmt.Sprintf(RedisKeyOAuth2Code, code), data, OAuth2CodeExpire).Err(); err != nil {
+		return "", err
+	}
+	return buildOAuth2Redirect(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}), nil
+}
+
+// GrantToken 按 grant_type 签发访问令牌
+func (s *OAuth2GrantService) GrantToken(ctx context.Context, clientId, clientSecret string, req *system.OAuth2OpenTokenReq) (*system.OAuth2OpenAccessTokenResp, error) {
+	var (
+		tokenDO *OAuth2AccessToken
+		err     error
+	)
+	switch req.GrantType {
+	case consts.OAuth2GrantTypeAuthorizationCode:
+		tokenDO, err = s.grantAuthorizationCode(ctx, clientId, clientSecret, req)
+	case consts.OAuth2GrantTypePassword:
+		tokenDO, err = s.grantPassword(ctx, clientId, clientSecret, req)
+	case consts.OAuth2GrantTypeClientCredentials:
+		tokenDO, err = s.grantClientCredentials(ctx, clientId, clientSecret, req)
+	case consts.OAuth2GrantTypeRefreshToken:
+		tokenDO, err = s.grantRefreshToken(ctx, clientId, clientSecret, req)
+	default:
+		return nil, ErrOAuth2ClientGrantTypeNotExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &system.OAuth2OpenAccessTokenResp{
+		AccessToken:  tokenDO.AccessToken,
+		RefreshToken: tokenDO.RefreshToken,
+		TokenType:    OAuth2TokenTypeBearer,
+		ExpiresIn:    int64(time.Until(tokenDO.ExpiresTime).Seconds()),
+		Scope:        strings.Join(tokenDO.Scopes, " "),
+	}, nil
+}
+
+// CheckToken 校验访问令牌，供资源服务器调用
+func (s *OAuth2GrantService) CheckToken(ctx context.Context, clientId, clientSecret, token string) (*system.OAuth2OpenCheckTokenResp, error) {
+	if _, err := s.validClient(ctx, clientId, clientSecret, "", nil, false); err != nil {
+		return nil, err
+	}
+	tokenDO, err := s.tokenSvc.CheckAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &system.OAuth2OpenCheckTokenResp{
+		UserID:      tokenDO.UserID,
+		UserType:    tokenDO.UserType,
+		TenantID:    tokenDO.TenantID,
+		ClientID:    tokenDO.ClientID,
+		Scopes:      tokenDO.Scopes,
+		AccessToken: tokenDO.AccessToken,
+		Exp:         tokenDO.ExpiresTime.Unix(),
+	}, nil
+}
+
+// RevokeToken 撤销访问令牌及其令牌族，只允许撤销本客户端签发的令牌
+func (s *OAuth2GrantService) RevokeToken(ctx context.Context, clientId, clientSecret, token string) (bool, error) {
+	client, err := s.validClient(ctx, clientId, clientSecret, "", nil, false)
+	if err != nil {
+		return false, err
+	}
+	tokenDO, err := s.tokenSvc.GetAccessToken(ctx, token)
+	if err != nil {
+		return false, err
+	}
+	if tokenDO == nil || tokenDO.ClientID != client.ClientID {
+		return false, nil
+	}
+	if _, err := s.tokenSvc.RemoveAccessToken(ctx, tokenDO.AccessToken); err != nil {
+		return false, err
+	}
+	if err := s.tokenSvc.RevokeTokenFamily(ctx, tokenDO.FamilyID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// grantAuthorizationCode 授权码模式，申请授权码时携带 code_challenge 的公开客户端可不传密钥
+func (s *OAuth2GrantService) grantAuthorizationCode(ctx context.Context, clientId, clientSecret string, req *system.OAuth2OpenTokenReq) (*OAuth2AccessToken, error) {
+	// 1. 消费授权码，无论成功与否只能使用一次
+	data, err := s.rdb.GetDel(ctx, fmt.Sprintf(RedisKeyOAuth2Code, req.Code)).Result()
+	if err == redis.Nil {
+		return nil, ErrOAuth2CodeNotExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	var code OAuth2Code
+	if err := json.Unmarshal([]byte(data), &code); err != nil {
+		return nil, err
+	}
+
+	// 2. 校验客户端与授权码的一致性
+	client, err := s.validClient(ctx, clientId, clientSecret, consts.OAuth2GrantTypeAuthorizationCode, nil, code.CodeChallenge != "")
+	if err != nil {
+		return nil, err
+	}
+	if code.ClientID != client.ClientID {
+		return nil, ErrOAuth2GrantClientIdMismatch
+	}
+	if code.RedirectURI != req.RedirectURI {
+		return nil, ErrOAuth2GrantRedirectUriMismatch
+	}
+	if code.State != req.State {
+		return nil, ErrOAuth2GrantStateMismatch
+	}
+	if code.CodeChallenge != "" && !verifyCodeChallenge(code.CodeChallenge, code.CodeChallengeMethod, req.CodeVerifier) {
+		return nil, ErrOAuth2GrantCodeVerifierMismatch
+	}
+
+	// 3. 签发令牌
+	user, err := s.getEnabledUser(pkgContext.WithTenantID(ctx, code.TenantID), code.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenSvc.CreateClientAccessToken(ctx, client, user.ID, code.UserType, code.TenantID,
+		map[string]string{"nickname": user.Nickname}, code.Scopes)
+}
+
+// grantPassword 密码模式，复用后台登录的账号校验（锁定、失败计数与登录日志）
+func (s *OAuth2GrantService) grantPassword(ctx context.Context, clientId, clientSecret string, req *system.OAuth2OpenTokenReq) (*OAuth2AccessToken, error) {
+	scopes := strings.Fields(req.Scope)
+	client, err := s.validClient(ctx, clientId, clientSecret, consts.OAuth2GrantTypePassword, scopes, false)
+	if err != nil {
+		return nil, err
+	}
+	if req.Username == "" || req.Password == "" {
+		return nil, bzErr.ErrParam
+	}
+
+	tenantId := requestTenantID(ctx)
+	ctx = pkgContext.WithTenantID(ctx, tenantId)
+	user, err := s.authSvc.Authenticate(ctx, tenantId, req.Username, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	// 密码模式无法完成二次验证，开启后只能使用授权码模式
+	required, err := s.authSvc.twoFactorSvc.Required(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		return nil, ErrOAuth2GrantTwoFactorRequired
+	}
+	s.authSvc.loginLimitSvc.ClearFailure(ctx, tenantId, req.Username)
+
+	tokenDO, err := s.tokenSvc.CreateClientAccessToken(ctx, client, user.ID, consts.UserTypeAdmin, tenantId,
+		map[string]string{"nickname": user.Nickname}, defaultOAuth2Scopes(client, scopes))
+	if err != nil {
+		return nil, err
+	}
+	s.authSvc.createLoginLog(ctx, user.ID, user.Username, consts.LoginLogTypeUsername, consts.LoginResultSuccess)
+	return tokenDO, nil
+}
+
+// grantClientCredentials 客户端模式，令牌不关联用户，用户类型为客户端，不能访问管理后台接口
+func (s *OAuth2GrantService) grantClientCredentials(ctx context.Context, clientId, clientSecret string, req *system.OAuth2OpenTokenReq) (*OAuth2AccessToken, error) {
+	scopes := strings.Fields(req.Scope)
+	client, err := s.validClient(ctx, clientId, clientSecret, consts.OAuth2GrantTypeClientCredentials, scopes, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenSvc.CreateClientAccessToken(ctx, client, 0, consts.UserTypeClient, requestTenantID(ctx),
+		map[string]string{"nickname": client.Name}, defaultOAuth2Scopes(client, scopes))
+}
+
+// grantRefreshToken 刷新模式，旧令牌立即失效，新令牌沿用原令牌族、用户与授权范围
+func (s *OAuth2GrantService) grantRefreshToken(ctx context.Context, clientId, clientSecret string, req *system.OAuth2OpenTokenReq) (*OAuth2AccessToken, error) {
+	client, err := s.validClient(ctx, clientId, clientSecret, consts.OAuth2GrantTypeRefreshToken, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	// 消费前校验令牌所属客户端，其他客户端提交的刷新令牌不会被消费
+	oldToken, err := s.tokenSvc.ConsumeRefreshToken(ctx, req.RefreshToken, client.ClientID)
+	if err == ErrRefreshTokenReused {
+		s.authSvc.createTokenReusedLog(ctx, oldToken)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := oldToken.UserInfo
+	if oldToken.UserID > 0 {
+		user, err := s.getEnabledUser(pkgContext.WithTenantID(ctx, oldToken.TenantID), oldToken.UserID)
+		if err != nil {
+			return nil, err
+		}
+		userInfo = map[string]string{"nickname": user.Nickname}
+	}
+	return s.tokenSvc.RefreshAccessToken(ctx, oldToken, userInfo, client)
+}
+
+// validClient 校验客户端并认证客户端密钥
+// allowPublic 为 true 时（PKCE）允许未登记密钥的公开客户端不传密钥，登记了密钥的机密客户端始终需要密钥
+func (s *OAuth2GrantService) validClient(ctx context.Context, clientId, clientSecret, grantType string, scopes []string, allowPublic bool) (*model.SystemOAuth2Client, error) {
+	if clientId == "" {
+		return nil, ErrOAuth2ClientNotExists
+	}
+	client, err := s.clientSvc.ValidOAuthClient(ctx, clientId, clientSecret, grantType, scopes, "")
+	if err != nil {
+		return nil, err
+	}
+	if clientSecret == "" && (client.ClientSecret != "" || !allowPublic) {
+		return nil, ErrOAuth2ClientSecretInvalid
+	}
+	return client, nil
+}
+
+// getEnabledUser 获取用户并校验状态
+func (s *OAuth2GrantService) getEnabledUser(ctx context.Context, userId int64) (*model.SystemUser, error) {
+	u := s.q.SystemUser
+	user, err := u.WithContext(ctx).Where(u.ID.Eq(userId)).First()
+	if err != nil {
+		return nil, bzErr.NewBizError(1002000002, "用户不存在")
+	}
+	if user.Status != consts.CommonStatusEnable {
+		return nil, bzErr.NewBizError(1002000001, "用户已被禁用")
+	}
+	return user, nil
+}
+
+// defaultOAuth2Scopes 未指定授权范围时使用客户端的全部授权范围
+func defaultOAuth2Scopes(client *model.SystemOAuth2Client, scopes []string) []string {
+	if len(scopes) > 0 {
+		return scopes
+	}
+	return ParseOAuth2ClientList(client.Scopes)
+}
+
+// verifyCodeChallenge 校验 PKCE code_verifier（RFC 7636）
+func verifyCodeChallenge(challenge, method, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	expected := verifier
+	if method == consts.OAuth2CodeChallengeMethodS256 {
+		sum := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// buildOAuth2Redirect 在重定向地址上追加参数，忽略空值
+func buildOAuth2Redirect(redirectUri string, params url.Values) string {
+	for key, values := range params {
+		if len(values) == 0 || values[0] == "" {
+			params.Del(key)
+		}
+	}
+	if len(params) == 0 {
+		return redirectUri
+	}
+	separator := "?"
+	if strings.Contains(redirectUri, "?") {
+		separator = "&"
+	}
+	return redirectUri + separator + params.Encode()
+}
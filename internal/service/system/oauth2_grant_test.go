@@ -0,0 +1,140 @@
+package system
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/pkg/utils"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// RFC 7636 附录 B 示例
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if !verifyCodeChallenge(challenge, "S256", verifier) {
+		t.Fatal("S256 verifier should match")
+	}
+	if verifyCodeChallenge(challenge, "S256", verifier+"x") {
+		t.Fatal("wrong verifier should not match")
+	}
+	if !verifyCodeChallenge(verifier, "plain", verifier) {
+		t.Fatal("plain verifier should match")
+	}
+	if verifyCodeChallenge(challenge, "S256", "") {
+		t.Fatal("empty verifier should not match")
+	}
+}
+
+func TestBuildOAuth2Redirect(t *testing.T) {
+	cases := []struct {
+		uri    string
+		params url.Values
+		want   string
+	}{
+		{"https://a.com/cb", url.Values{"code": {"c1"}, "state": {""}}, "https://a.com/cb?code=c1"},
+		{"https://a.com/cb?x=1", url.Values{"code": {"c1"}, "state": {"s"}}, "https://a.com/cb?x=1&code=c1&state=s"},
+		{"https://a.com/cb", url.Values{"state": {""}}, "https://a.com/cb"},
+	}
+	for _, tc := range cases {
+		if got := buildOAuth2Redirect(tc.uri, tc.params); got != tc.want {
+			t.Errorf("buildOAuth2Redirect(%q) = %q, want %q", tc.uri, got, tc.want)
+		}
+	}
+}
+
+// newTestOAuth2GrantService 创建登记了机密客户端 confidential、other 与公开客户端 public 的授权服务
+func newTestOAuth2GrantService(t *testing.T) *OAuth2GrantService {
+	t.Helper()
+	db := newTestDB(t, &model.SystemOAuth2Client{})
+	grantTypes := `["authorization_code","client_credentials","refresh_token"]`
+	for _, client := range []*model.SystemOAuth2Client{
+		{ClientID: "confidential", ClientSecret: "s3cret", Name: "机密客户端", AuthorizedGrantTypes: grantTypes, Scopes: `["user.read"]`},
+		{ClientID: "other", ClientSecret: "0ther", Name: "其他客户端", AuthorizedGrantTypes: grantTypes, Scopes: `["user.read"]`},
+		{ClientID: "public", Name: "公开客户端", AuthorizedGrantTypes: grantTypes, Scopes: `["user.read"]`},
+	} {
+		client.Status = consts.CommonStatusEnable
+		if err := db.Create(client).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, rdb := useTestRedis(t)
+	return &OAuth2GrantService{rdb: rdb, clientSvc: NewOAuth2ClientService(db), tokenSvc: NewOAuth2TokenService()}
+}
+
+func TestOAuth2ValidClientSecret(t *testing.T) {
+	ctx := context.Background()
+	s := newTestOAuth2GrantService(t)
+
+	tests := []struct {
+		name         string
+		clientId     string
+		clientSecret string
+		allowPublic  bool
+		wantErr      error
+	}{
+		{"confidential with secret", "confidential", "s3cret", false, nil},
+		{"wrong secret", "confidential", "guess", false, ErrOAuth2ClientSecretInvalid},
+		{"confidential without secret", "confidential", "", false, ErrOAuth2ClientSecretInvalid},
+		{"confidential pkce without secret", "confidential", "", true, ErrOAuth2ClientSecretInvalid},
+		{"public pkce without secret", "public", "", true, nil},
+		{"public without pkce", "public", "", false, ErrOAuth2ClientSecretInvalid},
+		{"public with any secret", "public", "s3cret", true, ErrOAuth2ClientSecretInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.validClient(ctx, tt.clientId, tt.clientSecret, consts.OAuth2GrantTypeAuthorizationCode, nil, tt.allowPublic)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			// 错误信息不回显提交的密钥
+			if err != nil && tt.clientSecret != "" && strings.Contains(err.Error(), tt.clientSecret) {
+				t.Fatalf("error %q echoes the submitted secret", err.Error())
+			}
+		})
+	}
+}
+
+func TestOAuth2ClientCredentialsTokenUserType(t *testing.T) {
+	ctx := context.Background()
+	s := newTestOAuth2GrantService(t)
+
+	resp, err := s.GrantToken(ctx, "confidential", "s3cret", &system.OAuth2OpenTokenReq{GrantType: consts.OAuth2GrantTypeClientCredentials})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ParseToken(resp.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserType != consts.UserTypeClient || claims.UserID != 0 {
+		t.Fatalf("claims = %+v, want a client token without user", claims)
+	}
+}
+
+func TestOAuth2GrantRefreshTokenClientMismatch(t *testing.T) {
+	ctx := context.Background()
+	s := newTestOAuth2GrantService(t)
+
+	token, err := s.GrantToken(ctx, "confidential", "s3cret", &system.OAuth2OpenTokenReq{GrantType: consts.OAuth2GrantTypeClientCredentials})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &system.OAuth2OpenTokenReq{GrantType: consts.OAuth2GrantTypeRefreshToken, RefreshToken: token.RefreshToken}
+	if _, err := s.GrantToken(ctx, "other", "0ther", req); err != ErrOAuth2GrantClientIdMismatch {
+		t.Fatalf("err = %v, want ErrOAuth2GrantClientIdMismatch", err)
+	}
+	refreshed, err := s.GrantToken(ctx, "confidential", "s3cret", req)
+	if err != nil {
+		t.Fatalf("owner client should still refresh, err = %v", err)
+	}
+	if refreshed.RefreshToken == token.RefreshToken {
+		t.Error("refresh token should rotate")
+	}
+}
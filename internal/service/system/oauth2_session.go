@@ -0,0 +1,200 @@
+package system
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/admin-go/pkg/cache"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// RedisKeyOAuth2Session 在线会话信息（Hash），格式：oauth2_session:{sessionId}
+	RedisKeyOAuth2Session = "oauth2_session:%s"
+
+	// RedisKeyOAuth2UserSessions 用户的在线会话索引（ZSet，score 为过期时间戳），格式：oauth2_user_sessions:{userType}:{userId}
+	RedisKeyOAuth2UserSessions = "oauth2_user_sessions:%d:%d"
+
+	// RedisKeyOAuth2TenantSessions 租户的在线会话索引（ZSet，score 为过期时间戳），格式：oauth2_tenant_sessions:{tenantId}
+	RedisKeyOAuth2TenantSessions = "oauth2_tenant_sessions:%d"
+
+	// OAuth2SessionTouchInterval 最后活跃时间的更新间隔，避免每个请求都写 Redis
+	OAuth2SessionTouchInterval = time.Minute
+)
+
+// OAuth2Session 在线会话，与访问令牌一一对应
+// 会话编号为访问令牌的摘要，对外展示与操作时不暴露令牌本身
+type OAuth2Session struct {
+	ID             string `redis:"id"`
+	AccessToken    string `redis:"accessToken"`
+	RefreshToken   string `redis:"refreshToken"`
+	UserID         int64  `redis:"userId"`
+	UserType       int    `redis:"userType"`
+	TenantID       int64  `redis:"tenantId"`
+	ClientID       string `redis:"clientId"`
+	FamilyID       string `redis:"familyId"` // 令牌族编号，即令牌中的登录会话编号，刷新后不变
+	Nickname       string `redis:"nickname"`
+	UserIP         string `redis:"userIp"`
+	UserAgent      string `redis:"userAgent"`
+	LoginTime      int64  `redis:"loginTime"`      // 毫秒时间戳
+	LastActiveTime int64  `redis:"lastActiveTime"` // 毫秒时间戳
+	ExpiresTime    int64  `redis:"expiresTime"`    // 毫秒时间戳
+}
+
+// touchSessionScript 会话存在且距上次更新超过间隔时，更新最后活跃时间
+var touchSessionScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+local last = tonumber(redis.call('HGET', KEYS[1], 'lastActiveTime') or '0')
+if tonumber(ARGV[1]) - last < tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'lastActiveTime', ARGV[1])
+return 1
+`)
+
+// OAuth2SessionID 根据访问令牌计算会话编号
+func OAuth2SessionID(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return hex.EncodeToString(sum[:16])
+}
+
+// TouchOAuth2Session 更新会话的最后活跃时间，由认证中间件在每次请求时调用
+func TouchOAuth2Session(ctx context.Context, accessToken string) {
+	if cache.RDB == nil {
+		return
+	}
+	key := fmt.Sprintf(RedisKeyOAuth2Session, OAuth2SessionID(accessToken))
+	now := time.Now().UnixMilli()
+	if err := touchSessionScript.Run(ctx, cache.RDB, []string{key}, now, OAuth2SessionTouchInterval.Milliseconds()).Err(); err != nil {
+		zap.L().Warn("Failed to touch oauth2 session", zap.Error(err))
+	}
+}
+
+// GetSession 获取在线会话，不存在或已过期时返回 nil
+func (s *OAuth2TokenService) GetSession(ctx context.Context, sessionId string) (*OAuth2Session, error) {
+	if cache.RDB == nil {
+		return nil, nil
+	}
+	cmd := cache.RDB.HGetAll(ctx, fmt.Sprintf(RedisKeyOAuth2Session, sessionId))
+	if err := cmd.Err(); err != nil {
+		return nil, err
+	}
+	if len(cmd.Val()) == 0 {
+		return nil, nil
+	}
+	var session OAuth2Session
+	if err := cmd.Scan(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetTenantSessions 获取租户下的全部在线会话
+func (s *OAuth2TokenService) GetTenantSessions(ctx context.Context, tenantId int64) ([]*OAuth2Session, error) {
+	return s.getIndexedSessions(ctx, fmt.Sprintf(RedisKeyOAuth2TenantSessions, tenantId))
+}
+
+// GetUserSessions 获取用户的全部在线会话
+func (s *OAuth2TokenService) GetUserSessions(ctx context.Context, userType int, userId int64) ([]*OAuth2Session, error) {
+	return s.getIndexedSessions(ctx, fmt.Sprintf(RedisKeyOAuth2UserSessions, userType, userId))
+}
+
+// saveSession 创建访问令牌后记录在线会话，登录 IP 与 UA 取自当前请求
+func (s *OAuth2TokenService) saveSession(ctx context.Context, tokenDO *OAuth2AccessToken) error {
+	if cache.RDB == nil {
+		return nil
+	}
+	now := time.Now()
+	session := &OAuth2Session{
+		ID:             OAuth2SessionID(tokenDO.AccessToken),
+		AccessToken:    tokenDO.AccessToken,
+		RefreshToken:   tokenDO.RefreshToken,
+		UserID:         tokenDO.UserID,
+		UserType:       tokenDO.UserType,
+		TenantID:       tokenDO.TenantID,
+		ClientID:       tokenDO.ClientID,
+		FamilyID:       tokenDO.FamilyID,
+		Nickname:       tokenDO.UserInfo["nickname"],
+		LoginTime:      now.UnixMilli(),
+		LastActiveTime: now.UnixMilli(),
+		ExpiresTime:    tokenDO.ExpiresTime.UnixMilli(),
+	}
+	if c := pkgContext.GetGinContext(ctx); c != nil {
+		session.UserIP = c.ClientIP()
+		session.UserAgent = utils.TruncateString(c.Request.UserAgent(), 512)
+	}
+
+	sessionKey := fmt.Sprintf(RedisKeyOAuth2Session, session.ID)
+	score := float64(tokenDO.ExpiresTime.Unix())
+	expired := strconv.FormatInt(now.Unix(), 10)
+	pipe := cache.RDB.TxPipeline()
+	pipe.HSet(ctx, sessionKey, session)
+	pipe.ExpireAt(ctx, sessionKey, tokenDO.ExpiresTime)
+	for _, indexKey := range sessionIndexKeys(session) {
+		pipe.ZAdd(ctx, indexKey, redis.Z{Score: score, Member: session.ID})
+		pipe.ZRemRangeByScore(ctx, indexKey, "-inf", expired)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// removeSession 删除在线会话及索引
+func (s *OAuth2TokenService) removeSession(ctx context.Context, session *OAuth2Session) error {
+	if cache.RDB == nil || session == nil {
+		return nil
+	}
+	pipe := cache.RDB.TxPipeline()
+	pipe.Del(ctx, fmt.Sprintf(RedisKeyOAuth2Session, session.ID))
+	for _, indexKey := range sessionIndexKeys(session) {
+		pipe.ZRem(ctx, indexKey, session.ID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// getIndexedSessions 读取索引中的会话，顺带清理已过期的索引项
+func (s *OAuth2TokenService) getIndexedSessions(ctx context.Context, indexKey string) ([]*OAuth2Session, error) {
+	if cache.RDB == nil {
+		return nil, nil
+	}
+	cache.RDB.ZRemRangeByScore(ctx, indexKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10))
+	ids, err := cache.RDB.ZRange(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*OAuth2Session, 0, len(ids))
+	var staleIds []any
+	for _, id := range ids {
+		session, err := s.GetSession(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			staleIds = append(staleIds, id)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	if len(staleIds) > 0 {
+		cache.RDB.ZRem(ctx, indexKey, staleIds...)
+	}
+	return sessions, nil
+}
+
+// sessionIndexKeys 会话所在的索引
+func sessionIndexKeys(session *OAuth2Session) []string {
+	return []string{
+		fmt.Sprintf(RedisKeyOAuth2UserSessions, session.UserType, session.UserID),
+		fmt.Sprintf(RedisKeyOAuth2TenantSessions, session.TenantID),
+	}
+}
@@ -6,18 +6,35 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
 	"github.com/wxlbd/admin-go/pkg/cache"
 	"github.com/wxlbd/admin-go/pkg/errors"
 	"github.com/wxlbd/admin-go/pkg/utils"
+	"go.uber.org/zap"
 )
 
 const (
 	// Redis Key 前缀：访问令牌，与 Java 保持一致
 	RedisKeyOAuth2AccessToken = "oauth2_access_token:%s"
 
+	// RedisKeyOAuth2RefreshToken 刷新令牌，与访问令牌分开存储，避免刷新令牌被当作访问令牌使用
+	RedisKeyOAuth2RefreshToken = "oauth2_refresh_token:%s"
+
+	// RedisKeyOAuth2RefreshTokenUsed 已使用的刷新令牌，保留至原有效期结束，用于识别重放
+	RedisKeyOAuth2RefreshTokenUsed = "oauth2_refresh_token_used:%s"
+
+	// RedisKeyOAuth2TokenFamily 令牌族（Hash），记录同一次登录当前有效的访问令牌与刷新令牌
+	RedisKeyOAuth2TokenFamily = "oauth2_token_family:%s"
+
 	// 默认过期时间
 	DefaultAccessTokenExpireSeconds  = 30 * 24 * 3600 // 30 天
 	DefaultRefreshTokenExpireSeconds = 60 * 24 * 3600 // 60 天
+
+	// ImpersonateTokenExpireSeconds 访问其它租户的令牌有效期，刷新令牌同样有效期
+	ImpersonateTokenExpireSeconds = 2 * 3600 // 2 小时
 )
 
 // OAuth2AccessToken 访问令牌结构，与 Java OAuth2AccessTokenDO 对齐
@@ -30,9 +47,28 @@ type OAuth2AccessToken struct {
 	UserInfo     map[string]string `json:"userInfo"`
 	ClientID     string            `json:"clientId"`
 	Scopes       []string          `json:"scopes"`
-	ExpiresTime  time.Time         `json:"expiresTime"`
+	FamilyID     string            `json:"familyId,omitempty"` // 令牌族编号，同一次登录多次刷新共用
+	// ImpersonatorID 代登录的操作人编号，访问其它租户时签发的令牌才有值
+	ImpersonatorID int64 `json:"impersonatorId,omitempty"`
+	// ImpersonateExpiresTime 代登录的截止时间，自首次签发起计算，刷新令牌不会延长
+	ImpersonateExpiresTime *time.Time `json:"impersonateExpiresTime,omitempty"`
+	ExpiresTime            time.Time  `json:"expiresTime"`
+}
+
+// tokenImpersonation 代登录信息，刷新令牌时沿用操作人与截止时间
+type tokenImpersonation struct {
+	impersonatorId int64
+	expiresTime    time.Time
 }
 
+var (
+	// ErrRefreshTokenInvalid 刷新令牌无效
+	ErrRefreshTokenInvalid = errors.NewBizError(1002000005, "刷新令牌无效或已过期")
+
+	// ErrRefreshTokenReused 刷新令牌被重复使用，整个令牌族已被吊销
+	ErrRefreshTokenReused = errors.NewBizError(1002000006, "刷新令牌已失效，请重新登录")
+)
+
 // OAuth2TokenService OAuth2 Token 服务
 type OAuth2TokenService struct{}
 
@@ -40,12 +76,165 @@ func NewOAuth2TokenService() *OAuth2TokenService {
 	return &OAuth2TokenService{}
 }
 
-// CreateAccessToken 创建访问令牌（使用 JWT 格式）
+// CreateAccessToken 创建访问令牌（使用 JWT 格式），后台账号登录使用，令牌归属默认客户端
 func (s *OAuth2TokenService) CreateAccessToken(ctx context.Context, userId int64, userType int, tenantId int64, userInfo map[string]string) (*OAuth2AccessToken, error) {
+	accessSeconds, refreshSeconds := tokenValiditySeconds(nil)
+	return s.createAccessToken(ctx, userId, userType, tenantId, userInfo, consts.OAuth2ClientIDDefault, []string{},
+		accessSeconds, refreshSeconds, "", nil)
+}
+
+// CreateImpersonateAccessToken 为访问其它租户的操作人签发代登录令牌，令牌归属默认客户端，有效期较短
+// 代登录自签发起 ImpersonateTokenExpireSeconds 后截止，期间刷新令牌不会延长
+func (s *OAuth2TokenService) CreateImpersonateAccessToken(ctx context.Context, userId int64, userType int, tenantId int64, userInfo map[string]string, impersonatorId int64) (*OAuth2AccessToken, error) {
+	return s.createAccessToken(ctx, userId, userType, tenantId, userInfo, consts.OAuth2ClientIDDefault, []string{},
+		ImpersonateTokenExpireSeconds, ImpersonateTokenExpireSeconds, "", &tokenImpersonation{
+			impersonatorId: impersonatorId,
+			// 去除单调时钟读数，与刷新时从 Redis 读取的截止时间一致按墙上时间比较
+			expiresTime: time.Now().Add(ImpersonateTokenExpireSeconds * time.Second).Round(0),
+		})
+}
+
+// CreateClientAccessToken 为 OAuth2 客户端创建访问令牌，有效期取客户端配置
+func (s *OAuth2TokenService) CreateClientAccessToken(ctx context.Context, client *model.SystemOAuth2Client, userId int64, userType int, tenantId int64, userInfo map[string]string, scopes []string) (*OAuth2AccessToken, error) {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	accessSeconds, refreshSeconds := tokenValiditySeconds(client)
+	return s.createAccessToken(ctx, userId, userType, tenantId, userInfo, client.ClientID, scopes, accessSeconds, refreshSeconds, "", nil)
+}
+
+// ConsumeRefreshToken 消费刷新令牌：原子删除，只能成功使用一次，并删除与之配对的访问令牌
+// 刷新令牌不属于 clientId 时返回 ErrOAuth2GrantClientIdMismatch，不消费令牌，避免其他客户端使其失效
+// 已使用过的刷新令牌再次出现视为泄露，吊销整个令牌族并返回 ErrRefreshTokenReused，
+// 此时同时返回原刷新令牌信息，便于调用方记录安全日志
+func (s *OAuth2TokenService) ConsumeRefreshToken(ctx context.Context, refreshToken, clientId string) (*OAuth2AccessToken, error) {
+	if cache.RDB == nil || refreshToken == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+	key := fmt.Sprintf(RedisKeyOAuth2RefreshToken, refreshToken)
+
+	// 1. 先读取并校验客户端与有效期
+	data, err := cache.RDB.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return s.checkRefreshTokenReuse(ctx, refreshToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tokenDO OAuth2AccessToken
+	if err := json.Unmarshal([]byte(data), &tokenDO); err != nil {
+		return nil, err
+	}
+	if tokenDO.ClientID != clientId {
+		return nil, ErrOAuth2GrantClientIdMismatch
+	}
+	if time.Now().After(tokenDO.ExpiresTime) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	// 2. 原子取出并删除，并发刷新时只有一个请求能成功，其余按重复使用处理
+	if err := cache.RDB.GetDel(ctx, key).Err(); err == redis.Nil {
+		return s.checkRefreshTokenReuse(ctx, refreshToken)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// 3. 标记为已使用，保留至原有效期结束
+	if err := cache.RDB.Set(ctx, fmt.Sprintf(RedisKeyOAuth2RefreshTokenUsed, refreshToken), data, time.Until(tokenDO.ExpiresTime)).Err(); err != nil {
+		return nil, err
+	}
+
+	// 4. 删除配对的访问令牌
+	if _, err := s.RemoveAccessToken(ctx, tokenDO.AccessToken); err != nil {
+		return nil, err
+	}
+	return &tokenDO, nil
+}
+
+// RefreshAccessToken 使用已消费的刷新令牌签发新令牌，沿用令牌族、客户端与授权范围
+// client 为空时使用默认有效期；代登录令牌保留操作人与截止时间，超过截止时间后不能再刷新
+func (s *OAuth2TokenService) RefreshAccessToken(ctx context.Context, refreshTokenDO *OAuth2AccessToken, userInfo map[string]string, client *model.SystemOAuth2Client) (*OAuth2AccessToken, error) {
+	accessSeconds, refreshSeconds := tokenValiditySeconds(client)
+	var impersonation *tokenImpersonation
+	if refreshTokenDO.ImpersonatorID > 0 {
+		if refreshTokenDO.ImpersonateExpiresTime == nil || !time.Now().Before(*refreshTokenDO.ImpersonateExpiresTime) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		accessSeconds, refreshSeconds = ImpersonateTokenExpireSeconds, ImpersonateTokenExpireSeconds
+		impersonation = &tokenImpersonation{
+			impersonatorId: refreshTokenDO.ImpersonatorID,
+			expiresTime:    *refreshTokenDO.ImpersonateExpiresTime,
+		}
+	}
+	return s.createAccessToken(ctx, refreshTokenDO.UserID, refreshTokenDO.UserType, refreshTokenDO.TenantID, userInfo,
+		refreshTokenDO.ClientID, refreshTokenDO.Scopes, accessSeconds, refreshSeconds, refreshTokenDO.FamilyID, impersonation)
+}
+
+// RevokeTokenFamily 吊销令牌族当前有效的访问令牌与刷新令牌
+func (s *OAuth2TokenService) RevokeTokenFamily(ctx context.Context, familyId string) error {
+	if cache.RDB == nil || familyId == "" {
+		return nil
+	}
+	familyKey := fmt.Sprintf(RedisKeyOAuth2TokenFamily, familyId)
+	family, err := cache.RDB.HGetAll(ctx, familyKey).Result()
+	if err != nil {
+		return err
+	}
+	if accessToken := family["accessToken"]; accessToken != "" {
+		if _, err := s.RemoveAccessToken(ctx, accessToken); err != nil {
+			return err
+		}
+	}
+	keys := []string{familyKey}
+	if refreshToken := family["refreshToken"]; refreshToken != "" {
+		keys = append(keys, fmt.Sprintf(RedisKeyOAuth2RefreshToken, refreshToken))
+	}
+	return cache.RDB.Del(ctx, keys...).Err()
+}
+
+// checkRefreshTokenReuse 刷新令牌不存在时，判断是否为已使用过的刷新令牌被重放
+func (s *OAuth2TokenService) checkRefreshTokenReuse(ctx context.Context, refreshToken string) (*OAuth2AccessToken, error) {
+	data, err := cache.RDB.Get(ctx, fmt.Sprintf(RedisKeyOAuth2RefreshTokenUsed, refreshToken)).Result()
+	if err == redis.Nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tokenDO OAuth2AccessToken
+	if err := json.Unmarshal([]byte(data), &tokenDO); err != nil {
+		return nil, err
+	}
+	if err := s.RevokeTokenFamily(ctx, tokenDO.FamilyID); err != nil {
+		zap.L().Error("Failed to revoke token family", zap.String("familyId", tokenDO.FamilyID), zap.Error(err))
+	}
+	zap.L().Warn("Refresh token reused, token family revoked",
+		zap.Int64("userId", tokenDO.UserID), zap.String("clientId", tokenDO.ClientID), zap.String("familyId", tokenDO.FamilyID))
+	return &tokenDO, ErrRefreshTokenReused
+}
+
+// createAccessToken 签发访问令牌与刷新令牌，familyId 为空时开启新的令牌族
+// impersonation 为代登录信息，令牌有效期不超过代登录的截止时间
+func (s *OAuth2TokenService) createAccessToken(ctx context.Context, userId int64, userType int, tenantId int64, userInfo map[string]string,
+	clientId string, scopes []string, accessSeconds, refreshSeconds int, familyId string, impersonation *tokenImpersonation) (*OAuth2AccessToken, error) {
+	if familyId == "" {
+		familyId = uuid.NewString()
+	}
+
 	// 1. 计算过期时间
-	expireDuration := time.Duration(DefaultAccessTokenExpireSeconds) * time.Second
-	refreshDuration := time.Duration(DefaultRefreshTokenExpireSeconds) * time.Second
-	expiresTime := time.Now().Add(expireDuration)
+	now := time.Now()
+	expireDuration := time.Duration(accessSeconds) * time.Second
+	refreshDuration := time.Duration(refreshSeconds) * time.Second
+	var impersonatorId int64
+	var impersonateExpiresTime *time.Time
+	if impersonation != nil {
+		impersonatorId = impersonation.impersonatorId
+		impersonateExpiresTime = &impersonation.expiresTime
+		remaining := impersonation.expiresTime.Sub(now)
+		expireDuration = min(expireDuration, remaining)
+		refreshDuration = min(refreshDuration, remaining)
+	}
+	expiresTime := now.Add(expireDuration)
 
 	// 2. 获取昵称
 	nickname := ""
@@ -54,11 +243,19 @@ func (s *OAuth2TokenService) CreateAccessToken(ctx context.Context, userId int64
 	}
 
 	// 3. 使用 JWT 生成令牌（包含完整用户信息）
-	accessToken, err := utils.GenerateTokenWithInfo(userId, userType, tenantId, nickname, expireDuration)
+	claims := utils.Claims{
+		UserID:         userId,
+		UserType:       userType,
+		TenantID:       tenantId,
+		Nickname:       nickname,
+		ImpersonatorID: impersonatorId,
+		SessionID:      familyId,
+	}
+	accessToken, err := utils.GenerateTokenWithClaims(claims, expireDuration)
 	if err != nil {
 		return nil, err
 	}
-	refreshToken, err := utils.GenerateTokenWithInfo(userId, userType, tenantId, nickname, refreshDuration)
+	refreshToken, err := utils.GenerateTokenWithClaims(claims, refreshDuration)
 	if err != nil {
 		return nil, err
 	}
@@ -71,9 +268,13 @@ func (s *OAuth2TokenService) CreateAccessToken(ctx context.Context, userId int64
 		UserType:     userType,
 		TenantID:     tenantId,
 		UserInfo:     userInfo,
-		ClientID:     "default",
-		Scopes:       []string{},
+		ClientID:     clientId,
+		Scopes:       scopes,
+		FamilyID:     familyId,
 		ExpiresTime:  expiresTime,
+
+		ImpersonatorID:         impersonatorId,
+		ImpersonateExpiresTime: impersonateExpiresTime,
 	}
 
 	// 5. 存储到 Redis（白名单机制）
@@ -81,22 +282,31 @@ func (s *OAuth2TokenService) CreateAccessToken(ctx context.Context, userId int64
 		return nil, err
 	}
 
-	// 6. 同时存储 RefreshToken 到 Redis
+	// 6. 存储刷新令牌，并记录令牌族当前有效的令牌
 	refreshTokenDO := &OAuth2AccessToken{
-		AccessToken:  refreshToken,
+		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		UserID:       userId,
 		UserType:     userType,
 		TenantID:     tenantId,
 		UserInfo:     userInfo,
-		ClientID:     "default",
-		Scopes:       []string{},
-		ExpiresTime:  time.Now().Add(refreshDuration),
+		ClientID:     clientId,
+		Scopes:       scopes,
+		FamilyID:     familyId,
+		ExpiresTime:  now.Add(refreshDuration),
+
+		ImpersonatorID:         impersonatorId,
+		ImpersonateExpiresTime: impersonateExpiresTime,
 	}
-	if err := s.setAccessTokenToRedis(ctx, refreshTokenDO); err != nil {
+	if err := s.setRefreshTokenToRedis(ctx, refreshTokenDO); err != nil {
 		return nil, err
 	}
 
+	// 7. 记录在线会话，失败不影响登录
+	if err := s.saveSession(ctx, tokenDO); err != nil {
+		zap.L().Warn("Failed to save oauth2 session", zap.Int64("userId", userId), zap.Error(err))
+	}
+
 	return tokenDO, nil
 }
 
@@ -148,13 +358,26 @@ func (s *OAuth2TokenService) RemoveAccessToken(ctx context.Context, accessToken
 		cache.RDB.Del(ctx, redisKey)
 	}
 
+	// 3. 删除访问令牌对应的在线会话
+	if session, _ := s.GetSession(ctx, OAuth2SessionID(accessToken)); session != nil {
+		_ = s.removeSession(ctx, session)
+	}
+
 	return tokenDO, nil
 }
 
-// RefreshAccessToken 刷新访问令牌
-func (s *OAuth2TokenService) RefreshAccessToken(ctx context.Context, refreshToken string, userId int64, userType int, tenantId int64, userInfo map[string]string) (*OAuth2AccessToken, error) {
-	// 直接创建新的访问令牌
-	return s.CreateAccessToken(ctx, userId, userType, tenantId, userInfo)
+// RemoveSession 删除在线会话，同时删除其访问令牌与刷新令牌
+func (s *OAuth2TokenService) RemoveSession(ctx context.Context, session *OAuth2Session) error {
+	if cache.RDB == nil {
+		return nil
+	}
+	if err := cache.RDB.Del(ctx,
+		fmt.Sprintf(RedisKeyOAuth2AccessToken, session.AccessToken),
+		fmt.Sprintf(RedisKeyOAuth2RefreshToken, session.RefreshToken),
+	).Err(); err != nil {
+		return err
+	}
+	return s.removeSession(ctx, session)
 }
 
 // setAccessTokenToRedis 将令牌存储到 Redis
@@ -179,3 +402,38 @@ func (s *OAuth2TokenService) setAccessTokenToRedis(ctx context.Context, tokenDO
 
 	return cache.RDB.Set(ctx, redisKey, string(data), ttl).Err()
 }
+
+// setRefreshTokenToRedis 存储刷新令牌，并更新令牌族
+func (s *OAuth2TokenService) setRefreshTokenToRedis(ctx context.Context, refreshTokenDO *OAuth2AccessToken) error {
+	if cache.RDB == nil {
+		return nil
+	}
+	data, err := json.Marshal(refreshTokenDO)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(refreshTokenDO.ExpiresTime)
+	if ttl <= 0 {
+		return nil
+	}
+
+	familyKey := fmt.Sprintf(RedisKeyOAuth2TokenFamily, refreshTokenDO.FamilyID)
+	pipe := cache.RDB.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf(RedisKeyOAuth2RefreshToken, refreshTokenDO.RefreshToken), string(data), ttl)
+	pipe.HSet(ctx, familyKey, "accessToken", refreshTokenDO.AccessToken, "refreshToken", refreshTokenDO.RefreshToken)
+	pipe.ExpireAt(ctx, familyKey, refreshTokenDO.ExpiresTime)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// tokenValiditySeconds 令牌有效期，优先取客户端配置
+func tokenValiditySeconds(client *model.SystemOAuth2Client) (int, int) {
+	accessSeconds, refreshSeconds := DefaultAccessTokenExpireSeconds, DefaultRefreshTokenExpireSeconds
+	if client != nil && client.AccessTokenValiditySeconds > 0 {
+		accessSeconds = client.AccessTokenValiditySeconds
+	}
+	if client != nil && client.RefreshTokenValiditySeconds > 0 {
+		refreshSeconds = client.RefreshTokenValiditySeconds
+	}
+	return accessSeconds, refreshSeconds
+}
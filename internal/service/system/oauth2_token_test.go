@@ -0,0 +1,173 @@
+package system
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/pkg/utils"
+)
+
+func TestRefreshAccessTokenKeepsLoginSession(t *testing.T) {
+	ctx := context.Background()
+	useTestRedis(t)
+	s := NewOAuth2TokenService()
+
+	token, err := s.CreateAccessToken(ctx, 1, consts.UserTypeAdmin, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumed, err := s.ConsumeRefreshToken(ctx, token.RefreshToken, consts.OAuth2ClientIDDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := s.RefreshAccessToken(ctx, consumed, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// WebSocket 连接持有的旧令牌与刷新后的在线会话通过登录会话编号关联
+	oldClaims, err := utils.ParseToken(token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := s.GetSession(ctx, OAuth2SessionID(refreshed.AccessToken))
+	if err != nil || session == nil {
+		t.Fatalf("session = %v, err = %v", session, err)
+	}
+	if oldClaims.SessionID == "" || session.FamilyID != oldClaims.SessionID {
+		t.Errorf("session family = %q, want the login session %q of the original token", session.FamilyID, oldClaims.SessionID)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	useTestRedis(t)
+	s := NewOAuth2TokenService()
+
+	first, err := s.CreateAccessToken(ctx, 1, consts.UserTypeAdmin, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumed, err := s.ConsumeRefreshToken(ctx, first.RefreshToken, consts.OAuth2ClientIDDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.RefreshAccessToken(ctx, consumed, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.FamilyID != first.FamilyID || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refreshed token should rotate within the family %q, got family %q", first.FamilyID, second.FamilyID)
+	}
+	// 旧访问令牌随刷新失效，新令牌可用
+	if token, _ := s.GetAccessToken(ctx, first.AccessToken); token != nil {
+		t.Error("old access token should be removed")
+	}
+	if token, _ := s.GetAccessToken(ctx, second.AccessToken); token == nil {
+		t.Error("new access token should be valid")
+	}
+	third, err := s.ConsumeRefreshToken(ctx, second.RefreshToken, consts.OAuth2ClientIDDefault)
+	if err != nil || third.FamilyID != first.FamilyID {
+		t.Fatalf("rotated refresh token should be usable once, got %v, err %v", third, err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	useTestRedis(t)
+	s := NewOAuth2TokenService()
+
+	first, err := s.CreateAccessToken(ctx, 1, consts.UserTypeAdmin, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consumed, err := s.ConsumeRefreshToken(ctx, first.RefreshToken, consts.OAuth2ClientIDDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.RefreshAccessToken(ctx, consumed, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重放已使用的刷新令牌，吊销整个令牌族
+	reused, err := s.ConsumeRefreshToken(ctx, first.RefreshToken, consts.OAuth2ClientIDDefault)
+	if err != ErrRefreshTokenReused || reused == nil || reused.FamilyID != first.FamilyID {
+		t.Fatalf("reuse = (%v, %v), want ErrRefreshTokenReused with the original token", reused, err)
+	}
+	if token, _ := s.GetAccessToken(ctx, second.AccessToken); token != nil {
+		t.Error("access token of the revoked family should be removed")
+	}
+	if _, err := s.ConsumeRefreshToken(ctx, second.RefreshToken, consts.OAuth2ClientIDDefault); err != ErrRefreshTokenInvalid {
+		t.Errorf("refresh token of the revoked family: err = %v, want ErrRefreshTokenInvalid", err)
+	}
+}
+
+func TestConsumeRefreshTokenClientMismatch(t *testing.T) {
+	ctx := context.Background()
+	useTestRedis(t)
+	s := NewOAuth2TokenService()
+
+	token, err := s.CreateAccessToken(ctx, 1, consts.UserTypeAdmin, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ConsumeRefreshToken(ctx, token.RefreshToken, "other"); err != ErrOAuth2GrantClientIdMismatch {
+		t.Fatalf("err = %v, want ErrOAuth2GrantClientIdMismatch", err)
+	}
+	// 其他客户端提交后令牌未被消费，所属客户端仍可正常刷新
+	if _, err := s.ConsumeRefreshToken(ctx, token.RefreshToken, consts.OAuth2ClientIDDefault); err != nil {
+		t.Fatalf("owner client should still refresh, err = %v", err)
+	}
+}
+
+func TestRefreshImpersonateAccessTokenKeepsExpiry(t *testing.T) {
+	ctx := context.Background()
+	useTestRedis(t)
+	s := NewOAuth2TokenService()
+
+	token, err := s.CreateImpersonateAccessToken(ctx, 1, consts.UserTypeAdmin, 2, nil, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.ImpersonateExpiresTime == nil {
+		t.Fatal("impersonate expiry not set")
+	}
+	deadline := *token.ImpersonateExpiresTime
+
+	// 1. 刷新后沿用原截止时间，有效期不超过截止时间
+	consumed, err := s.ConsumeRefreshToken(ctx, token.RefreshToken, consts.OAuth2ClientIDDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := s.RefreshAccessToken(ctx, consumed, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.ImpersonatorID != 100 || refreshed.ImpersonateExpiresTime == nil || !refreshed.ImpersonateExpiresTime.Equal(deadline) {
+		t.Fatalf("refreshed impersonation = %d, %v, want 100, %v", refreshed.ImpersonatorID, refreshed.ImpersonateExpiresTime, deadline)
+	}
+	if refreshed.ExpiresTime.After(deadline) {
+		t.Errorf("access token expires at %v, after the impersonate deadline %v", refreshed.ExpiresTime, deadline)
+	}
+	consumed, err = s.ConsumeRefreshToken(ctx, refreshed.RefreshToken, consts.OAuth2ClientIDDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consumed.ExpiresTime.After(deadline) {
+		t.Errorf("refresh token expires at %v, after the impersonate deadline %v", consumed.ExpiresTime, deadline)
+	}
+
+	// 2. 超过截止时间后拒绝刷新
+	expired := deadline.Add(-3 * time.Hour)
+	consumed.ImpersonateExpiresTime = &expired
+	if _, err := s.RefreshAccessToken(ctx, consumed, nil, nil); err != ErrRefreshTokenInvalid {
+		t.Errorf("err = %v, want %v", err, ErrRefreshTokenInvalid)
+	}
+	consumed.ImpersonateExpiresTime = nil
+	if _, err := s.RefreshAccessToken(ctx, consumed, nil, nil); err != ErrRefreshTokenInvalid {
+		t.Errorf("missing expiry: err = %v, want %v", err, ErrRefreshTokenInvalid)
+	}
+}
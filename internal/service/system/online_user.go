@@ -0,0 +1,158 @@
+package system
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/websocket"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	bzErr "github.com/wxlbd/admin-go/pkg/errors"
+	"github.com/wxlbd/admin-go/pkg/pagination"
+)
+
+// WebSocketMessageTypeForceLogout 强制退出通知的 WebSocket 消息类型
+const WebSocketMessageTypeForceLogout = "force-logout"
+
+// ErrOnlineSessionNotExists 会话不存在或已下线
+var ErrOnlineSessionNotExists = bzErr.NewBizError(1_002_030_000, "会话不存在或已下线")
+
+// OnlineUserService 在线用户管理：基于访问令牌的在线会话索引，支持强制退出
+type OnlineUserService struct {
+	q           *query.Query
+	tokenSvc    *OAuth2TokenService
+	loginLogSvc *LoginLogService
+	wsManager   *websocket.Manager
+}
+
+func NewOnlineUserService(q *query.Query, tokenSvc *OAuth2TokenService, loginLogSvc *LoginLogService, wsManager *websocket.Manager) *OnlineUserService {
+	return &OnlineUserService{
+		q:           q,
+		tokenSvc:    tokenSvc,
+		loginLogSvc: loginLogSvc,
+		wsManager:   wsManager,
+	}
+}
+
+// GetOnlineUserPage 获得租户下的在线用户分页，按登录时间倒序
+func (s *OnlineUserService) GetOnlineUserPage(ctx context.Context, tenantId int64, req *system.OnlineUserPageReq) (*pagination.PageResult[*system.OnlineUserResp], error) {
+	sessions, err := s.tokenSvc.GetTenantSessions(ctx, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	usernames, err := s.getUsernames(ctx, sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*system.OnlineUserResp, 0, len(sessions))
+	for _, session := range sessions {
+		username := usernames[session.UserID]
+		if req.Username != "" && !strings.Contains(username, req.Username) {
+			continue
+		}
+		if req.UserIP != "" && !strings.Contains(session.UserIP, req.UserIP) {
+			continue
+		}
+		list = append(list, &system.OnlineUserResp{
+			ID:             session.ID,
+			UserID:         session.UserID,
+			UserType:       session.UserType,
+			Username:       username,
+			Nickname:       session.Nickname,
+			TenantID:       session.TenantID,
+			ClientID:       session.ClientID,
+			UserIP:         session.UserIP,
+			UserAgent:      session.UserAgent,
+			LoginTime:      time.UnixMilli(session.LoginTime),
+			LastActiveTime: time.UnixMilli(session.LastActiveTime),
+			ExpiresTime:    time.UnixMilli(session.ExpiresTime),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LoginTime.After(list[j].LoginTime) })
+
+	total := int64(len(list))
+	offset := req.GetOffset()
+	if offset >= len(list) {
+		return pagination.NewPageResult([]*system.OnlineUserResp{}, total), nil
+	}
+	return pagination.NewPageResult(list[offset:min(offset+req.GetLimit(), len(list))], total), nil
+}
+
+// ForceLogout 强制退出指定会话
+func (s *OnlineUserService) ForceLogout(ctx context.Context, tenantId int64, sessionId string) error {
+	session, err := s.tokenSvc.GetSession(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	// 只允许操作本租户的会话
+	if session == nil || session.TenantID != tenantId {
+		return ErrOnlineSessionNotExists
+	}
+	return s.forceLogout(ctx, []*OAuth2Session{session})
+}
+
+// ForceLogoutUser 强制退出后台用户的全部会话，返回退出的会话数
+func (s *OnlineUserService) ForceLogoutUser(ctx context.Context, tenantId, userId int64) (int, error) {
+	sessions, err := s.tokenSvc.GetUserSessions(ctx, consts.UserTypeAdmin, userId)
+	if err != nil {
+		return 0, err
+	}
+	sessions = lo.Filter(sessions, func(item *OAuth2Session, _ int) bool { return item.TenantID == tenantId })
+	if err := s.forceLogout(ctx, sessions); err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// forceLogout 删除会话令牌，记录强制退出日志，并通知对应的 WebSocket 连接后关闭
+func (s *OnlineUserService) forceLogout(ctx context.Context, sessions []*OAuth2Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	usernames, err := s.getUsernames(ctx, sessions)
+	if err != nil {
+		return err
+	}
+	var ip, userAgent string
+	if c := pkgContext.GetGinContext(ctx); c != nil {
+		ip, userAgent = c.ClientIP(), c.Request.UserAgent()
+	}
+
+	message, _ := (&websocket.Message{
+		Type:    WebSocketMessageTypeForceLogout,
+		Content: map[string]string{"reason": "您已被管理员强制退出"},
+	}).ToJSON()
+	for _, session := range sessions {
+		if err := s.tokenSvc.RemoveSession(ctx, session); err != nil {
+			return err
+		}
+		s.loginLogSvc.CreateLoginLog(ctx, session.UserID, session.UserType, usernames[session.UserID], ip, userAgent,
+			consts.LogoutLogTypeDelete, consts.LoginResultSuccess)
+		// 令牌刷新后连接仍持有旧令牌，按登录会话编号匹配，连接可能建立在其他实例上
+		s.wsManager.Kick(ctx, session.UserType, session.UserID, session.FamilyID, message)
+	}
+	return nil
+}
+
+// getUsernames 批量获取后台用户的用户名
+func (s *OnlineUserService) getUsernames(ctx context.Context, sessions []*OAuth2Session) (map[int64]string, error) {
+	userIds := lo.Uniq(lo.FilterMap(sessions, func(item *OAuth2Session, _ int) (int64, bool) {
+		return item.UserID, item.UserType == consts.UserTypeAdmin && item.UserID > 0
+	}))
+	if len(userIds) == 0 {
+		return map[int64]string{}, nil
+	}
+	u := s.q.SystemUser
+	users, err := u.WithContext(ctx).Where(u.ID.In(userIds...)).Find()
+	if err != nil {
+		return nil, err
+	}
+	return lo.SliceToMap(users, func(item *model.SystemUser) (int64, string) { return item.ID, item.Username }), nil
+}
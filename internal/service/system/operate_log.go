@@ -2,19 +2,45 @@ package system
 
 import (
 	"context"
+	"time"
 
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/asyncwriter"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 	"github.com/wxlbd/admin-go/pkg/pagination"
+
+	"go.uber.org/zap"
 )
 
 type OperateLogService struct {
-	q *query.Query
+	q      *query.Query
+	writer *asyncwriter.Writer[*model.SystemOperateLog]
+}
+
+func NewOperateLogService(q *query.Query, log *zap.Logger) *OperateLogService {
+	s := &OperateLogService{q: q}
+	s.writer = asyncwriter.New("operate-log", log, asyncwriter.Options{
+		QueueSize:     2048,
+		BatchSize:     50,
+		FlushInterval: time.Second,
+	}, s.createOperateLogs)
+	return s
+}
+
+// CreateOperateLogAsync 异步记录操作日志，队列满时丢弃，不影响请求耗时
+func (s *OperateLogService) CreateOperateLogAsync(log *model.SystemOperateLog) {
+	s.writer.Write(log)
+}
+
+// Close 停止接收新日志，并等待已入队的日志落库
+func (s *OperateLogService) Close(ctx context.Context) error {
+	return s.writer.Close(ctx)
 }
 
-func NewOperateLogService(q *query.Query) *OperateLogService {
-	return &OperateLogService{q: q}
+// createOperateLogs 批量写入操作日志
+func (s *OperateLogService) createOperateLogs(ctx context.Context, logs []*model.SystemOperateLog) error {
+	return s.q.SystemOperateLog.WithContext(ctx).CreateInBatches(logs, len(logs))
 }
 
 // GetOperateLogPage 获取操作日志分页
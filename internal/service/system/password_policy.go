@@ -0,0 +1,319 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/samber/lo"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	bzErr "github.com/wxlbd/admin-go/pkg/errors"
+	"github.com/wxlbd/admin-go/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ========== 密码策略参数配置键 ==========
+
+// 密码策略保存在参数配置（infra_config）中，未配置时使用默认值，默认策略与原有行为一致
+const (
+	PasswordConfigKeyMinLength       = "system.password.min-length"       // 最小长度，默认 6
+	PasswordConfigKeyCharTypes       = "system.password.char-types"       // 必须包含的字符类型，逗号分隔：lower,upper,digit,special
+	PasswordConfigKeyCheckUsername   = "system.password.check-username"   // 是否禁止包含用户名，true/false
+	PasswordConfigKeyCheckDictionary = "system.password.check-dictionary" // 是否禁止使用常见弱密码，true/false
+	PasswordConfigKeyHistoryCount    = "system.password.history-count"    // 禁止与最近 N 次密码相同，0 表示不限制
+	PasswordConfigKeyMaxAgeDays      = "system.password.max-age-days"     // 密码有效天数，过期后登录需修改密码，0 表示不过期
+
+	// PasswordConfigKeyInitPassword 创建用户未指定密码时使用的初始密码，对应 Java: system.user.init-password
+	PasswordConfigKeyInitPassword = "system.user.init-password"
+
+	defaultPasswordMinLength = 6
+	defaultInitPassword      = "123456"
+)
+
+// 字符类型
+const (
+	PasswordCharTypeLower   = "lower"
+	PasswordCharTypeUpper   = "upper"
+	PasswordCharTypeDigit   = "digit"
+	PasswordCharTypeSpecial = "special"
+)
+
+var passwordCharTypeNames = map[string]string{
+	PasswordCharTypeLower:   "小写字母",
+	PasswordCharTypeUpper:   "大写字母",
+	PasswordCharTypeDigit:   "数字",
+	PasswordCharTypeSpecial: "特殊字符",
+}
+
+// passwordDictionary 常见弱密码，比较时忽略大小写
+var passwordDictionary = lo.Keyify([]string{
+	"123456", "1234567", "12345678", "123456789", "1234567890", "654321", "111111", "000000",
+	"666666", "888888", "123123", "112233", "121212", "abc123", "abcd1234", "a123456",
+	"a12345678", "qwerty", "qwe123", "qwerty123", "qwertyuiop", "1q2w3e4r", "1qaz2wsx", "asdfgh",
+	"zxcvbnm", "password", "password1", "password123", "passw0rd", "p@ssw0rd", "p@ssword", "admin",
+	"admin123", "admin@123", "administrator", "root", "root123", "test123", "iloveyou", "welcome",
+	"letmein", "monkey", "dragon", "football", "baseball", "sunshine", "princess", "superman",
+})
+
+// ========== 错误码定义 ==========
+
+var (
+	ErrPasswordContainsUsername = bzErr.NewBizError(1_002_031_002, "密码不能包含用户名")
+	ErrPasswordTooCommon        = bzErr.NewBizError(1_002_031_003, "密码过于简单，请勿使用常见密码")
+	ErrPasswordOldError         = bzErr.NewBizError(1_002_031_005, "原密码不正确")
+)
+
+func newPasswordTooShortError(minLength int) *bzErr.BizError {
+	return bzErr.NewBizError(1_002_031_000, fmt.Sprintf("密码长度不能少于 %d 位", minLength))
+}
+
+func newPasswordCharTypeError(charTypes []string) *bzErr.BizError {
+	names := lo.Map(charTypes, func(item string, _ int) string { return passwordCharTypeNames[item] })
+	return bzErr.NewBizError(1_002_031_001, fmt.Sprintf("密码必须包含%s", strings.Join(names, "、")))
+}
+
+func newPasswordReusedError(historyCount int) *bzErr.BizError {
+	return bzErr.NewBizError(1_002_031_004, fmt.Sprintf("新密码不能与最近 %d 次使用的密码相同", historyCount))
+}
+
+// ========== 密码策略服务 ==========
+
+// PasswordPolicy 密码策略
+type PasswordPolicy struct {
+	MinLength       int
+	CharTypes       []string
+	CheckUsername   bool
+	CheckDictionary bool
+	HistoryCount    int
+	MaxAgeDays      int
+}
+
+// PasswordPolicyService 密码策略：强度校验、历史密码、密码有效期
+type PasswordPolicyService struct {
+	q *query.Query
+}
+
+func NewPasswordPolicyService(q *query.Query) *PasswordPolicyService {
+	return &PasswordPolicyService{q: q}
+}
+
+// GetPolicy 读取密码策略，未配置或配置有误的项使用默认值
+func (s *PasswordPolicyService) GetPolicy(ctx context.Context) (*PasswordPolicy, error) {
+	c := s.q.SystemConfig
+	list, err := c.WithContext(ctx).Where(c.ConfigKey.In(
+		PasswordConfigKeyMinLength,
+		PasswordConfigKeyCharTypes,
+		PasswordConfigKeyCheckUsername,
+		PasswordConfigKeyCheckDictionary,
+		PasswordConfigKeyHistoryCount,
+		PasswordConfigKeyMaxAgeDays,
+	)).Find()
+	if err != nil {
+		return nil, err
+	}
+	values := lo.SliceToMap(list, func(item *model.SystemConfig) (string, string) {
+		return item.ConfigKey, strings.TrimSpace(item.Value)
+	})
+
+	policy := &PasswordPolicy{
+		MinLength:       parsePasswordConfigInt(values, PasswordConfigKeyMinLength, defaultPasswordMinLength),
+		CheckUsername:   parsePasswordConfigBool(values, PasswordConfigKeyCheckUsername),
+		CheckDictionary: parsePasswordConfigBool(values, PasswordConfigKeyCheckDictionary),
+		HistoryCount:    parsePasswordConfigInt(values, PasswordConfigKeyHistoryCount, 0),
+		MaxAgeDays:      parsePasswordConfigInt(values, PasswordConfigKeyMaxAgeDays, 0),
+	}
+	for _, item := range strings.Split(values[PasswordConfigKeyCharTypes], ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if _, ok := passwordCharTypeNames[item]; ok && !lo.Contains(policy.CharTypes, item) {
+			policy.CharTypes = append(policy.CharTypes, item)
+		}
+	}
+	return policy, nil
+}
+
+// ValidatePassword 校验新密码是否符合策略；user 为空表示创建用户，不校验历史密码
+func (s *PasswordPolicyService) ValidatePassword(ctx context.Context, user *model.SystemUser, username, password string) error {
+	policy, err := s.GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if err := policy.CheckStrength(username, password); err != nil {
+		return err
+	}
+	if user == nil || policy.HistoryCount <= 0 {
+		return nil
+	}
+
+	// 当前密码计入最近使用的密码
+	if utils.CheckPasswordHash(password, user.Password) {
+		return newPasswordReusedError(policy.HistoryCount)
+	}
+	if policy.HistoryCount == 1 {
+		return nil
+	}
+	h := s.q.SystemUserPasswordHistory
+	histories, err := h.WithContext(ctx).Where(h.UserID.Eq(user.ID)).Order(h.ID.Desc()).Limit(policy.HistoryCount - 1).Find()
+	if err != nil {
+		return err
+	}
+	for _, history := range histories {
+		if utils.CheckPasswordHash(password, history.Password) {
+			return newPasswordReusedError(policy.HistoryCount)
+		}
+	}
+	return nil
+}
+
+// ChangePassword 校验并修改用户密码，旧密码记入历史
+func (s *PasswordPolicyService) ChangePassword(ctx context.Context, user *model.SystemUser, password string) error {
+	if err := s.ValidatePassword(ctx, user, user.Username, password); err != nil {
+		return err
+	}
+	hashedPwd, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.q.Transaction(func(tx *query.Query) error {
+		u := tx.SystemUser
+		if _, err := u.WithContext(ctx).Where(u.ID.Eq(user.ID)).UpdateSimple(
+			u.Password.Value(hashedPwd),
+			u.PasswordUpdateTime.Value(now),
+			u.PasswordChangeRequired.Value(false),
+		); err != nil {
+			return err
+		}
+		history := &model.SystemUserPasswordHistory{
+			UserID:   user.ID,
+			Password: user.Password,
+		}
+		history.TenantID = user.TenantID
+		return tx.SystemUserPasswordHistory.WithContext(ctx).Create(history)
+	})
+}
+
+// GetInitPassword 读取初始密码，未配置时使用默认值
+// 初始密码由管理员统一下发，不校验密码策略，使用初始密码的用户首次登录需修改密码
+func (s *PasswordPolicyService) GetInitPassword(ctx context.Context) (string, error) {
+	c := s.q.SystemConfig
+	config, err := c.WithContext(ctx).Where(c.ConfigKey.Eq(PasswordConfigKeyInitPassword)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultInitPassword, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if config.Value == "" {
+		return defaultInitPassword, nil
+	}
+	return config.Value, nil
+}
+
+// IsPasswordChangeRequired 是否需要修改密码：仍在使用初始密码或密码超过有效期，登录时据此提示用户修改密码
+func (s *PasswordPolicyService) IsPasswordChangeRequired(ctx context.Context, user *model.SystemUser) bool {
+	if user.PasswordChangeRequired {
+		return true
+	}
+	policy, err := s.GetPolicy(ctx)
+	if err != nil {
+		zap.L().Warn("Failed to get password policy", zap.Error(err))
+		return false
+	}
+	return policy.IsExpired(user, time.Now())
+}
+
+// CheckStrength 校验密码强度：长度、字符类型、用户名、常见弱密码
+func (p *PasswordPolicy) CheckStrength(username, password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return newPasswordTooShortError(p.MinLength)
+	}
+	if len(p.CharTypes) > 0 {
+		present := passwordCharTypes(password)
+		if !lo.Every(present, p.CharTypes) {
+			return newPasswordCharTypeError(p.CharTypes)
+		}
+	}
+	lower := strings.ToLower(password)
+	if p.CheckUsername && len(username) >= 3 {
+		name := strings.ToLower(username)
+		if strings.Contains(lower, name) || strings.Contains(lower, reverseString(name)) {
+			return ErrPasswordContainsUsername
+		}
+	}
+	if p.CheckDictionary {
+		if _, ok := passwordDictionary[lower]; ok {
+			return ErrPasswordTooCommon
+		}
+	}
+	return nil
+}
+
+// IsExpired 密码是否超过有效期，未记录修改时间的以创建时间计算
+func (p *PasswordPolicy) IsExpired(user *model.SystemUser, now time.Time) bool {
+	if p.MaxAgeDays <= 0 {
+		return false
+	}
+	updateTime := user.CreateTime
+	if user.PasswordUpdateTime != nil {
+		updateTime = *user.PasswordUpdateTime
+	}
+	if updateTime.IsZero() {
+		return false
+	}
+	return now.Sub(updateTime) > time.Duration(p.MaxAgeDays)*24*time.Hour
+}
+
+// passwordCharTypes 密码包含的字符类型
+func passwordCharTypes(password string) []string {
+	var types []string
+	for _, r := range password {
+		var t string
+		switch {
+		case unicode.IsLower(r):
+			t = PasswordCharTypeLower
+		case unicode.IsUpper(r):
+			t = PasswordCharTypeUpper
+		case unicode.IsDigit(r):
+			t = PasswordCharTypeDigit
+		default:
+			t = PasswordCharTypeSpecial
+		}
+		if !lo.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func parsePasswordConfigInt(values map[string]string, key string, defaultValue int) int {
+	value, ok := values[key]
+	if !ok || value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		zap.L().Warn("Invalid password policy config", zap.String("key", key), zap.String("value", value))
+		return defaultValue
+	}
+	return n
+}
+
+func parsePasswordConfigBool(values map[string]string, key string) bool {
+	b, _ := strconv.ParseBool(values[key])
+	return b
+}
@@ -0,0 +1,60 @@
+package system
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/model"
+)
+
+func TestPasswordPolicyCheckStrength(t *testing.T) {
+	policy := &PasswordPolicy{
+		MinLength:       8,
+		CharTypes:       []string{PasswordCharTypeLower, PasswordCharTypeUpper, PasswordCharTypeDigit},
+		CheckUsername:   true,
+		CheckDictionary: true,
+	}
+	cases := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"Ab1", true},          // 长度不足
+		{"abcdefgh1", true},    // 缺少大写字母
+		{"Zhangsan123", true},  // 包含用户名
+		{"Nasgnahz123", true},  // 包含倒序用户名
+		{"Tr0ub4dor&3", false}, // 符合策略
+	}
+	for _, c := range cases {
+		if err := policy.CheckStrength("zhangsan", c.password); (err != nil) != c.wantErr {
+			t.Errorf("CheckStrength(%q) err = %v, wantErr %v", c.password, err, c.wantErr)
+		}
+	}
+
+	dictionary := &PasswordPolicy{MinLength: 6, CheckDictionary: true}
+	if err := dictionary.CheckStrength("admin", "Password123"); err != ErrPasswordTooCommon {
+		t.Errorf("common password should be rejected, got %v", err)
+	}
+	if err := (&PasswordPolicy{MinLength: defaultPasswordMinLength}).CheckStrength("admin", "123456"); err != nil {
+		t.Errorf("default policy should accept existing passwords, got %v", err)
+	}
+}
+
+func TestPasswordPolicyIsExpired(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-91 * 24 * time.Hour)
+	user := &model.SystemUser{PasswordUpdateTime: &old}
+	user.CreateTime = now
+
+	if (&PasswordPolicy{}).IsExpired(user, now) {
+		t.Fatal("password should never expire without max age")
+	}
+	if !(&PasswordPolicy{MaxAgeDays: 90}).IsExpired(user, now) {
+		t.Fatal("password older than max age should expire")
+	}
+
+	// 未记录修改时间时以创建时间计算
+	user.PasswordUpdateTime = nil
+	if (&PasswordPolicy{MaxAgeDays: 90}).IsExpired(user, now) {
+		t.Fatal("new user password should not expire")
+	}
+}
@@ -5,18 +5,24 @@ import (
 
 	"github.com/samber/lo"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
+	"github.com/wxlbd/admin-go/internal/pkg/permission"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 )
 
 type PermissionService struct {
-	q       *query.Query
-	roleSvc *RoleService
+	q            *query.Query
+	roleSvc      *RoleService
+	scopeCache   *datascope.Cache
+	policySyncer *permission.PolicySyncer
 }
 
-func NewPermissionService(q *query.Query, roleSvc *RoleService) *PermissionService {
+func NewPermissionService(q *query.Query, roleSvc *RoleService, scopeCache *datascope.Cache, policySyncer *permission.PolicySyncer) *PermissionService {
 	return &PermissionService{
-		q:       q,
-		roleSvc: roleSvc,
+		q:            q,
+		roleSvc:      roleSvc,
+		scopeCache:   scopeCache,
+		policySyncer: policySyncer,
 	}
 }
 
@@ -75,7 +81,7 @@ func (s *PermissionService) getAllMenuIds(ctx context.Context) ([]int64, error)
 // AssignRoleMenu 赋予角色菜单
 func (s *PermissionService) AssignRoleMenu(ctx context.Context, roleId int64, menuIds []int64) error {
 	// 使用事务
-	return s.q.Transaction(func(tx *query.Query) error {
+	err := s.q.Transaction(func(tx *query.Query) error {
 		// 1. 删除旧的角色菜单关联
 		rm := tx.SystemRoleMenu
 		if _, err := rm.WithContext(ctx).Where(rm.RoleID.Eq(roleId)).Delete(); err != nil {
@@ -98,16 +104,22 @@ func (s *PermissionService) AssignRoleMenu(ctx context.Context, roleId int64, me
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	s.policySyncer.RefreshRoles(ctx, roleId)
+	return nil
 }
 
 // AssignRoleDataScope 赋予角色数据权限
-func (s *PermissionService) AssignRoleDataScope(ctx context.Context, roleId int64, dataScope int, deptIds []int64) error {
-	return s.roleSvc.UpdateRoleDataScope(ctx, roleId, dataScope, deptIds)
+// config 为数据范围的拓展配置，如「本部门及以下 N 级」的层级深度
+func (s *PermissionService) AssignRoleDataScope(ctx context.Context, roleId int64, dataScope int, deptIds []int64, config model.RoleDataScopeConfig) error {
+	return s.roleSvc.UpdateRoleDataScope(ctx, roleId, dataScope, deptIds, config)
 }
 
 // AssignUserRole 赋予用户角色
 func (s *PermissionService) AssignUserRole(ctx context.Context, userId int64, roleIds []int64) error {
-	return s.q.Transaction(func(tx *query.Query) error {
+	err := s.q.Transaction(func(tx *query.Query) error {
 		ur := tx.SystemUserRole
 		// 1. 删除旧的用户角色关联
 		if _, err := ur.WithContext(ctx).Where(ur.UserID.Eq(userId)).Delete(); err != nil {
@@ -129,6 +141,12 @@ func (s *PermissionService) AssignUserRole(ctx context.Context, userId int64, ro
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	s.scopeCache.InvalidateUser(ctx, userId)
+	s.policySyncer.RefreshUsers(ctx, userId)
+	return nil
 }
 
 // IsSuperAdmin 检查用户是否为超级管理员
@@ -9,17 +9,23 @@ import (
 
 	"github.com/wxlbd/admin-go/internal/consts"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
+	"github.com/wxlbd/admin-go/internal/pkg/permission"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 	"github.com/wxlbd/admin-go/pkg/pagination"
 )
 
 type RoleService struct {
-	q *query.Query
+	q            *query.Query
+	scopeCache   *datascope.Cache
+	policySyncer *permission.PolicySyncer
 }
 
-func NewRoleService(q *query.Query) *RoleService {
+func NewRoleService(q *query.Query, scopeCache *datascope.Cache, policySyncer *permission.PolicySyncer) *RoleService {
 	return &RoleService{
-		q: q,
+		q:            q,
+		scopeCache:   scopeCache,
+		policySyncer: policySyncer,
 	}
 }
 
@@ -67,7 +73,12 @@ func (s *RoleService) UpdateRole(ctx context.Context, req *system.RoleSaveReq) e
 		Status: int32(*req.Status),
 		Remark: req.Remark,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	// 角色编码、状态影响数据范围
+	s.scopeCache.InvalidateAll(ctx)
+	return nil
 }
 
 // UpdateRoleStatus 更新角色状态
@@ -80,23 +91,35 @@ func (s *RoleService) UpdateRoleStatus(ctx context.Context, req *system.RoleUpda
 	if role.Type == consts.RoleTypeSystem {
 		return errors.New("内置角色不能修改状态")
 	}
-	_, err = r.WithContext(ctx).Where(r.ID.Eq(req.ID)).Update(r.Status, *req.Status)
-	return err
+	if _, err = r.WithContext(ctx).Where(r.ID.Eq(req.ID)).Update(r.Status, *req.Status); err != nil {
+		return err
+	}
+	s.scopeCache.InvalidateAll(ctx)
+	return nil
 }
 
 // UpdateRoleDataScope 更新数据权限
-func (s *RoleService) UpdateRoleDataScope(ctx context.Context, roleId int64, dataScope int, deptIds []int64) error {
+func (s *RoleService) UpdateRoleDataScope(ctx context.Context, roleId int64, dataScope int, deptIds []int64, config model.RoleDataScopeConfig) error {
+	if dataScope == consts.DataScopeDeptAndChildDepth && config.DeptDepth <= 0 {
+		return errors.New("部门层级深度必须大于 0")
+	}
 	r := s.q.SystemRole
 	_, err := r.WithContext(ctx).Where(r.ID.Eq(roleId)).First()
 	if err != nil {
 		return errors.New("角色不存在")
 	}
 
-	_, err = r.WithContext(ctx).Where(r.ID.Eq(roleId)).Updates(&model.SystemRole{
+	// 指定更新的字段，清空指定部门、层级深度为 0 时同样写入
+	_, err = r.WithContext(ctx).Where(r.ID.Eq(roleId)).Select(r.DataScope, r.DataScopeDeptIds, r.DataScopeConfig).Updates(&model.SystemRole{
 		DataScope:        int32(dataScope),
 		DataScopeDeptIds: model.Int64ListFromCSV(deptIds), // Handled by serializer:json
+		DataScopeConfig:  config,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	s.scopeCache.InvalidateAll(ctx)
+	return nil
 }
 
 // DeleteRole 删除角色
@@ -117,8 +140,11 @@ func (s *RoleService) DeleteRole(ctx context.Context, id int64) error {
 	if userRoleCount > 0 {
 		return errors.New("角色已分配给用户，无法删除")
 	}
-	_, err = r.WithContext(ctx).Where(r.ID.Eq(id)).Delete()
-	return err
+	if _, err = r.WithContext(ctx).Where(r.ID.Eq(id)).Delete(); err != nil {
+		return err
+	}
+	s.policySyncer.RefreshRoles(ctx, id)
+	return nil
 }
 
 // GetRole 获得角色
@@ -229,6 +255,7 @@ func (s *RoleService) convertResp(item *model.SystemRole) *system.RoleRespVO {
 		Remark:           item.Remark,
 		DataScope:        item.DataScope,
 		DataScopeDeptIDs: []int64(item.DataScopeDeptIds),
+		DeptDepth:        item.DataScopeConfig.DeptDepth,
 		CreateTime:       item.CreateTime,
 	}
 }
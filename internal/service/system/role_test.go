@@ -0,0 +1,39 @@
+package system
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
+	"go.uber.org/zap"
+)
+
+func TestUpdateRoleDataScopeWritesZeroValues(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQuery(t, &model.SystemRole{})
+	s := NewRoleService(q, datascope.NewCache(nil, zap.NewNop()), nil)
+	role := &model.SystemRole{
+		Name:             "部门",
+		Code:             "dept",
+		DataScope:        consts.DataScopeDeptAndChildDepth,
+		DataScopeDeptIds: model.Int64ListFromCSV{1, 2},
+		DataScopeConfig:  model.RoleDataScopeConfig{DeptDepth: 2},
+	}
+	if err := q.SystemRole.WithContext(ctx).Create(role); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.UpdateRoleDataScope(ctx, role.ID, consts.DataScopeDeptOnly, nil, model.RoleDataScopeConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := q.SystemRole.WithContext(ctx).Where(q.SystemRole.ID.Eq(role.ID)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.DataScope != consts.DataScopeDeptOnly || len(updated.DataScopeDeptIds) != 0 || updated.DataScopeConfig.DeptDepth != 0 {
+		t.Errorf("data scope = %d, dept ids = %v, depth = %d, want cleared",
+			updated.DataScope, updated.DataScopeDeptIds, updated.DataScopeConfig.DeptDepth)
+	}
+}
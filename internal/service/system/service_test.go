@@ -0,0 +1,58 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"github.com/wxlbd/admin-go/pkg/cache"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestRedis 创建基于 miniredis 的 Redis 客户端
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return mr, rdb
+}
+
+// useTestRedis 将全局 Redis 客户端替换为 miniredis，令牌服务等直接使用全局客户端
+func useTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr, rdb := newTestRedis(t)
+	origin := cache.RDB
+	cache.RDB = rdb
+	t.Cleanup(func() { cache.RDB = origin })
+	return mr, rdb
+}
+
+// newTestDB 创建内存 SQLite 数据库并建表
+func newTestDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接相互独立，只使用一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestQuery 创建基于内存 SQLite 的查询对象
+func newTestQuery(t *testing.T, models ...any) *query.Query {
+	t.Helper()
+	return query.Use(newTestDB(t, models...))
+}
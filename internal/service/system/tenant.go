@@ -2,30 +2,55 @@ package system
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/samber/lo"
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
 	"github.com/wxlbd/admin-go/internal/consts"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/permission"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 	pkgContext "github.com/wxlbd/admin-go/pkg/context"
 	"github.com/wxlbd/admin-go/pkg/pagination"
 	"github.com/wxlbd/admin-go/pkg/utils"
+	"go.uber.org/zap"
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// DefaultTenantID 未指定租户时使用的默认租户（系统租户）
+const DefaultTenantID int64 = 1
+
+// RedisKeyTenantWebsite 域名绑定的租户缓存，格式：tenant:website:{website}，未绑定时缓存空值
+const RedisKeyTenantWebsite = "tenant:website:%s"
+
+// tenantWebsiteCacheTTL 域名租户缓存时长，租户新增、修改、删除时主动清除
+const tenantWebsiteCacheTTL = 5 * time.Minute
+
 type TenantService struct {
-	q             *query.Query
-	roleSvc       *RoleService
-	permissionSvc *PermissionService
+	q                 *query.Query
+	rdb               *redis.Client
+	roleSvc           *RoleService
+	permissionSvc     *PermissionService
+	passwordPolicySvc *PasswordPolicyService
+	policySyncer      *permission.PolicySyncer
 }
 
-func NewTenantService(q *query.Query, roleSvc *RoleService, permissionSvc *PermissionService) *TenantService {
+func NewTenantService(q *query.Query, rdb *redis.Client, roleSvc *RoleService, permissionSvc *PermissionService, passwordPolicySvc *PasswordPolicyService, policySyncer *permission.PolicySyncer) *TenantService {
 	return &TenantService{
-		q:             q,
-		roleSvc:       roleSvc,
-		permissionSvc: permissionSvc,
+		q:                 q,
+		rdb:               rdb,
+		roleSvc:           roleSvc,
+		permissionSvc:     permissionSvc,
+		passwordPolicySvc: passwordPolicySvc,
+		policySyncer:      policySyncer,
 	}
 }
 
@@ -47,10 +72,22 @@ func (s *TenantService) CreateTenant(ctx context.Context, req *system.TenantCrea
 		return 0, errors.New("租户套餐不存在或已禁用")
 	}
 
-	// 4. 事务执行
+	// 4. 校验管理员密码是否符合密码策略
+	if err := s.passwordPolicySvc.ValidatePassword(ctx, nil, req.Username, req.Password); err != nil {
+		return 0, err
+	}
+
+	// 5. 获得模板租户，新租户复制其初始数据
+	templateTenantId, err := s.getTemplateTenantID(ctx, req.TemplateTenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	// 6. 事务执行
+	defer s.evictWebsiteCache(ctx, req.Websites)
 	var tenantId int64
-	err := s.q.Transaction(func(tx *query.Query) error {
-		// 4.1 创建租户
+	err = s.q.Transaction(func(tx *query.Query) error {
+		// 6.1 创建租户
 		tenant := &model.SystemTenant{
 			Name:          req.Name,
 			ContactName:   req.ContactName,
@@ -66,7 +103,10 @@ func (s *TenantService) CreateTenant(ctx context.Context, req *system.TenantCrea
 		}
 		tenantId = tenant.ID
 
-		// 4.2 创建角色
+		// 租户下的数据归属新租户，而非当前登录用户的租户
+		tenantCtx := pkgContext.WithTenantID(ctx, tenantId)
+
+		// 6.2 创建角色
 		role := &model.SystemRole{
 			Name:             "租户管理员",
 			Code:             consts.RoleCodeTenantAdmin,
@@ -78,38 +118,40 @@ func (s *TenantService) CreateTenant(ctx context.Context, req *system.TenantCrea
 			Remark:           "系统自动生成",
 		}
 		role.TenantID = tenantId
-		if err := tx.SystemRole.WithContext(ctx).Create(role); err != nil {
+		if err := tx.SystemRole.WithContext(tenantCtx).Create(role); err != nil {
 			return err
 		}
 
-		// 4.3 创建用户
+		// 6.3 创建用户
 		hashedPwd, err := utils.HashPassword(req.Password)
 		if err != nil {
 			return err
 		}
+		now := time.Now()
 		user := &model.SystemUser{
-			Username: req.Username,
-			Password: hashedPwd,
-			Nickname: req.ContactName,
-			Mobile:   req.ContactMobile,
-			Status:   consts.CommonStatusEnable,
+			Username:           req.Username,
+			Password:           hashedPwd,
+			Nickname:           req.ContactName,
+			Mobile:             req.ContactMobile,
+			Status:             consts.CommonStatusEnable,
+			PasswordUpdateTime: &now,
 		}
 		user.TenantID = tenantId
-		if err := tx.SystemUser.WithContext(ctx).Create(user); err != nil {
+		if err := tx.SystemUser.WithContext(tenantCtx).Create(user); err != nil {
 			return err
 		}
 
-		// 4.4 关联用户与角色
+		// 6.4 关联用户与角色
 		userRole := &model.SystemUserRole{
 			UserID: user.ID,
 			RoleID: role.ID,
 		}
 		userRole.TenantID = tenantId
-		if err := tx.SystemUserRole.WithContext(ctx).Create(userRole); err != nil {
+		if err := tx.SystemUserRole.WithContext(tenantCtx).Create(userRole); err != nil {
 			return err
 		}
 
-		// 4.5 赋予角色菜单权限
+		// 6.5 赋予角色菜单权限
 		menuIds := pkg.MenuIDs
 		if len(menuIds) > 0 {
 			roleMenus := make([]*model.SystemRoleMenu, len(menuIds))
@@ -120,20 +162,31 @@ func (s *TenantService) CreateTenant(ctx context.Context, req *system.TenantCrea
 				}
 				roleMenus[i].TenantID = tenantId
 			}
-			if err := tx.SystemRoleMenu.WithContext(ctx).Create(roleMenus...); err != nil {
+			if err := tx.SystemRoleMenu.WithContext(tenantCtx).Create(roleMenus...); err != nil {
 				return err
 			}
 		}
 
-		// 4.6 更新租户的联系人用户ID
+		// 6.6 复制模板租户的部门、岗位、角色、通知公告
+		if templateTenantId > 0 {
+			if _, err := s.cloneTenantData(ctx, tx, templateTenantId, tenantId, menuIds); err != nil {
+				return err
+			}
+		}
+
+		// 6.7 更新租户的联系人用户ID
 		if _, err := tx.SystemTenant.WithContext(ctx).Where(tx.SystemTenant.ID.Eq(tenantId)).Update(tx.SystemTenant.ContactUserID, user.ID); err != nil {
 			return err
 		}
 
 		return nil
 	})
-
-	return tenantId, err
+	if err != nil {
+		return 0, err
+	}
+	// 新租户的角色、用户角色批量写入，全量加载权限策略
+	s.policySyncer.Reload(ctx)
+	return tenantId, nil
 }
 
 // UpdateTenant 更新租户
@@ -162,6 +215,7 @@ func (s *TenantService) UpdateTenant(ctx context.Context, req *system.TenantUpda
 	}
 
 	// 4. 更新
+	defer s.evictWebsiteCache(ctx, append(tenant.Websites, req.Websites...))
 	t := s.q.SystemTenant
 	tenantObj := &model.SystemTenant{
 		Name:          req.Name,
@@ -189,6 +243,7 @@ func (s *TenantService) UpdateTenant(ctx context.Context, req *system.TenantUpda
 		if err := s.updateTenantRoleMenu(ctx, req.ID, menuIds); err != nil {
 			return err
 		}
+		s.policySyncer.Reload(ctx)
 	}
 
 	return nil
@@ -197,22 +252,28 @@ func (s *TenantService) UpdateTenant(ctx context.Context, req *system.TenantUpda
 // DeleteTenant 删除租户
 func (s *TenantService) DeleteTenant(ctx context.Context, id int64) error {
 	// 校验存在及系统租户保护
-	if _, err := s.validateUpdateTenant(ctx, id); err != nil {
+	tenant, err := s.validateUpdateTenant(ctx, id)
+	if err != nil {
 		return err
 	}
+	defer s.evictWebsiteCache(ctx, tenant.Websites)
 
 	t := s.q.SystemTenant
-	_, err := t.WithContext(ctx).Where(t.ID.Eq(id)).Delete()
+	_, err = t.WithContext(ctx).Where(t.ID.Eq(id)).Delete()
 	return err
 }
 
 // DeleteTenantList 批量删除租户 (对齐 Java: deleteTenantList)
 func (s *TenantService) DeleteTenantList(ctx context.Context, ids []int64) error {
+	var websites []string
 	for _, id := range ids {
-		if _, err := s.validateUpdateTenant(ctx, id); err != nil {
+		tenant, err := s.validateUpdateTenant(ctx, id)
+		if err != nil {
 			return err
 		}
+		websites = append(websites, tenant.Websites...)
 	}
+	defer s.evictWebsiteCache(ctx, websites)
 	t := s.q.SystemTenant
 	_, err := t.WithContext(ctx).Where(t.ID.In(ids...)).Delete()
 	return err
@@ -377,6 +438,99 @@ func (s *TenantService) ValidTenant(ctx context.Context, id int64) error {
 	return nil
 }
 
+// requestTenantID 当前请求的租户，由租户中间件从请求头、域名或令牌中解析；未解析到时使用默认租户
+func requestTenantID(ctx context.Context) int64 {
+	if tenantId := pkgContext.GetTenantIDFromContext(ctx); tenantId > 0 {
+		return tenantId
+	}
+	return DefaultTenantID
+}
+
+// withRequestTenant 将请求租户固定到 ctx，未解析到租户的公开接口（如登录）使用默认租户
+// 租户插件拒绝未指定租户的查询，公开接口访问租户数据前须先调用
+func withRequestTenant(ctx context.Context) context.Context {
+	return pkgContext.WithTenantID(ctx, requestTenantID(ctx))
+}
+
+// ValidTenantAccountQuota 校验租户账号配额，系统租户不限制
+// 须在创建用户的事务中调用，锁定租户记录，使并发创建用户串行执行，避免超出配额
+func (s *TenantService) ValidTenantAccountQuota(ctx context.Context, tx *query.Query, tenantId int64) error {
+	t := tx.SystemTenant
+	tenant, err := t.WithContext(ctx).Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).Where(t.ID.Eq(tenantId)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("租户不存在")
+	}
+	if err != nil {
+		return err
+	}
+	if s.isSystemTenant(tenant) {
+		return nil
+	}
+	u := tx.SystemUser
+	count, err := u.WithContext(pkgContext.WithTenantID(ctx, tenantId)).Where(u.TenantID.Eq(tenantId)).Count()
+	if err != nil {
+		return err
+	}
+	if count >= int64(tenant.AccountCount) {
+		return fmt.Errorf("创建用户失败，原因：超过租户最大账号配额(%d)", tenant.AccountCount)
+	}
+	return nil
+}
+
+// GetTenantUsage 获得租户资源使用情况
+// 文件与短信日志不区分租户，按上传者、接收用户所属的租户统计
+func (s *TenantService) GetTenantUsage(ctx context.Context, id int64) (*system.TenantUsageResp, error) {
+	t := s.q.SystemTenant
+	tenant, err := t.WithContext(ctx).Where(t.ID.Eq(id)).First()
+	if err != nil {
+		return nil, errors.New("租户不存在")
+	}
+	ctx = pkgContext.WithTenantID(ctx, id)
+
+	// 1. 账号数
+	u := s.q.SystemUser
+	userCount, err := u.WithContext(ctx).Where(u.TenantID.Eq(id)).Count()
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 文件数与占用空间
+	var file struct {
+		Count int64
+		Size  int64
+	}
+	if err := s.q.InfraFile.WithContext(ctx).UnderlyingDB().
+		Select("COUNT(*) AS count, COALESCE(SUM(infra_file.size), 0) AS size").
+		Joins("JOIN system_users ON system_users.id = infra_file.creator").
+		Where("system_users.tenant_id = ?", id).
+		Scan(&file).Error; err != nil {
+		return nil, err
+	}
+
+	// 3. 本月发送成功的短信数
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	l := s.q.SystemSmsLog
+	smsCount, err := l.WithContext(ctx).Where(
+		l.UserType.Eq(consts.UserTypeAdmin),
+		l.SendStatus.Eq(consts.SmsSendStatusSuccess),
+		l.CreateTime.Gte(monthStart),
+		l.Columns(l.UserId).In(u.WithContext(ctx).Select(u.ID).Where(u.TenantID.Eq(id))),
+	).Count()
+	if err != nil {
+		return nil, err
+	}
+
+	return &system.TenantUsageResp{
+		TenantID:     id,
+		AccountCount: int(tenant.AccountCount),
+		UserCount:    userCount,
+		FileCount:    file.Count,
+		FileSize:     file.Size,
+		SmsCount:     smsCount,
+	}, nil
+}
+
 // GetTenantSimpleList 获取启用状态的租户精简列表
 func (s *TenantService) GetTenantSimpleList(ctx context.Context) ([]system.TenantSimpleResp, error) {
 	tenantRepo := s.q.SystemTenant
@@ -395,26 +549,77 @@ func (s *TenantService) GetTenantSimpleList(ctx context.Context) ([]system.Tenan
 	return result, nil
 }
 
-// GetTenantByWebsite 根据域名查询租户
+// GetTenantByWebsite 根据域名查询租户，未绑定时返回 nil
+// 查询结果缓存到 Redis，Redis 不可用时直接查询数据库
 func (s *TenantService) GetTenantByWebsite(ctx context.Context, website string) (*system.TenantSimpleResp, error) {
-	tenantRepo := s.q.SystemTenant
-	list, err := tenantRepo.WithContext(ctx).Where(tenantRepo.Status.Eq(0)).Find()
-	if err != nil {
+	if website == "" {
 		return nil, nil
 	}
-
-	// 应用层显式匹配
-	for _, t := range list {
-		for _, w := range t.Websites {
-			if w == website {
-				return &system.TenantSimpleResp{
-					ID:   t.ID,
-					Name: t.Name,
-				}, nil
+	key := fmt.Sprintf(RedisKeyTenantWebsite, website)
+	if s.rdb != nil {
+		data, err := s.rdb.Get(ctx, key).Result()
+		if err == nil {
+			if data == "" {
+				return nil, nil
 			}
+			var resp system.TenantSimpleResp
+			if err := json.Unmarshal([]byte(data), &resp); err == nil {
+				return &resp, nil
+			}
+		} else if err != redis.Nil {
+			zap.L().Warn("Failed to get tenant website cache", zap.String("website", website), zap.Error(err))
 		}
 	}
-	return nil, nil
+
+	list, err := s.findTenantsByWebsite(ctx, website, s.q.SystemTenant.Status.Eq(consts.CommonStatusEnable))
+	if err != nil {
+		return nil, err
+	}
+	var resp *system.TenantSimpleResp
+	data := ""
+	if len(list) > 0 {
+		resp = &system.TenantSimpleResp{ID: list[0].ID, Name: list[0].Name}
+		bytes, _ := json.Marshal(resp)
+		data = string(bytes)
+	}
+	if s.rdb != nil {
+		if err := s.rdb.Set(ctx, key, data, tenantWebsiteCacheTTL).Err(); err != nil {
+			zap.L().Warn("Failed to set tenant website cache", zap.String("website", website), zap.Error(err))
+		}
+	}
+	return resp, nil
+}
+
+// findTenantsByWebsite 查询绑定了指定域名的租户
+// 域名以逗号分隔存储，先按 LIKE 缩小范围，再精确匹配
+func (s *TenantService) findTenantsByWebsite(ctx context.Context, website string, conds ...gen.Condition) ([]*model.SystemTenant, error) {
+	t := s.q.SystemTenant
+	column := field.NewString(t.TableName(), "website")
+	list, err := t.WithContext(ctx).Where(conds...).Where(field.Or(
+		column.Eq(website),
+		column.Like(website+",%"),
+		column.Like("%,"+website),
+		column.Like("%,"+website+",%"),
+	)).Find()
+	if err != nil {
+		return nil, err
+	}
+	return lo.Filter(list, func(tenant *model.SystemTenant, _ int) bool {
+		return lo.Contains(tenant.Websites, website)
+	}), nil
+}
+
+// evictWebsiteCache 清除域名租户缓存
+func (s *TenantService) evictWebsiteCache(ctx context.Context, websites []string) {
+	if s.rdb == nil || len(websites) == 0 {
+		return
+	}
+	keys := lo.Map(lo.Uniq(websites), func(website string, _ int) string {
+		return fmt.Sprintf(RedisKeyTenantWebsite, website)
+	})
+	if err := s.rdb.Del(ctx, keys...).Err(); err != nil {
+		zap.L().Warn("Failed to evict tenant website cache", zap.Strings("websites", websites), zap.Error(err))
+	}
 }
 
 // isSystemTenant 判断是否为系统租户 (PackageID=0) - 对齐 Java: isSystemTenant
@@ -441,12 +646,8 @@ func (s *TenantService) validTenantWebsiteDuplicate(ctx context.Context, website
 	if len(websites) == 0 {
 		return nil
 	}
-	t := s.q.SystemTenant
 	for _, website := range websites {
-		// 这里简单处理：由于 websites 是 JSON 数组，无法直接在 DB 层高效 like 精确匹配（除非用 JSON 函数）
-		// 我们先查出所有数据（通常租户不多）或者优化查询
-		// TODO: 生产环境建议使用数据库 JSON 函数或 ES 索引
-		list, err := t.WithContext(ctx).Find()
+		list, err := s.findTenantsByWebsite(ctx, website)
 		if err != nil {
 			return err
 		}
@@ -454,11 +655,7 @@ func (s *TenantService) validTenantWebsiteDuplicate(ctx context.Context, website
 			if excludeId > 0 && tenant.ID == excludeId {
 				continue
 			}
-			for _, w := range tenant.Websites {
-				if w == website {
-					return errors.New("域名 [" + website + "] 已被其他租户绑定")
-				}
-			}
+			return errors.New("域名 [" + website + "] 已被其他租户绑定")
 		}
 	}
 	return nil
@@ -530,7 +727,8 @@ func (s *TenantService) HandleTenantMenu(ctx context.Context, handler func(allow
 
 // updateTenantRoleMenu 更新租户下所有角色的菜单权限
 func (s *TenantService) updateTenantRoleMenu(ctx context.Context, tenantId int64, menuIds []int64) error {
-	// 通过 Q 的 Where 条件保证租户隔离
+	// 切换到目标租户，角色及其菜单的读写都限定在该租户内
+	ctx = pkgContext.WithTenantID(ctx, tenantId)
 	r := s.q.SystemRole
 	roles, err := r.WithContext(ctx).Where(r.TenantID.Eq(tenantId)).Find()
 	if err != nil {
@@ -0,0 +1,195 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	pkgTenant "github.com/wxlbd/admin-go/internal/pkg/tenant"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"github.com/wxlbd/admin-go/internal/service/infra"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	"go.uber.org/zap"
+	"gorm.io/gen"
+)
+
+const (
+	// TenantExpireJobHandlerName 租户到期处理任务的处理器名称
+	TenantExpireJobHandlerName = "tenantExpireJob"
+
+	// TenantExpireWarningTemplateCode 租户即将到期提醒的站内信、邮件模板编码
+	// 模板参数：tenantName、expireTime、days
+	TenantExpireWarningTemplateCode = "tenant_expire_warning"
+
+	// RedisKeyTenantExpireWarned 已发送到期提醒的标记，格式：tenant_expire_warned:{tenantId}:{expireUnix}
+	// 续期后到期时间变化，会重新提醒
+	RedisKeyTenantExpireWarned = "tenant_expire_warned:%d:%d"
+
+	defaultTenantExpireWarningDays = 7
+)
+
+// TenantExpireJob 租户到期处理任务
+// 1. 停用已过期的租户，并踢下线该租户的全部会话
+// 2. 对 N 天内即将到期的租户，向联系人发送站内信与邮件提醒，N 由任务参数指定，默认 7 天
+type TenantExpireJob struct {
+	q         *query.Query
+	rdb       *redis.Client
+	tokenSvc  *OAuth2TokenService
+	notifySvc *NotifyService
+	mailSvc   *MailService
+}
+
+func NewTenantExpireJob(q *query.Query, rdb *redis.Client, tokenSvc *OAuth2TokenService, notifySvc *NotifyService, mailSvc *MailService) *TenantExpireJob {
+	return &TenantExpireJob{
+		q:         q,
+		rdb:       rdb,
+		tokenSvc:  tokenSvc,
+		notifySvc: notifySvc,
+		mailSvc:   mailSvc,
+	}
+}
+
+// GetHandlerName 返回处理器名称
+func (j *TenantExpireJob) GetHandlerName() string {
+	return TenantExpireJobHandlerName
+}
+
+// TenantExpireJobParam 租户到期处理任务的参数，如 {"warningDays": 7}
+type TenantExpireJobParam struct {
+	WarningDays int `json:"warningDays"` // 提前提醒的天数，0 表示不提醒
+}
+
+// Execute 执行任务，param 见 TenantExpireJobParam，兼容直接填写提前提醒的天数
+func (j *TenantExpireJob) Execute(ctx context.Context, param string) error {
+	p := TenantExpireJobParam{WarningDays: defaultTenantExpireWarningDays}
+	if days, err := strconv.Atoi(strings.TrimSpace(param)); err == nil {
+		p.WarningDays = days
+	} else if err := infra.ParseJobParam(param, &p); err != nil {
+		return err
+	}
+	if p.WarningDays < 0 {
+		return fmt.Errorf("提前提醒的天数不能小于 0: %d", p.WarningDays)
+	}
+
+	// 任务跨租户执行
+	ctx = pkgTenant.SkipTenant(ctx)
+	now := time.Now()
+	disabled, err := j.disableExpiredTenants(ctx, now)
+	if err != nil {
+		return err
+	}
+	warned, err := j.warnExpiringTenants(ctx, now, p.WarningDays)
+	zap.L().Info("Tenant expire job finished", zap.Int("disabled", disabled), zap.Int("warned", warned))
+	return err
+}
+
+// disableExpiredTenants 停用已过期的租户，返回停用的数量
+func (j *TenantExpireJob) disableExpiredTenants(ctx context.Context, now time.Time) (int, error) {
+	t := j.q.SystemTenant
+	tenants, err := j.findTenants(ctx, t.ExpireDate.Lt(now))
+	if err != nil {
+		return 0, err
+	}
+	for _, tenant := range tenants {
+		if _, err := t.WithContext(ctx).Where(t.ID.Eq(tenant.ID)).Update(t.Status, consts.CommonStatusDisable); err != nil {
+			return 0, err
+		}
+		if err := j.removeTenantSessions(ctx, tenant.ID); err != nil {
+			return 0, err
+		}
+		zap.L().Info("Tenant expired and disabled", zap.Int64("tenantId", tenant.ID), zap.Time("expireTime", tenant.ExpireDate))
+	}
+	return len(tenants), nil
+}
+
+// removeTenantSessions 删除租户的全部在线会话及令牌
+func (j *TenantExpireJob) removeTenantSessions(ctx context.Context, tenantId int64) error {
+	sessions, err := j.tokenSvc.GetTenantSessions(ctx, tenantId)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := j.tokenSvc.RemoveSession(ctx, session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warnExpiringTenants 向即将到期的租户联系人发送提醒，每个到期时间只提醒一次，返回提醒的数量
+func (j *TenantExpireJob) warnExpiringTenants(ctx context.Context, now time.Time, warningDays int) (int, error) {
+	if warningDays == 0 {
+		return 0, nil
+	}
+	t := j.q.SystemTenant
+	tenants, err := j.findTenants(ctx, t.ExpireDate.Gte(now), t.ExpireDate.Lt(now.AddDate(0, 0, warningDays)))
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	warned := 0
+	for _, tenant := range tenants {
+		if tenant.ContactUserID == 0 {
+			continue
+		}
+		key := fmt.Sprintf(RedisKeyTenantExpireWarned, tenant.ID, tenant.ExpireDate.Unix())
+		if j.rdb != nil {
+			ok, err := j.rdb.SetNX(ctx, key, 1, tenant.ExpireDate.Sub(now)+24*time.Hour).Result()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+		if err := j.sendWarning(ctx, tenant, now); err != nil {
+			// 发送失败时清除标记，下次执行时重试
+			if j.rdb != nil {
+				_ = j.rdb.Del(ctx, key).Err()
+			}
+			errs = append(errs, fmt.Errorf("租户(%d)到期提醒发送失败: %w", tenant.ID, err))
+			continue
+		}
+		warned++
+	}
+	return warned, errors.Join(errs...)
+}
+
+// sendWarning 向租户联系人发送站内信与邮件（联系人有邮箱时）
+func (j *TenantExpireJob) sendWarning(ctx context.Context, tenant *model.SystemTenant, now time.Time) error {
+	ctx = pkgContext.WithTenantID(ctx, tenant.ID)
+	params := map[string]interface{}{
+		"tenantName": tenant.Name,
+		"expireTime": tenant.ExpireDate.Format(time.DateTime),
+		"days":       int(tenant.ExpireDate.Sub(now).Hours()/24) + 1,
+	}
+	if _, err := j.notifySvc.SendNotify(ctx, tenant.ContactUserID, consts.UserTypeAdmin, TenantExpireWarningTemplateCode, params); err != nil {
+		return err
+	}
+
+	u := j.q.SystemUser
+	contact, err := u.WithContext(ctx).Where(u.ID.Eq(tenant.ContactUserID)).First()
+	if err != nil || contact.Email == "" {
+		return nil
+	}
+	_, err = j.mailSvc.SendSingleMail(ctx, []string{contact.Email}, nil, nil,
+		contact.ID, consts.UserTypeAdmin, TenantExpireWarningTemplateCode, params)
+	return err
+}
+
+// findTenants 查询启用中的普通租户，系统租户不会过期
+func (j *TenantExpireJob) findTenants(ctx context.Context, conds ...gen.Condition) ([]*model.SystemTenant, error) {
+	t := j.q.SystemTenant
+	return t.WithContext(ctx).
+		Where(t.Status.Eq(consts.CommonStatusEnable), t.PackageID.Neq(0), t.ExpireDate.Gt(time.Time{})).
+		Where(conds...).
+		Find()
+}
@@ -0,0 +1,109 @@
+package system
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+)
+
+func TestTenantExpireJob(t *testing.T) {
+	useTestRedis(t)
+	q := newTestQuery(t, &model.SystemTenant{}, &model.SystemUser{}, &model.SystemNotifyTemplate{}, &model.SystemNotifyMessage{})
+	ctx := context.Background()
+	now := time.Now()
+
+	tenants := []*model.SystemTenant{
+		{Name: "expired", PackageID: 1, ExpireDate: now.Add(-time.Hour)},
+		{Name: "expiring", PackageID: 1, ExpireDate: now.Add(48 * time.Hour), ContactUserID: 10},
+		{Name: "later", PackageID: 1, ExpireDate: now.AddDate(0, 0, 30), ContactUserID: 10},
+		{Name: "system", PackageID: 0, ExpireDate: now.Add(-time.Hour)},
+	}
+	if err := q.SystemTenant.WithContext(ctx).Create(tenants...); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SystemNotifyTemplate.WithContext(ctx).Create(&model.SystemNotifyTemplate{
+		Code:    TenantExpireWarningTemplateCode,
+		Content: "{tenantName} 将于 {days} 天后到期",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenSvc := NewOAuth2TokenService()
+	if _, err := tokenSvc.CreateAccessToken(ctx, 1, consts.UserTypeAdmin, tenants[0].ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	job := NewTenantExpireJob(q, nil, tokenSvc, NewNotifyService(q), nil)
+
+	if err := job.Execute(ctx, "3"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 1. 过期租户被停用并踢下线，系统租户不受影响
+	list, err := q.SystemTenant.WithContext(ctx).Order(q.SystemTenant.ID).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStatus := []int32{consts.CommonStatusDisable, consts.CommonStatusEnable, consts.CommonStatusEnable, consts.CommonStatusEnable}
+	for i, tenant := range list {
+		if tenant.Status != wantStatus[i] {
+			t.Errorf("tenant %s status = %d, want %d", tenant.Name, tenant.Status, wantStatus[i])
+		}
+	}
+	sessions, err := tokenSvc.GetTenantSessions(ctx, tenants[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("expired tenant sessions = %d, want 0", len(sessions))
+	}
+
+	// 2. 仅提醒提醒期内到期的租户
+	messages, err := q.SystemNotifyMessage.WithContext(ctx).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].UserID != 10 || messages[0].TemplateContent != "expiring 将于 2 天后到期" {
+		t.Fatalf("messages = %+v", messages)
+	}
+}
+
+func TestTenantExpireJobWarnsOnce(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	q := newTestQuery(t, &model.SystemTenant{}, &model.SystemUser{}, &model.SystemNotifyTemplate{}, &model.SystemNotifyMessage{})
+	ctx := context.Background()
+	if err := q.SystemTenant.WithContext(ctx).Create(&model.SystemTenant{
+		Name: "expiring", PackageID: 1, ExpireDate: time.Now().Add(time.Hour), ContactUserID: 10,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SystemNotifyTemplate.WithContext(ctx).Create(&model.SystemNotifyTemplate{Code: TenantExpireWarningTemplateCode}); err != nil {
+		t.Fatal(err)
+	}
+	job := NewTenantExpireJob(q, rdb, NewOAuth2TokenService(), NewNotifyService(q), nil)
+
+	for i := 0; i < 2; i++ {
+		if err := job.Execute(ctx, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count, err := q.SystemNotifyMessage.WithContext(ctx).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("messages = %d, want 1", count)
+	}
+
+	// 未配置模板时发送失败，清除标记以便下次重试
+	job = NewTenantExpireJob(q, rdb, NewOAuth2TokenService(), NewNotifyService(newTestQuery(t, &model.SystemNotifyTemplate{})), nil)
+	rdb.FlushAll(ctx)
+	if err := job.Execute(ctx, ""); err == nil {
+		t.Error("want missing template error")
+	}
+	if keys := rdb.Keys(ctx, "tenant_expire_warned:*").Val(); len(keys) != 0 {
+		t.Errorf("warned keys = %v, want none", keys)
+	}
+}
@@ -75,6 +75,8 @@ func (s *TenantPackageService) UpdateTenantPackage(ctx context.Context, r *syste
 				_ = s.tenantSvc.updateTenantRoleMenu(ctx, tenant.ID, r.MenuIds)
 			}
 		}
+		// 套餐内的权限标识在鉴权时校验，需要重新加载
+		s.tenantSvc.policySyncer.Reload(ctx)
 	}
 	return nil
 }
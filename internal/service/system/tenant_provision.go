@@ -0,0 +1,253 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// TenantConfigKeyTemplateTenantID 默认的模板租户编号，创建租户未指定模板时使用，未配置时不复制
+// 模板租户中维护好的部门、岗位、角色及其菜单、通知公告即为新租户的初始数据
+// 参数配置、字典、站内信/邮件/短信模板为全局数据，所有租户共用，无需复制
+const TenantConfigKeyTemplateTenantID = "system.tenant.template-tenant-id"
+
+// CloneTenant 将源租户的初始数据复制到目标租户，目标租户中已存在的数据（按编码或名称）跳过
+func (s *TenantService) CloneTenant(ctx context.Context, req *system.TenantCloneReq) (*system.TenantCloneResp, error) {
+	// 1. 校验源租户、目标租户
+	if req.SourceTenantID == req.TargetTenantID {
+		return nil, errors.New("源租户与目标租户不能相同")
+	}
+	t := s.q.SystemTenant
+	if _, err := t.WithContext(ctx).Where(t.ID.Eq(req.SourceTenantID)).First(); err != nil {
+		return nil, errors.New("源租户不存在")
+	}
+	target, err := s.validateUpdateTenant(ctx, req.TargetTenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 角色菜单限定在目标租户的套餐内
+	tp := s.q.SystemTenantPackage
+	pkg, err := tp.WithContext(ctx).Where(tp.ID.Eq(target.PackageID)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("租户套餐不存在")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 事务复制
+	var resp *system.TenantCloneResp
+	err = s.q.Transaction(func(tx *query.Query) error {
+		var err error
+		resp, err = s.cloneTenantData(ctx, tx, req.SourceTenantID, req.TargetTenantID, pkg.MenuIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.policySyncer.Reload(ctx)
+	return resp, nil
+}
+
+// getTemplateTenantID 获得创建租户时使用的模板租户，优先使用请求指定的，其次为参数配置的默认模板
+func (s *TenantService) getTemplateTenantID(ctx context.Context, templateTenantId int64) (int64, error) {
+	if templateTenantId == 0 {
+		c := s.q.SystemConfig
+		config, err := c.WithContext(ctx).Where(c.ConfigKey.Eq(TenantConfigKeyTemplateTenantID)).First()
+		if err != nil {
+			return 0, nil
+		}
+		templateTenantId, _ = strconv.ParseInt(strings.TrimSpace(config.Value), 10, 64)
+		if templateTenantId == 0 {
+			return 0, nil
+		}
+	}
+	t := s.q.SystemTenant
+	if _, err := t.WithContext(ctx).Where(t.ID.Eq(templateTenantId)).First(); err != nil {
+		return 0, errors.New("模板租户不存在")
+	}
+	return templateTenantId, nil
+}
+
+// cloneTenantData 在事务内复制部门、岗位、角色及其菜单、通知公告，不复制用户
+// 部门保持原有的树形结构，角色的数据权限部门映射为复制后的部门；只复制自定义角色，
+// 租户管理员角色由创建租户时生成，超级管理员及其他内置系统角色不复制，避免越权
+func (s *TenantService) cloneTenantData(ctx context.Context, tx *query.Query, sourceTenantId, targetTenantId int64, menuIds []int64) (*system.TenantCloneResp, error) {
+	sourceCtx := pkgContext.WithTenantID(ctx, sourceTenantId)
+	targetCtx := pkgContext.WithTenantID(ctx, targetTenantId)
+	resp := &system.TenantCloneResp{}
+
+	// 1. 部门
+	deptIdMap, err := s.cloneDepts(sourceCtx, targetCtx, tx, sourceTenantId, targetTenantId, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 岗位
+	p := tx.SystemPost
+	posts, err := p.WithContext(sourceCtx).Where(p.TenantID.Eq(sourceTenantId)).Find()
+	if err != nil {
+		return nil, err
+	}
+	var existPostCodes []string
+	if err := p.WithContext(targetCtx).Where(p.TenantID.Eq(targetTenantId)).Pluck(p.Code, &existPostCodes); err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		if lo.Contains(existPostCodes, post.Code) {
+			continue
+		}
+		newPost := &model.SystemPost{Name: post.Name, Code: post.Code, Sort: post.Sort, Status: post.Status, Remark: post.Remark}
+		newPost.TenantID = targetTenantId
+		if err := p.WithContext(targetCtx).Create(newPost); err != nil {
+			return nil, err
+		}
+		resp.PostCount++
+	}
+
+	// 3. 角色及其菜单
+	r := tx.SystemRole
+	roles, err := r.WithContext(sourceCtx).Where(r.TenantID.Eq(sourceTenantId),
+		r.Code.NotIn(consts.RoleCodeTenantAdmin, consts.RoleCodeSuperAdmin), r.Type.Neq(consts.RoleTypeSystem)).Find()
+	if err != nil {
+		return nil, err
+	}
+	var existRoleCodes []string
+	if err := r.WithContext(targetCtx).Where(r.TenantID.Eq(targetTenantId)).Pluck(r.Code, &existRoleCodes); err != nil {
+		return nil, err
+	}
+	rm := tx.SystemRoleMenu
+	for _, role := range roles {
+		if lo.Contains(existRoleCodes, role.Code) {
+			continue
+		}
+		newRole := &model.SystemRole{
+			Name:      role.Name,
+			Code:      role.Code,
+			Sort:      role.Sort,
+			DataScope: role.DataScope,
+			DataScopeDeptIds: lo.FilterMap(role.DataScopeDeptIds, func(id int64, _ int) (int64, bool) {
+				newId, ok := deptIdMap[id]
+				return newId, ok
+			}),
+			DataScopeConfig: role.DataScopeConfig,
+			Status:          role.Status,
+			Type:            role.Type,
+			Remark:          role.Remark,
+		}
+		newRole.TenantID = targetTenantId
+		if err := r.WithContext(targetCtx).Create(newRole); err != nil {
+			return nil, err
+		}
+		resp.RoleCount++
+
+		var roleMenuIds []int64
+		if err := rm.WithContext(sourceCtx).Where(rm.RoleID.Eq(role.ID)).Pluck(rm.MenuID, &roleMenuIds); err != nil {
+			return nil, err
+		}
+		roleMenuIds = utils.Intersect(roleMenuIds, menuIds)
+		if len(roleMenuIds) == 0 {
+			continue
+		}
+		roleMenus := make([]*model.SystemRoleMenu, len(roleMenuIds))
+		for i, menuId := range roleMenuIds {
+			roleMenus[i] = &model.SystemRoleMenu{RoleID: newRole.ID, MenuID: menuId}
+			roleMenus[i].TenantID = targetTenantId
+		}
+		if err := rm.WithContext(targetCtx).Create(roleMenus...); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. 通知公告
+	n := tx.SystemNotice
+	notices, err := n.WithContext(sourceCtx).Where(n.TenantID.Eq(sourceTenantId)).Find()
+	if err != nil {
+		return nil, err
+	}
+	var existNoticeTitles []string
+	if err := n.WithContext(targetCtx).Where(n.TenantID.Eq(targetTenantId)).Pluck(n.Title, &existNoticeTitles); err != nil {
+		return nil, err
+	}
+	for _, notice := range notices {
+		if lo.Contains(existNoticeTitles, notice.Title) {
+			continue
+		}
+		newNotice := &model.SystemNotice{Title: notice.Title, Type: notice.Type, Content: notice.Content, Status: notice.Status}
+		newNotice.TenantID = targetTenantId
+		if err := n.WithContext(targetCtx).Create(newNotice); err != nil {
+			return nil, err
+		}
+		resp.NoticeCount++
+	}
+	return resp, nil
+}
+
+// cloneDepts 按层级复制部门树，返回源部门编号到目标部门编号的映射
+// 目标租户中同一上级下已存在同名部门时复用该部门，负责人为用户数据，不复制
+func (s *TenantService) cloneDepts(sourceCtx, targetCtx context.Context, tx *query.Query, sourceTenantId, targetTenantId int64, resp *system.TenantCloneResp) (map[int64]int64, error) {
+	d := tx.SystemDept
+	depts, err := d.WithContext(sourceCtx).Where(d.TenantID.Eq(sourceTenantId)).Order(d.Sort).Find()
+	if err != nil {
+		return nil, err
+	}
+	existDepts, err := d.WithContext(targetCtx).Where(d.TenantID.Eq(targetTenantId)).Find()
+	if err != nil {
+		return nil, err
+	}
+	existDeptKeys := lo.SliceToMap(existDepts, func(item *model.SystemDept) (string, int64) {
+		return deptCloneKey(item.ParentID, item.Name), item.ID
+	})
+
+	// 源部门编号 -> 目标部门编号，根节点的上级为 0
+	deptIdMap := map[int64]int64{0: 0}
+	children := lo.GroupBy(depts, func(item *model.SystemDept) int64 { return item.ParentID })
+	parentIds := []int64{0}
+	for len(parentIds) > 0 {
+		var nextParentIds []int64
+		for _, parentId := range parentIds {
+			for _, dept := range children[parentId] {
+				newParentId := deptIdMap[parentId]
+				if existId, ok := existDeptKeys[deptCloneKey(newParentId, dept.Name)]; ok {
+					deptIdMap[dept.ID] = existId
+					nextParentIds = append(nextParentIds, dept.ID)
+					continue
+				}
+				newDept := &model.SystemDept{
+					Name:     dept.Name,
+					ParentID: newParentId,
+					Sort:     dept.Sort,
+					Phone:    dept.Phone,
+					Email:    dept.Email,
+					Status:   dept.Status,
+				}
+				newDept.TenantID = targetTenantId
+				if err := d.WithContext(targetCtx).Create(newDept); err != nil {
+					return nil, err
+				}
+				deptIdMap[dept.ID] = newDept.ID
+				nextParentIds = append(nextParentIds, dept.ID)
+				resp.DeptCount++
+			}
+		}
+		parentIds = nextParentIds
+	}
+	delete(deptIdMap, 0)
+	return deptIdMap, nil
+}
+
+// deptCloneKey 部门在同一上级下以名称区分
+func deptCloneKey(parentId int64, name string) string {
+	return strconv.FormatInt(parentId, 10) + "/" + name
+}
@@ -0,0 +1,176 @@
+package system
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+)
+
+const (
+	testSourceTenantID int64 = 2
+	testTargetTenantID int64 = 3
+)
+
+// newTestCloneQuery 创建复制租户数据所需的表
+func newTestCloneQuery(t *testing.T) *query.Query {
+	t.Helper()
+	return newTestQuery(t, &model.SystemTenant{}, &model.SystemTenantPackage{}, &model.SystemDept{}, &model.SystemPost{},
+		&model.SystemRole{}, &model.SystemRoleMenu{}, &model.SystemNotice{})
+}
+
+// createTestDept 在租户下创建部门
+func createTestDept(t *testing.T, q *query.Query, tenantId, parentId int64, name string) *model.SystemDept {
+	t.Helper()
+	dept := &model.SystemDept{Name: name, ParentID: parentId}
+	dept.TenantID = tenantId
+	if err := q.SystemDept.WithContext(context.Background()).Create(dept); err != nil {
+		t.Fatal(err)
+	}
+	return dept
+}
+
+func TestCloneDeptsRemapsTree(t *testing.T) {
+	q := newTestCloneQuery(t)
+	ctx := context.Background()
+	root := createTestDept(t, q, testSourceTenantID, 0, "总部")
+	child := createTestDept(t, q, testSourceTenantID, root.ID, "研发部")
+	leaf := createTestDept(t, q, testSourceTenantID, child.ID, "后端组")
+	other := createTestDept(t, q, testSourceTenantID, 0, "分部")
+	// 目标租户已有同名的根部门，复用而不重复创建
+	existRoot := createTestDept(t, q, testTargetTenantID, 0, "总部")
+
+	resp := &system.TenantCloneResp{}
+	deptIdMap, err := (&TenantService{q: q}).cloneDepts(pkgContext.WithTenantID(ctx, testSourceTenantID),
+		pkgContext.WithTenantID(ctx, testTargetTenantID), q, testSourceTenantID, testTargetTenantID, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.DeptCount != 3 || len(deptIdMap) != 4 {
+		t.Fatalf("dept count = %d, map = %v", resp.DeptCount, deptIdMap)
+	}
+	if deptIdMap[root.ID] != existRoot.ID {
+		t.Errorf("root mapped to %d, want the existing dept %d", deptIdMap[root.ID], existRoot.ID)
+	}
+
+	d := q.SystemDept
+	depts, err := d.WithContext(ctx).Where(d.TenantID.Eq(testTargetTenantID)).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parents := map[int64]int64{}
+	for _, dept := range depts {
+		parents[dept.ID] = dept.ParentID
+	}
+	for source, want := range map[int64]int64{child.ID: existRoot.ID, leaf.ID: deptIdMap[child.ID], other.ID: 0} {
+		if got := parents[deptIdMap[source]]; got != want {
+			t.Errorf("dept %d parent = %d, want %d", deptIdMap[source], got, want)
+		}
+	}
+}
+
+func TestCloneTenantData(t *testing.T) {
+	q := newTestCloneQuery(t)
+	ctx := context.Background()
+	dept := createTestDept(t, q, testSourceTenantID, 0, "总部")
+
+	posts := []*model.SystemPost{{Name: "董事长", Code: "ceo"}, {Name: "经理", Code: "manager"}, {Name: "经理", Code: "manager"}}
+	posts[0].TenantID, posts[1].TenantID, posts[2].TenantID = testSourceTenantID, testSourceTenantID, testTargetTenantID
+	// 只复制自定义角色，超级管理员、租户管理员及其他内置系统角色不复制
+	roles := []*model.SystemRole{
+		{Name: "管理员", Code: consts.RoleCodeTenantAdmin, Type: consts.RoleTypeSystem},
+		{Name: "部门", Code: "dept", DataScope: 2, DataScopeDeptIds: model.Int64ListFromCSV{dept.ID, 999}, Type: consts.RoleTypeCustom},
+		{Name: "超级管理员", Code: consts.RoleCodeSuperAdmin, Type: consts.RoleTypeCustom},
+		{Name: "普通角色", Code: "common", Type: consts.RoleTypeSystem},
+	}
+	for _, role := range roles {
+		role.TenantID = testSourceTenantID
+	}
+	notice := &model.SystemNotice{Title: "欢迎", Content: "欢迎使用"}
+	notice.TenantID = testSourceTenantID
+	if err := q.SystemPost.WithContext(ctx).Create(posts...); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SystemRole.WithContext(ctx).Create(roles...); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SystemNotice.WithContext(ctx).Create(notice); err != nil {
+		t.Fatal(err)
+	}
+	for _, menuId := range []int64{1, 2, 3} {
+		if err := q.SystemRoleMenu.WithContext(ctx).Create(&model.SystemRoleMenu{RoleID: roles[1].ID, MenuID: menuId}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var resp *system.TenantCloneResp
+	err := q.Transaction(func(tx *query.Query) error {
+		var err error
+		resp, err = (&TenantService{q: q}).cloneTenantData(ctx, tx, testSourceTenantID, testTargetTenantID, []int64{1, 2})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := system.TenantCloneResp{DeptCount: 1, PostCount: 1, RoleCount: 1, NoticeCount: 1}
+	if *resp != want {
+		t.Errorf("resp = %+v, want %+v", *resp, want)
+	}
+
+	// 角色的数据权限部门映射为复制后的部门，菜单限定在套餐内
+	r := q.SystemRole
+	role, err := r.WithContext(ctx).Where(r.TenantID.Eq(testTargetTenantID)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := q.SystemDept
+	newDept, err := d.WithContext(ctx).Where(d.TenantID.Eq(testTargetTenantID)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.Code != "dept" || !slices.Equal(role.DataScopeDeptIds, []int64{newDept.ID}) {
+		t.Errorf("role = %s, data scope depts = %v, want [%d]", role.Code, role.DataScopeDeptIds, newDept.ID)
+	}
+	var menuIds []int64
+	rm := q.SystemRoleMenu
+	if err := rm.WithContext(ctx).Where(rm.RoleID.Eq(role.ID)).Order(rm.MenuID).Pluck(rm.MenuID, &menuIds); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(menuIds, []int64{1, 2}) {
+		t.Errorf("role menus = %v, want [1 2]", menuIds)
+	}
+}
+
+func TestCloneTenantValidates(t *testing.T) {
+	q := newTestCloneQuery(t)
+	ctx := context.Background()
+	if err := q.SystemTenant.WithContext(ctx).Create(
+		&model.SystemTenant{ID: testSourceTenantID, Name: "源租户", PackageID: 1},
+		&model.SystemTenant{ID: testTargetTenantID, Name: "目标租户", PackageID: 100},
+	); err != nil {
+		t.Fatal(err)
+	}
+	s := &TenantService{q: q}
+
+	tests := []struct {
+		name string
+		req  system.TenantCloneReq
+		want string
+	}{
+		{"same tenant", system.TenantCloneReq{SourceTenantID: testSourceTenantID, TargetTenantID: testSourceTenantID}, "源租户与目标租户不能相同"},
+		{"missing source", system.TenantCloneReq{SourceTenantID: 100, TargetTenantID: testTargetTenantID}, "源租户不存在"},
+		{"missing package", system.TenantCloneReq{SourceTenantID: testSourceTenantID, TargetTenantID: testTargetTenantID}, "租户套餐不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.CloneTenant(ctx, &tt.req); err == nil || err.Error() != tt.want {
+				t.Errorf("err = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
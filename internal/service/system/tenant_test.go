@@ -0,0 +1,191 @@
+package system
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+)
+
+func TestGetTenantByWebsite(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	db := newTestDB(t, &model.SystemTenant{})
+	svc := &TenantService{q: query.Use(db), rdb: rdb}
+	ctx := context.Background()
+
+	tenants := []*model.SystemTenant{
+		{Name: "a", Websites: model.StringListFromCSV{"a.com", "www.a.com"}},
+		{Name: "ba", Websites: model.StringListFromCSV{"ba.com"}},
+		{Name: "disabled", Status: 1, Websites: model.StringListFromCSV{"c.com"}},
+	}
+	if err := db.Create(&tenants).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		website string
+		want    int64
+	}{
+		{"a.com", tenants[0].ID},
+		{"www.a.com", tenants[0].ID},
+		{"ba.com", tenants[1].ID},
+		{"com", 0},
+		{"c.com", 0},
+	}
+	for _, tt := range tests {
+		resp, err := svc.GetTenantByWebsite(ctx, tt.website)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.website, err)
+		}
+		var got int64
+		if resp != nil {
+			got = resp.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: tenant = %d, want %d", tt.website, got, tt.want)
+		}
+	}
+
+	// 命中缓存，不再查询数据库
+	if err := db.Model(tenants[0]).Update("website", "b.com").Error; err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := svc.GetTenantByWebsite(ctx, "a.com"); err != nil || resp == nil || resp.ID != tenants[0].ID {
+		t.Fatalf("cached resp = %+v, err = %v", resp, err)
+	}
+
+	// 清除缓存后重新查询
+	svc.evictWebsiteCache(ctx, []string{"a.com"})
+	if resp, err := svc.GetTenantByWebsite(ctx, "a.com"); err != nil || resp != nil {
+		t.Fatalf("evicted resp = %+v, err = %v", resp, err)
+	}
+}
+
+func TestGetTenantByWebsiteReturnsDBError(t *testing.T) {
+	db := newTestDB(t, &model.SystemTenant{})
+	svc := &TenantService{q: query.Use(db)}
+	if err := db.Migrator().DropTable(&model.SystemTenant{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetTenantByWebsite(context.Background(), "a.com"); err == nil {
+		t.Fatal("want db error")
+	}
+}
+
+func TestValidTenantWebsiteDuplicate(t *testing.T) {
+	db := newTestDB(t, &model.SystemTenant{})
+	svc := &TenantService{q: query.Use(db)}
+	tenant := &model.SystemTenant{Name: "a", Websites: model.StringListFromCSV{"a.com", "b.com"}}
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := svc.validTenantWebsiteDuplicate(ctx, []string{"b.com"}, 0); err == nil {
+		t.Error("want duplicate error")
+	}
+	if err := svc.validTenantWebsiteDuplicate(ctx, []string{"b.com"}, tenant.ID); err != nil {
+		t.Errorf("exclude self: %v", err)
+	}
+	if err := svc.validTenantWebsiteDuplicate(ctx, []string{"xb.com"}, 0); err != nil {
+		t.Errorf("partial match: %v", err)
+	}
+}
+
+func TestValidTenantAccountQuota(t *testing.T) {
+	db := newTestDB(t, &model.SystemTenant{}, &model.SystemUser{})
+	q := query.Use(db)
+	svc := &TenantService{q: q}
+	tenants := []*model.SystemTenant{
+		{Name: "system", PackageID: 0, AccountCount: 0},
+		{Name: "limited", PackageID: 1, AccountCount: 2},
+	}
+	if err := db.Create(&tenants).Error; err != nil {
+		t.Fatal(err)
+	}
+	users := []*model.SystemUser{{Username: "a"}, {Username: "b"}}
+	users[0].TenantID = tenants[1].ID
+	users[1].TenantID = tenants[0].ID
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	valid := func(tenantId int64) error {
+		return q.Transaction(func(tx *query.Query) error {
+			return svc.ValidTenantAccountQuota(ctx, tx, tenantId)
+		})
+	}
+	if err := valid(tenants[0].ID); err != nil {
+		t.Errorf("system tenant: %v", err)
+	}
+	if err := valid(tenants[1].ID); err != nil {
+		t.Errorf("below quota: %v", err)
+	}
+	users = []*model.SystemUser{{Username: "c"}}
+	users[0].TenantID = tenants[1].ID
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := valid(tenants[1].ID); err == nil {
+		t.Error("want quota exceeded error")
+	}
+	if err := valid(100); err == nil || err.Error() != "租户不存在" {
+		t.Errorf("missing tenant: err = %v", err)
+	}
+}
+
+func TestGetTenantUsage(t *testing.T) {
+	db := newTestDB(t, &model.SystemTenant{}, &model.SystemUser{}, &model.InfraFile{}, &model.SystemSmsLog{})
+	svc := &TenantService{q: query.Use(db)}
+	tenant := &model.SystemTenant{Name: "a", PackageID: 1, AccountCount: 10}
+	if err := db.Create(tenant).Error; err != nil {
+		t.Fatal(err)
+	}
+	users := []*model.SystemUser{{Username: "a"}, {Username: "b"}, {Username: "other"}}
+	users[0].TenantID = tenant.ID
+	users[1].TenantID = tenant.ID
+	users[2].TenantID = tenant.ID + 1
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	files := []*model.InfraFile{{Size: 100}, {Size: 50}, {Size: 1000}}
+	files[0].Creator = "1"
+	files[1].Creator = "2"
+	files[2].Creator = "3"
+	if err := db.Create(&files).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	lastMonth := time.Now().AddDate(0, -1, -1)
+	logs := []*model.SystemSmsLog{
+		{UserId: users[0].ID, UserType: consts.UserTypeAdmin, SendStatus: consts.SmsSendStatusSuccess},
+		{UserId: users[1].ID, UserType: consts.UserTypeAdmin, SendStatus: consts.SmsSendStatusSuccess},
+		{UserId: users[1].ID, UserType: consts.UserTypeAdmin, SendStatus: 0},
+		{UserId: users[2].ID, UserType: consts.UserTypeAdmin, SendStatus: consts.SmsSendStatusSuccess},
+		{UserId: users[0].ID, UserType: consts.UserTypeMember, SendStatus: consts.SmsSendStatusSuccess},
+	}
+	if err := db.Create(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	expired := &model.SystemSmsLog{UserId: users[0].ID, UserType: consts.UserTypeAdmin, SendStatus: consts.SmsSendStatusSuccess}
+	expired.CreateTime = lastMonth
+	if err := db.Create(expired).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := svc.GetTenantUsage(context.Background(), tenant.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.AccountCount != 10 || usage.UserCount != 2 || usage.FileCount != 2 || usage.FileSize != 150 || usage.SmsCount != 2 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if _, err := svc.GetTenantUsage(context.Background(), 100); err == nil {
+		t.Error("want missing tenant error")
+	}
+}
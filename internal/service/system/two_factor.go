@@ -0,0 +1,508 @@
+package system
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/samber/lo"
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/consts"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/totp"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	"github.com/wxlbd/admin-go/pkg/config"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	bzErr "github.com/wxlbd/admin-go/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// ========== 二次验证配置常量 ==========
+
+const (
+	// TwoFactorTicketKeyPrefix 预认证票据 Redis key 前缀
+	TwoFactorTicketKeyPrefix = "login:2fa:ticket:"
+
+	// TwoFactorTicketExpire 预认证票据有效期
+	TwoFactorTicketExpire = 5 * time.Minute
+
+	// TwoFactorTicketMaxFailures 同一票据允许的验证失败次数，超过后需重新登录
+	TwoFactorTicketMaxFailures = 5
+
+	// TwoFactorRecoveryCodeCount 恢复码数量
+	TwoFactorRecoveryCodeCount = 10
+)
+
+// ========== 错误码定义 ==========
+
+var (
+	// ErrTwoFactorTicketInvalid 预认证票据不存在或已过期
+	ErrTwoFactorTicketInvalid = bzErr.NewBizError(1_002_029_000, "登录已超时，请重新登录")
+
+	// ErrTwoFactorCodeError 验证码不正确
+	ErrTwoFactorCodeError = bzErr.NewBizError(1_002_029_001, "二次验证码不正确")
+
+	// ErrTwoFactorNotSetup 尚未绑定验证器
+	ErrTwoFactorNotSetup = bzErr.NewBizError(1_002_029_002, "尚未绑定验证器，请先获取绑定二维码")
+
+	// ErrTwoFactorAlreadyEnabled 已开启二次验证
+	ErrTwoFactorAlreadyEnabled = bzErr.NewBizError(1_002_029_003, "已开启二次验证，请先关闭")
+
+	// ErrTwoFactorNotEnabled 未开启二次验证
+	ErrTwoFactorNotEnabled = bzErr.NewBizError(1_002_029_004, "未开启二次验证")
+
+	// ErrTwoFactorEnforced 租户策略强制开启，不允许关闭
+	ErrTwoFactorEnforced = bzErr.NewBizError(1_002_029_005, "当前角色要求开启二次验证，不允许关闭")
+)
+
+// TwoFactorTicket 预认证票据：账号密码等第一因子已通过，等待 TOTP 验证
+type TwoFactorTicket struct {
+	UserID   int64  `json:"userId"`
+	TenantID int64  `json:"tenantId"`
+	Username string `json:"username"`
+	LogType  int    `json:"logType"`  // 第一因子的登录类型，完成后按该类型记录登录日志
+	Setup    bool   `json:"setup"`    // 是否需要在登录过程中绑定验证器
+	Failures int    `json:"failures"` // 验证失败次数
+}
+
+// ========== 二次验证服务 ==========
+
+// TwoFactorService TOTP 二次验证：绑定、登录校验、恢复码与租户强制策略
+// 二次验证失败计入账号的登录失败次数，与密码错误共用锁定策略
+type TwoFactorService struct {
+	q             *query.Query
+	rdb           *redis.Client
+	loginLimitSvc *LoginLimitService
+}
+
+func NewTwoFactorService(q *query.Query, rdb *redis.Client, loginLimitSvc *LoginLimitService) *TwoFactorService {
+	return &TwoFactorService{q: q, rdb: rdb, loginLimitSvc: loginLimitSvc}
+}
+
+// Challenge 第一因子通过后判断是否需要二次验证，需要时签发预认证票据，否则返回 nil
+func (s *TwoFactorService) Challenge(ctx context.Context, user *model.SystemUser, tenantId int64, logType int) (*system.AuthLoginResp, error) {
+	enabled, required, err := s.status(ctx, user)
+	if err != nil || !required {
+		return nil, err
+	}
+
+	ticket := strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := s.saveTicket(ctx, ticket, &TwoFactorTicket{
+		UserID:   user.ID,
+		TenantID: tenantId,
+		Username: user.Username,
+		LogType:  logType,
+		Setup:    !enabled,
+	}, TwoFactorTicketExpire); err != nil {
+		return nil, err
+	}
+	return &system.AuthLoginResp{
+		UserId:                 user.ID,
+		TwoFactorTicket:        ticket,
+		TwoFactorSetupRequired: !enabled,
+	}, nil
+}
+
+// Required 用户登录是否需要二次验证：已开启，或被租户策略强制开启
+func (s *TwoFactorService) Required(ctx context.Context, user *model.SystemUser) (bool, error) {
+	_, required, err := s.status(ctx, user)
+	return required, err
+}
+
+// SetupByTicket 登录过程中绑定验证器，仅限租户策略强制开启但尚未绑定的用户
+func (s *TwoFactorService) SetupByTicket(ctx context.Context, ticket string) (*system.TwoFactorSetupResp, error) {
+	info, _, err := s.getTicket(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Setup {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	ctx = pkgContext.WithTenantID(ctx, info.TenantID)
+	return s.setup(ctx, info.UserID, info.TenantID, info.Username)
+}
+
+// CompleteLogin 使用 TOTP 验证码或恢复码完成二次验证，成功后票据失效并清除账号的登录失败次数
+// 登录过程中绑定验证器的用户，验证通过即开启二次验证，并返回恢复码
+// 验证失败计入账号的登录失败次数，账号被锁定后票据失效，避免通过反复登录获取新票据暴力破解
+// 返回的票据在验证失败时也不为 nil，便于记录登录日志
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, req *system.AuthTwoFactorLoginReq) (*TwoFactorTicket, []string, error) {
+	info, ttl, err := s.getTicket(ctx, req.Ticket)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = pkgContext.WithTenantID(ctx, info.TenantID)
+	ip := clientIP(ctx)
+	if err := s.loginLimitSvc.CheckLocked(ctx, info.TenantID, info.Username, ip); err != nil {
+		s.rdb.Del(ctx, TwoFactorTicketKeyPrefix+req.Ticket)
+		return info, nil, err
+	}
+
+	var recoveryCodes []string
+	if info.Setup {
+		recoveryCodes, err = s.Enable(ctx, info.UserID, req.Code)
+	} else {
+		err = s.verify(ctx, info.UserID, req.Code, true)
+	}
+	if err != nil {
+		if err == ErrTwoFactorCodeError {
+			s.loginLimitSvc.RecordFailure(ctx, info.TenantID, info.Username, ip)
+			info.Failures++
+			if info.Failures >= TwoFactorTicketMaxFailures {
+				s.rdb.Del(ctx, TwoFactorTicketKeyPrefix+req.Ticket)
+			} else {
+				_ = s.saveTicket(ctx, req.Ticket, info, ttl)
+			}
+		}
+		return info, nil, err
+	}
+
+	s.rdb.Del(ctx, TwoFactorTicketKeyPrefix+req.Ticket)
+	s.loginLimitSvc.ClearFailure(ctx, info.TenantID, info.Username)
+	return info, recoveryCodes, nil
+}
+
+// GetStatus 获取用户的二次验证状态
+func (s *TwoFactorService) GetStatus(ctx context.Context, userId int64) (*system.TwoFactorStatusResp, error) {
+	user, err := s.getUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	enforced, err := s.isEnforced(ctx, user.ID, user.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	row, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &system.TwoFactorStatusResp{Enforced: enforced}
+	if row != nil && bool(row.Enabled) {
+		resp.Enabled = true
+		resp.EnableTime = row.EnableTime
+		resp.RecoveryCodeCount = len(row.RecoveryCodes)
+	}
+	return resp, nil
+}
+
+// Setup 生成新的 TOTP 密钥，调用 Enable 校验验证码后才生效
+func (s *TwoFactorService) Setup(ctx context.Context, userId int64) (*system.TwoFactorSetupResp, error) {
+	user, err := s.getUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.setup(ctx, user.ID, user.TenantID, user.Username)
+}
+
+// Enable 校验验证码并开启二次验证，返回恢复码明文
+func (s *TwoFactorService) Enable(ctx context.Context, userId int64, code string) ([]string, error) {
+	row, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if row == nil || row.Secret == "" {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if row.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	step, ok := totp.Validate(row.Secret, code, time.Now(), row.LastUsedStep)
+	if !ok {
+		return nil, ErrTwoFactorCodeError
+	}
+
+	recoveryCodes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	// 条件更新：并发请求使用同一验证码时只有一个生效
+	t := s.q.SystemUserTotp
+	info, err := t.WithContext(ctx).
+		Where(t.ID.Eq(row.ID), t.Enabled.Eq(model.BitBool(false)), t.LastUsedStep.Lt(step)).
+		UpdateSimple(
+			t.Enabled.Value(model.BitBool(true)),
+			t.EnableTime.Value(time.Now()),
+			t.LastUsedStep.Value(step),
+			t.RecoveryCodes.Value(model.StringListFromCSV(hashes)),
+		)
+	if err != nil {
+		return nil, err
+	}
+	if info.RowsAffected == 0 {
+		return nil, ErrTwoFactorCodeError
+	}
+	return recoveryCodes, nil
+}
+
+// Disable 校验验证码后关闭二次验证，租户策略强制开启时不允许关闭
+func (s *TwoFactorService) Disable(ctx context.Context, userId int64, code string) error {
+	user, err := s.getUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	enforced, err := s.isEnforced(ctx, user.ID, user.TenantID)
+	if err != nil {
+		return err
+	}
+	if enforced {
+		return ErrTwoFactorEnforced
+	}
+	if err := s.verify(ctx, userId, code, false); err != nil {
+		return err
+	}
+	return s.Reset(ctx, userId)
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，原恢复码全部失效
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userId int64, code string) ([]string, error) {
+	if err := s.verify(ctx, userId, code, false); err != nil {
+		return nil, err
+	}
+	recoveryCodes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	t := s.q.SystemUserTotp
+	if _, err := t.WithContext(ctx).Where(t.UserID.Eq(userId)).Update(t.RecoveryCodes, hashes); err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// Reset 清除用户的二次验证，供管理员处理丢失验证器的情况
+func (s *TwoFactorService) Reset(ctx context.Context, userId int64) error {
+	t := s.q.SystemUserTotp
+	_, err := t.WithContext(ctx).Unscoped().Where(t.UserID.Eq(userId)).Delete()
+	return err
+}
+
+// GetTenantPolicy 获取租户的二次验证强制策略
+func (s *TwoFactorService) GetTenantPolicy(ctx context.Context, tenantId int64) (*system.TenantTwoFactorPolicyResp, error) {
+	tenant, err := s.getTenant(ctx, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	return &system.TenantTwoFactorPolicyResp{
+		TenantID:  tenant.ID,
+		RoleCodes: lo.Ternary(tenant.TwoFactorRoleCodes == nil, []string{}, []string(tenant.TwoFactorRoleCodes)),
+	}, nil
+}
+
+// UpdateTenantPolicy 更新租户的二次验证强制策略
+func (s *TwoFactorService) UpdateTenantPolicy(ctx context.Context, req *system.TenantTwoFactorPolicyReq) error {
+	if _, err := s.getTenant(ctx, req.TenantID); err != nil {
+		return err
+	}
+	roleCodes := lo.Uniq(lo.Compact(req.RoleCodes))
+	t := s.q.SystemTenant
+	_, err := t.WithContext(ctx).Where(t.ID.Eq(req.TenantID)).Update(t.TwoFactorRoleCodes, model.StringListFromCSV(roleCodes))
+	return err
+}
+
+// setup 生成并保存待激活的密钥，已开启时不允许覆盖
+func (s *TwoFactorService) setup(ctx context.Context, userId, tenantId int64, username string) (*system.TwoFactorSetupResp, error) {
+	row, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if row != nil && row.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		row = &model.SystemUserTotp{UserID: userId}
+		row.TenantID = tenantId
+	}
+	row.Secret = secret
+	row.LastUsedStep = 0
+	if err := s.q.SystemUserTotp.WithContext(ctx).Save(row); err != nil {
+		return nil, err
+	}
+	return &system.TwoFactorSetupResp{
+		Secret:    secret,
+		QrCodeUri: totp.ProvisioningURI(config.C.App.Name, username, secret),
+	}, nil
+}
+
+// verify 校验 TOTP 验证码，allowRecovery 为 true 时也接受恢复码（使用后作废）
+func (s *TwoFactorService) verify(ctx context.Context, userId int64, code string, allowRecovery bool) error {
+	row, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if row == nil || !row.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	t := s.q.SystemUserTotp
+	if step, ok := totp.Validate(row.Secret, code, time.Now(), row.LastUsedStep); ok {
+		// 条件更新：并发请求重放同一验证码时只有一个生效
+		info, err := t.WithContext(ctx).Where(t.ID.Eq(row.ID), t.LastUsedStep.Lt(step)).Update(t.LastUsedStep, step)
+		if err != nil {
+			return err
+		}
+		if info.RowsAffected == 0 {
+			return ErrTwoFactorCodeError
+		}
+		return nil
+	}
+	if !allowRecovery {
+		return ErrTwoFactorCodeError
+	}
+
+	hash := hashRecoveryCode(code)
+	if !lo.Contains(row.RecoveryCodes, hash) {
+		return ErrTwoFactorCodeError
+	}
+	// 条件更新：恢复码未被并发请求修改时才作废，同一恢复码只能使用一次
+	remaining := model.StringListFromCSV(lo.Without(row.RecoveryCodes, hash))
+	info, err := t.WithContext(ctx).Where(t.ID.Eq(row.ID), t.RecoveryCodes.Eq(row.RecoveryCodes)).Update(t.RecoveryCodes, remaining)
+	if err != nil {
+		return err
+	}
+	if info.RowsAffected == 0 {
+		return ErrTwoFactorCodeError
+	}
+	return nil
+}
+
+// status 返回用户是否已开启二次验证，以及登录时是否需要二次验证
+func (s *TwoFactorService) status(ctx context.Context, user *model.SystemUser) (bool, bool, error) {
+	row, err := s.getTotp(ctx, user.ID)
+	if err != nil {
+		return false, false, err
+	}
+	if row != nil && row.Enabled {
+		return true, true, nil
+	}
+	enforced, err := s.isEnforced(ctx, user.ID, user.TenantID)
+	return false, enforced, err
+}
+
+// isEnforced 用户是否因租户策略被强制开启二次验证：持有策略中任一启用状态的角色
+func (s *TwoFactorService) isEnforced(ctx context.Context, userId, tenantId int64) (bool, error) {
+	tenant, err := s.getTenant(ctx, tenantId)
+	if err != nil {
+		// 租户不存在时不强制，由登录流程的租户校验处理
+		return false, nil
+	}
+	if len(tenant.TwoFactorRoleCodes) == 0 {
+		return false, nil
+	}
+
+	ur := s.q.SystemUserRole
+	userRoles, err := ur.WithContext(ctx).Where(ur.UserID.Eq(userId)).Find()
+	if err != nil {
+		return false, err
+	}
+	if len(userRoles) == 0 {
+		return false, nil
+	}
+	roleIds := lo.Map(userRoles, func(item *model.SystemUserRole, _ int) int64 { return item.RoleID })
+
+	r := s.q.SystemRole
+	count, err := r.WithContext(ctx).Where(
+		r.ID.In(roleIds...),
+		r.Code.In(tenant.TwoFactorRoleCodes...),
+		r.Status.Eq(consts.CommonStatusEnable),
+	).Count()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *TwoFactorService) getTotp(ctx context.Context, userId int64) (*model.SystemUserTotp, error) {
+	t := s.q.SystemUserTotp
+	row, err := t.WithContext(ctx).Where(t.UserID.Eq(userId)).First()
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return row, err
+}
+
+func (s *TwoFactorService) getUser(ctx context.Context, userId int64) (*model.SystemUser, error) {
+	u := s.q.SystemUser
+	user, err := u.WithContext(ctx).Where(u.ID.Eq(userId)).First()
+	if err != nil {
+		return nil, bzErr.NewBizError(1002003003, "用户不存在")
+	}
+	return user, nil
+}
+
+func (s *TwoFactorService) getTenant(ctx context.Context, tenantId int64) (*model.SystemTenant, error) {
+	t := s.q.SystemTenant
+	tenant, err := t.WithContext(ctx).Where(t.ID.Eq(tenantId)).First()
+	if err != nil {
+		return nil, bzErr.NewBizError(1002015000, "租户不存在")
+	}
+	return tenant, nil
+}
+
+func (s *TwoFactorService) saveTicket(ctx context.Context, ticket string, info *TwoFactorTicket, ttl time.Duration) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, TwoFactorTicketKeyPrefix+ticket, data, ttl).Err()
+}
+
+// getTicket 读取预认证票据及其剩余有效期
+func (s *TwoFactorService) getTicket(ctx context.Context, ticket string) (*TwoFactorTicket, time.Duration, error) {
+	key := TwoFactorTicketKeyPrefix + ticket
+	data, err := s.rdb.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, 0, ErrTwoFactorTicketInvalid
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var info TwoFactorTicket
+	if err := json.Unmarshal([]byte(data), &info); err != nil {
+		return nil, 0, err
+	}
+	ttl, err := s.rdb.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		ttl = TwoFactorTicketExpire
+	}
+	return &info, ttl, nil
+}
+
+// generateRecoveryCodes 生成恢复码明文及其摘要，格式如 k3f9q-7xw2m
+func generateRecoveryCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, TwoFactorRecoveryCodeCount)
+	hashes := make([]string, TwoFactorRecoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var code strings.Builder
+		for j, b := range buf {
+			if j == 5 {
+				code.WriteByte('-')
+			}
+			code.WriteByte(alphabet[int(b)%len(alphabet)])
+		}
+		codes[i] = code.String()
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 恢复码摘要，输入忽略大小写与首尾空白
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,162 @@
+package system
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/totp"
+	"github.com/wxlbd/admin-go/pkg/config"
+)
+
+// newTestTwoFactorService 创建已为用户 1 开启二次验证的服务，返回 TOTP 密钥
+func newTestTwoFactorService(t *testing.T) (*TwoFactorService, string) {
+	t.Helper()
+	q := newTestQuery(t, &model.SystemUserTotp{})
+	_, rdb := newTestRedis(t)
+	s := NewTwoFactorService(q, rdb, NewLoginLimitService(q, rdb))
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := &model.SystemUserTotp{UserID: 1, Secret: secret, Enabled: true}
+	row.TenantID = 1
+	if err := q.SystemUserTotp.WithContext(context.Background()).Create(row); err != nil {
+		t.Fatal(err)
+	}
+	return s, secret
+}
+
+func newTestTicket(t *testing.T, s *TwoFactorService, ticket string) {
+	t.Helper()
+	info := &TwoFactorTicket{UserID: 1, TenantID: 1, Username: "admin"}
+	if err := s.saveTicket(context.Background(), ticket, info, TwoFactorTicketExpire); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompleteLoginLocksAccountAcrossTickets(t *testing.T) {
+	ctx := context.Background()
+	s, secret := newTestTwoFactorService(t)
+	origin := config.C.LoginLimit
+	config.C.LoginLimit = config.LoginLimitConfig{UserMaxFailures: 3}
+	t.Cleanup(func() { config.C.LoginLimit = origin })
+
+	// 每次重新登录获取新票据，失败次数仍按账号累计
+	for i, ticket := range []string{"t1", "t2", "t3"} {
+		newTestTicket(t, s, ticket)
+		_, _, err := s.CompleteLogin(ctx, &system.AuthTwoFactorLoginReq{Ticket: ticket, Code: "000000"})
+		if err != ErrTwoFactorCodeError {
+			t.Fatalf("attempt %d: err = %v, want code error", i+1, err)
+		}
+	}
+
+	// 账号已锁定，正确的验证码也被拒绝
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	newTestTicket(t, s, "t4")
+	_, _, err := s.CompleteLogin(ctx, &system.AuthTwoFactorLoginReq{Ticket: "t4", Code: code})
+	if !isLoginLockedError(err) {
+		t.Fatalf("err = %v, want login locked", err)
+	}
+	if _, _, err := s.getTicket(ctx, "t4"); err != ErrTwoFactorTicketInvalid {
+		t.Fatalf("expected ticket removed after lock, got %v", err)
+	}
+}
+
+func TestCompleteLoginClearsFailuresAndRejectsReplay(t *testing.T) {
+	ctx := context.Background()
+	s, secret := newTestTwoFactorService(t)
+	origin := config.C.LoginLimit
+	config.C.LoginLimit = config.LoginLimitConfig{UserMaxFailures: 3}
+	t.Cleanup(func() { config.C.LoginLimit = origin })
+
+	newTestTicket(t, s, "t1")
+	if _, _, err := s.CompleteLogin(ctx, &system.AuthTwoFactorLoginReq{Ticket: "t1", Code: "000000"}); err != ErrTwoFactorCodeError {
+		t.Fatalf("err = %v, want code error", err)
+	}
+	failKey := LoginFailUserKeyPrefix + userLimitKey(1, "admin")
+	if n, _ := s.rdb.Get(ctx, failKey).Int(); n != 1 {
+		t.Fatalf("failures = %d, want 1", n)
+	}
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	if _, _, err := s.CompleteLogin(ctx, &system.AuthTwoFactorLoginReq{Ticket: "t1", Code: code}); err != nil {
+		t.Fatalf("err = %v, want success", err)
+	}
+	if n, _ := s.rdb.Exists(ctx, failKey).Result(); n != 0 {
+		t.Fatal("expected failures cleared after the second factor succeeds")
+	}
+
+	// 同一验证码不能再次使用
+	newTestTicket(t, s, "t2")
+	if _, _, err := s.CompleteLogin(ctx, &system.AuthTwoFactorLoginReq{Ticket: "t2", Code: code}); err != ErrTwoFactorCodeError {
+		t.Fatalf("replayed code err = %v, want code error", err)
+	}
+}
+
+func TestVerifyRejectsUsedStep(t *testing.T) {
+	ctx := context.Background()
+	s, secret := newTestTwoFactorService(t)
+
+	// 另一个请求已使用当前时间步，同一验证码被拒绝
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(secret, step)
+	tt := s.q.SystemUserTotp
+	if _, err := tt.WithContext(ctx).Where(tt.UserID.Eq(1)).Update(tt.LastUsedStep, step); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.verify(ctx, 1, code, false); err != ErrTwoFactorCodeError {
+		t.Fatalf("err = %v, want code error", err)
+	}
+}
+
+func TestVerifyRecoveryCodeOnce(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestTwoFactorService(t)
+	tt := s.q.SystemUserTotp
+	codes := model.StringListFromCSV{hashRecoveryCode("aaaa-1111"), hashRecoveryCode("bbbb-2222")}
+	if _, err := tt.WithContext(ctx).Where(tt.UserID.Eq(1)).Update(tt.RecoveryCodes, codes); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不允许恢复码时拒绝
+	if err := s.verify(ctx, 1, "aaaa-1111", false); err != ErrTwoFactorCodeError {
+		t.Fatalf("err = %v, want code error", err)
+	}
+	if err := s.verify(ctx, 1, "aaaa-1111", true); err != nil {
+		t.Fatalf("err = %v, want success", err)
+	}
+	// 恢复码使用后作废
+	if err := s.verify(ctx, 1, "aaaa-1111", true); err != ErrTwoFactorCodeError {
+		t.Fatalf("reused recovery code err = %v, want code error", err)
+	}
+	row, err := s.getTotp(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(row.RecoveryCodes) != 1 || row.RecoveryCodes[0] != hashRecoveryCode("bbbb-2222") {
+		t.Fatalf("unexpected remaining recovery codes: %v", row.RecoveryCodes)
+	}
+
+	// 并发使用同一恢复码时只有一个请求成功
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.verify(ctx, 1, "bbbb-2222", true) == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := succeeded.Load(); n != 1 {
+		t.Fatalf("expected recovery code to be accepted once, got %d", n)
+	}
+}
@@ -3,24 +3,37 @@ package system
 import (
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
 	"github.com/wxlbd/admin-go/internal/consts"
 	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/pkg/datascope"
+	"github.com/wxlbd/admin-go/internal/pkg/permission"
 	"github.com/wxlbd/admin-go/internal/repo/query"
 	"github.com/wxlbd/admin-go/pkg/pagination"
 	"github.com/wxlbd/admin-go/pkg/utils"
+	"gorm.io/gorm"
 )
 
 type UserService struct {
-	q       *query.Query
-	deptSvc *DeptService
+	q                 *query.Query
+	deptSvc           *DeptService
+	passwordPolicySvc *PasswordPolicyService
+	tenantSvc         *TenantService
+	scopeCache        *datascope.Cache
+	policySyncer      *permission.PolicySyncer
 }
 
-func NewUserService(q *query.Query, deptSvc *DeptService) *UserService {
+func NewUserService(q *query.Query, deptSvc *DeptService, passwordPolicySvc *PasswordPolicyService, tenantSvc *TenantService, scopeCache *datascope.Cache, policySyncer *permission.PolicySyncer) *UserService {
 	return &UserService{
-		q:       q,
-		deptSvc: deptSvc,
+		q:                 q,
+		deptSvc:           deptSvc,
+		passwordPolicySvc: passwordPolicySvc,
+		tenantSvc:         tenantSvc,
+		scopeCache:        scopeCache,
+		policySyncer:      policySyncer,
 	}
 }
 
@@ -59,9 +72,16 @@ func (s *UserService) CreateUser(ctx context.Context, req *system.UserSaveReq) (
 		}
 	}
 
-	// 2. 加密密码（空密码时使用默认密码）
-	if req.Password == "" {
-		req.Password = "123456" // 默认密码，对应 Java: system.user.init-password 配置
+	// 2. 校验密码策略并加密密码，未指定密码时使用初始密码，不校验策略，首次登录需修改密码
+	changeRequired := req.Password == ""
+	if changeRequired {
+		initPassword, err := s.passwordPolicySvc.GetInitPassword(ctx)
+		if err != nil {
+			return 0, err
+		}
+		req.Password = initPassword
+	} else if err := s.passwordPolicySvc.ValidatePassword(ctx, nil, req.Username, req.Password); err != nil {
+		return 0, err
 	}
 	hashedPwd, err := utils.HashPassword(req.Password)
 	if err != nil {
@@ -69,28 +89,39 @@ func (s *UserService) CreateUser(ctx context.Context, req *system.UserSaveReq) (
 	}
 
 	// 3. 构造用户对象
+	now := time.Now()
 	user := &model.SystemUser{
-		Username: req.Username,
-		Password: hashedPwd,
-		Nickname: req.Nickname,
-		DeptID:   req.DeptID,
-		PostIDs:  "",
-		Email:    req.Email,
-		Mobile:   req.Mobile,
-		Sex:      req.Sex,
-		Avatar:   req.Avatar,
-		Status:   int32(req.Status),
-		Remark:   req.Remark,
-	}
+		Username:           req.Username,
+		Password:           hashedPwd,
+		Nickname:           req.Nickname,
+		DeptID:             req.DeptID,
+		PostIDs:            "",
+		Email:              req.Email,
+		Mobile:             req.Mobile,
+		Sex:                req.Sex,
+		Avatar:             req.Avatar,
+		Status:             int32(req.Status),
+		Remark:             req.Remark,
+		PasswordUpdateTime: &now,
+
+		PasswordChangeRequired: changeRequired,
+	}
+	tenantId := requestTenantID(ctx)
+	user.TenantID = tenantId
 
 	// 4. 事务执行
 	err = s.q.Transaction(func(tx *query.Query) error {
-		// 4.1 插入用户
+		// 4.1 校验租户账号配额
+		if err := s.tenantSvc.ValidTenantAccountQuota(ctx, tx, tenantId); err != nil {
+			return err
+		}
+
+		// 4.2 插入用户
 		if err := tx.SystemUser.WithContext(ctx).Create(user); err != nil {
 			return err
 		}
 
-		// 4.2 关联岗位
+		// 4.3 关联岗位
 		if len(req.PostIDs) > 0 {
 			var userPosts []*model.SystemUserPost
 			for _, postId := range req.PostIDs {
@@ -104,7 +135,7 @@ func (s *UserService) CreateUser(ctx context.Context, req *system.UserSaveReq) (
 			}
 		}
 
-		// 4.3 关联角色
+		// 4.4 关联角色
 		if len(req.RoleIDs) > 0 {
 			var userRoles []*model.SystemUserRole
 			for _, roleId := range req.RoleIDs {
@@ -120,7 +151,17 @@ func (s *UserService) CreateUser(ctx context.Context, req *system.UserSaveReq) (
 		return nil
 	})
 
-	return user.ID, err
+	if err != nil {
+		return 0, err
+	}
+	if len(req.RoleIDs) > 0 {
+		s.policySyncer.RefreshUsers(ctx, user.ID)
+	}
+	// 新用户的岗位影响同岗位用户的数据范围
+	if len(req.PostIDs) > 0 {
+		s.scopeCache.InvalidateAll(ctx)
+	}
+	return user.ID, nil
 }
 
 // UpdateUser 更新用户
@@ -148,7 +189,7 @@ func (s *UserService) UpdateUser(ctx context.Context, req *system.UserSaveReq) e
 	}
 
 	// 3. 事务更新
-	return s.q.Transaction(func(tx *query.Query) error {
+	err = s.q.Transaction(func(tx *query.Query) error {
 		// 3.1 更新基本信息
 		_, err := tx.SystemUser.WithContext(ctx).Where(u.ID.Eq(req.ID)).Updates(&model.SystemUser{
 			Nickname: req.Nickname,
@@ -199,6 +240,13 @@ func (s *UserService) UpdateUser(ctx context.Context, req *system.UserSaveReq) e
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	s.policySyncer.RefreshUsers(ctx, req.ID)
+	// 用户的部门、岗位、角色影响本人及同岗位用户的数据范围
+	s.scopeCache.InvalidateAll(ctx)
+	return nil
 }
 
 // DeleteUser 删除用户
@@ -215,7 +263,7 @@ func (s *UserService) DeleteUser(ctx context.Context, id int64) error {
 	}
 
 	// 2. 删除用户及关联数据（使用事务）
-	return s.q.Transaction(func(tx *query.Query) error {
+	err = s.q.Transaction(func(tx *query.Query) error {
 		// 2.1 删除用户
 		if _, err := tx.SystemUser.WithContext(ctx).Where(tx.SystemUser.ID.Eq(id)).Delete(); err != nil {
 			return err
@@ -233,6 +281,11 @@ func (s *UserService) DeleteUser(ctx context.Context, id int64) error {
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	s.policySyncer.RefreshUsers(ctx, id)
+	return nil
 }
 
 // GetUser 获得用户详情
@@ -428,24 +481,42 @@ func (s *UserService) UpdateUserStatus(ctx context.Context, req *system.UserUpda
 
 // UpdateUserPassword 修改用户密码
 func (s *UserService) UpdateUserPassword(ctx context.Context, req *system.UserUpdatePasswordReq) error {
-	u := s.q.SystemUser
-	hashedPwd, err := utils.HashPassword(req.Password)
+	user, err := s.getUser(ctx, req.ID)
 	if err != nil {
 		return err
 	}
-	_, err = u.WithContext(ctx).Where(u.ID.Eq(req.ID)).Update(u.Password, hashedPwd)
-	return err
+	return s.passwordPolicySvc.ChangePassword(ctx, user, req.Password)
 }
 
 // ResetUserPassword 重置用户密码
 func (s *UserService) ResetUserPassword(ctx context.Context, req *system.UserResetPasswordReq) error {
-	u := s.q.SystemUser
-	hashedPwd, err := utils.HashPassword(req.Password)
+	user, err := s.getUser(ctx, req.ID)
 	if err != nil {
 		return err
 	}
-	_, err = u.WithContext(ctx).Where(u.ID.Eq(req.ID)).Update(u.Password, hashedPwd)
-	return err
+	return s.passwordPolicySvc.ChangePassword(ctx, user, req.Password)
+}
+
+// UpdateUserProfilePassword 修改本人密码，需校验原密码
+func (s *UserService) UpdateUserProfilePassword(ctx context.Context, userId int64, req *system.UserProfileUpdatePasswordReq) error {
+	user, err := s.getUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !utils.CheckPasswordHash(req.OldPassword, user.Password) {
+		return ErrPasswordOldError
+	}
+	return s.passwordPolicySvc.ChangePassword(ctx, user, req.NewPassword)
+}
+
+// getUser 获得用户，不存在时返回业务错误
+func (s *UserService) getUser(ctx context.Context, id int64) (*model.SystemUser, error) {
+	u := s.q.SystemUser
+	user, err := u.WithContext(ctx).Where(u.ID.Eq(id)).First()
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	return user, nil
 }
 
 // GetUserList 获得用户列表 (用于导出)
@@ -537,6 +608,88 @@ func (s *UserService) GetImportTemplate(ctx context.Context) ([]system.UserImpor
 	}, nil
 }
 
+// ImportUserList 批量导入用户
+// 对应 Java: AdminUserServiceImpl.importUserList
+func (s *UserService) ImportUserList(ctx context.Context, list []system.UserImportExcelVO, updateSupport bool) (*system.UserImportRespVO, error) {
+	if len(list) == 0 {
+		return nil, errors.New("导入用户数据不能为空！")
+	}
+	resp := &system.UserImportRespVO{
+		CreateUsernames:  []string{},
+		UpdateUsernames:  []string{},
+		FailureUsernames: map[string]string{},
+	}
+	u := s.q.SystemUser
+	for _, item := range list {
+		if item.Username == "" {
+			continue
+		}
+		sex, _ := strconv.ParseInt(item.Sex, 10, 32)
+		status, _ := strconv.Atoi(item.Status)
+		req := &system.UserSaveReq{
+			Username: item.Username,
+			Nickname: item.Nickname,
+			Email:    item.Email,
+			Mobile:   item.Mobile,
+			Sex:      int32(sex),
+			DeptID:   item.DeptID,
+			Status:   status,
+		}
+
+		// 1. 用户不存在时创建，受租户账号配额限制
+		existUser, err := u.WithContext(ctx).Where(u.Username.Eq(item.Username)).First()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if _, err := s.CreateUser(ctx, req); err != nil {
+				resp.FailureUsernames[item.Username] = err.Error()
+				continue
+			}
+			resp.CreateUsernames = append(resp.CreateUsernames, item.Username)
+			continue
+		}
+		if err != nil {
+			resp.FailureUsernames[item.Username] = err.Error()
+			continue
+		}
+
+		// 2. 用户已存在时，按 updateSupport 更新基本信息
+		if !updateSupport {
+			resp.FailureUsernames[item.Username] = "用户账号已存在"
+			continue
+		}
+		if err := s.updateImportUser(ctx, existUser.ID, req); err != nil {
+			resp.FailureUsernames[item.Username] = err.Error()
+			continue
+		}
+		resp.UpdateUsernames = append(resp.UpdateUsernames, item.Username)
+	}
+	return resp, nil
+}
+
+// updateImportUser 更新导入用户的基本信息，不修改岗位与角色
+func (s *UserService) updateImportUser(ctx context.Context, id int64, req *system.UserSaveReq) error {
+	if req.Mobile != "" {
+		if err := s.checkMobileUnique(ctx, req.Mobile, id); err != nil {
+			return err
+		}
+	}
+	if req.Email != "" {
+		if err := s.checkEmailUnique(ctx, req.Email, id); err != nil {
+			return err
+		}
+	}
+	u := s.q.SystemUser
+	// 使用 map 更新，状态、性别等零值同样写入
+	_, err := u.WithContext(ctx).Where(u.ID.Eq(id)).Updates(map[string]any{
+		"nickname": req.Nickname,
+		"dept_id":  req.DeptID,
+		"email":    req.Email,
+		"mobile":   req.Mobile,
+		"sex":      req.Sex,
+		"status":   req.Status,
+	})
+	return err
+}
+
 // Helpers
 
 func (s *UserService) checkUsernameUnique(ctx context.Context, username string, excludeId int64) error {
@@ -0,0 +1,114 @@
+package system
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wxlbd/admin-go/internal/api/contract/admin/system"
+	"github.com/wxlbd/admin-go/internal/model"
+	"github.com/wxlbd/admin-go/internal/repo/query"
+	pkgContext "github.com/wxlbd/admin-go/pkg/context"
+	"github.com/wxlbd/admin-go/pkg/utils"
+)
+
+// newTestUserService 创建用户服务，默认租户为不限配额的系统租户
+func newTestUserService(t *testing.T) (*UserService, *query.Query) {
+	t.Helper()
+	q := newTestQuery(t, &model.SystemUser{}, &model.SystemTenant{}, &model.SystemConfig{},
+		&model.SystemUserPost{}, &model.SystemUserRole{}, &model.SystemUserPasswordHistory{})
+	if err := q.SystemTenant.WithContext(context.Background()).Create(&model.SystemTenant{ID: DefaultTenantID, Name: "系统租户"}); err != nil {
+		t.Fatal(err)
+	}
+	passwordPolicySvc := NewPasswordPolicyService(q)
+	return &UserService{
+		q:                 q,
+		passwordPolicySvc: passwordPolicySvc,
+		tenantSvc:         &TenantService{q: q, passwordPolicySvc: passwordPolicySvc},
+	}, q
+}
+
+func TestCreateUserWithInitPassword(t *testing.T) {
+	ctx := pkgContext.WithTenantID(context.Background(), DefaultTenantID)
+	s, q := newTestUserService(t)
+	// 严格的密码策略不影响初始密码
+	for key, value := range map[string]string{
+		PasswordConfigKeyMinLength:       "10",
+		PasswordConfigKeyCheckDictionary: "true",
+	} {
+		if err := q.SystemConfig.WithContext(ctx).Create(&model.SystemConfig{ConfigKey: key, Value: value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	id, err := s.CreateUser(ctx, &system.UserSaveReq{Username: "zhangsan", Nickname: "张三"})
+	if err != nil {
+		t.Fatalf("init password should be exempt from the policy, err = %v", err)
+	}
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utils.CheckPasswordHash(defaultInitPassword, user.Password) || !user.PasswordChangeRequired {
+		t.Fatal("user should be created with the init password and required to change it")
+	}
+	if !s.passwordPolicySvc.IsPasswordChangeRequired(ctx, user) {
+		t.Fatal("login should require a password change")
+	}
+
+	// 指定的密码仍需符合策略
+	if _, err := s.CreateUser(ctx, &system.UserSaveReq{Username: "lisi", Nickname: "李四", Password: "123456"}); err == nil {
+		t.Fatal("explicit password should be validated")
+	}
+
+	// 修改密码后不再提示
+	if err := s.passwordPolicySvc.ChangePassword(ctx, user, "Tr0ub4dor&3x"); err != nil {
+		t.Fatal(err)
+	}
+	user, _ = s.getUser(ctx, id)
+	if user.PasswordChangeRequired || s.passwordPolicySvc.IsPasswordChangeRequired(ctx, user) {
+		t.Fatal("password change should clear the flag")
+	}
+}
+
+func TestGetInitPassword(t *testing.T) {
+	ctx := context.Background()
+	_, q := newTestUserService(t)
+	s := NewPasswordPolicyService(q)
+
+	if password, err := s.GetInitPassword(ctx); err != nil || password != defaultInitPassword {
+		t.Fatalf("GetInitPassword() = (%q, %v), want the default", password, err)
+	}
+	if err := q.SystemConfig.WithContext(ctx).Create(&model.SystemConfig{ConfigKey: PasswordConfigKeyInitPassword, Value: "Init@2026"}); err != nil {
+		t.Fatal(err)
+	}
+	if password, err := s.GetInitPassword(ctx); err != nil || password != "Init@2026" {
+		t.Fatalf("GetInitPassword() = (%q, %v), want the configured value", password, err)
+	}
+}
+
+func TestImportUserListUpdatesZeroValues(t *testing.T) {
+	ctx := pkgContext.WithTenantID(context.Background(), DefaultTenantID)
+	s, q := newTestUserService(t)
+	user := &model.SystemUser{Username: "zhangsan", Nickname: "张三", Sex: 2, Status: 1}
+	user.TenantID = DefaultTenantID
+	if err := q.SystemUser.WithContext(ctx).Create(user); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := s.ImportUserList(ctx, []system.UserImportExcelVO{
+		{Username: "zhangsan", Nickname: "张三", Sex: "0", Status: "0"},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.UpdateUsernames) != 1 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	updated, err := s.getUser(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Sex != 0 || updated.Status != 0 {
+		t.Errorf("sex = %d, status = %d, want 0", updated.Sex, updated.Status)
+	}
+}
@@ -10,13 +10,16 @@ import (
 var C = new(Config)
 
 type Config struct {
-	App   AppConfig   `mapstructure:"app"`
-	HTTP  HTTPConfig  `mapstructure:"http"`
-	Log   LogConfig   `mapstructure:"log"`
-	MySQL MySQLConfig `mapstructure:"mysql"`
-	Redis RedisConfig `mapstructure:"redis"`
-	Trade TradeConfig `mapstructure:"trade"`
-	Pay   PayConfig   `mapstructure:"pay"`
+	App        AppConfig        `mapstructure:"app"`
+	HTTP       HTTPConfig       `mapstructure:"http"`
+	Log        LogConfig        `mapstructure:"log"`
+	MySQL      MySQLConfig      `mapstructure:"mysql"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	APILog     APILogConfig     `mapstructure:"api_log"`
+	Captcha    CaptchaConfig    `mapstructure:"captcha"`
+	LoginLimit LoginLimitConfig `mapstructure:"login_limit"`
+	Trade      TradeConfig      `mapstructure:"trade"`
+	Pay        PayConfig        `mapstructure:"pay"`
 }
 
 type AppConfig struct {
@@ -25,8 +28,9 @@ type AppConfig struct {
 }
 
 type HTTPConfig struct {
-	Port string `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port            string `mapstructure:"port"`
+	Mode            string `mapstructure:"mode"`
+	ShutdownTimeout int    `mapstructure:"shutdown_timeout"` // 优雅停止的超时时间（秒），默认 30
 }
 
 type LogConfig struct {
@@ -50,6 +54,31 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db"`
 }
 
+// APILogConfig API 访问日志配置
+type APILogConfig struct {
+	QueueSize     int      `mapstructure:"queue_size"`     // 异步队列容量
+	BatchSize     int      `mapstructure:"batch_size"`     // 单次批量写入最大条数
+	FlushInterval int      `mapstructure:"flush_interval"` // 刷新间隔，单位：毫秒
+	MaskFields    []string `mapstructure:"mask_fields"`    // 完全脱敏的字段，如 password、token
+	MobileFields  []string `mapstructure:"mobile_fields"`  // 手机号脱敏的字段，保留前 3 后 4 位
+}
+
+// CaptchaConfig 验证码配置
+type CaptchaConfig struct {
+	Enable        bool   `mapstructure:"enable"`         // 是否开启登录验证码
+	Type          string `mapstructure:"type"`           // 默认验证码类型：blockPuzzle 滑块拼图，imageText 图形文字
+	ExpireSeconds int    `mapstructure:"expire_seconds"` // 验证码有效期，单位：秒
+	SliderOffset  int    `mapstructure:"slider_offset"`  // 滑块校验允许的误差，单位：像素
+}
+
+// LoginLimitConfig 登录失败限制配置，次数为 0 表示不限制
+type LoginLimitConfig struct {
+	UserMaxFailures int `mapstructure:"user_max_failures"` // 同一账号在计数窗口内允许的失败次数，达到后锁定账号
+	IPMaxFailures   int `mapstructure:"ip_max_failures"`   // 同一 IP 在计数窗口内允许的失败次数，达到后锁定 IP
+	FailureWindow   int `mapstructure:"failure_window"`    // 失败计数窗口，单位：秒
+	LockDuration    int `mapstructure:"lock_duration"`     // 锁定时长，单位：秒
+}
+
 type TradeConfig struct {
 	Express ExpressConfig `mapstructure:"express"`
 }
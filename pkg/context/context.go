@@ -10,6 +10,7 @@ const (
 	CtxUserIDKey     = "userID"
 	CtxLoginUserKey  = "loginUser"
 	CtxGinContextKey = "GinContext" // 用于在 context.Context 中传递 gin.Context
+	CtxTenantIDKey   = "tenantID"   // 显式指定的租户编号，优先于登录用户的租户
 )
 
 // LoginUser 登录用户信息，与 Java 的 LoginUser 对齐
@@ -19,6 +20,8 @@ type LoginUser struct {
 	TenantID int64  `json:"tenantId"`
 	DeptID   *int64 `json:"deptId"` // 部门ID (用于数据权限)
 	Nickname string `json:"nickname"`
+	// ImpersonatorID 代登录的操作人编号，平台管理员访问其它租户时有值
+	ImpersonatorID int64 `json:"impersonatorId,omitempty"`
 }
 
 func GetLoginUserID(c *gin.Context) int64 {
@@ -64,8 +67,16 @@ func SetLoginUser(c *gin.Context, user *LoginUser) {
 	}
 }
 
-// GetTenantId 获得租户编号
+// SetTenantId 设置当前请求的租户编号，由租户中间件解析后调用
+func SetTenantId(c *gin.Context, tenantId int64) {
+	c.Set(CtxTenantIDKey, tenantId)
+}
+
+// GetTenantId 获得租户编号，优先取租户中间件解析的请求租户，其次为登录用户的租户
 func GetTenantId(c *gin.Context) int64 {
+	if tenantId := c.GetInt64(CtxTenantIDKey); tenantId > 0 {
+		return tenantId
+	}
 	user := GetLoginUser(c)
 	if user == nil {
 		return 0
@@ -73,6 +84,19 @@ func GetTenantId(c *gin.Context) int64 {
 	return user.TenantID
 }
 
+// GetGinContext 从context.Context中获取gin.Context
+// 需在 InjectContext 中间件之后使用，否则返回 nil
+func GetGinContext(ctx context.Context) *gin.Context {
+	if ctx == nil {
+		return nil
+	}
+	if ginCtx, ok := ctx.(*gin.Context); ok {
+		return ginCtx
+	}
+	ginCtx, _ := ctx.Value(CtxGinContextKey).(*gin.Context)
+	return ginCtx
+}
+
 // GetLoginUserFromContext 从context.Context中获取登录用户
 // 用于非Gin场景(如GORM回调)
 func GetLoginUserFromContext(ctx context.Context) *LoginUser {
@@ -92,3 +116,26 @@ func GetLoginUserFromContext(ctx context.Context) *LoginUser {
 
 	return nil
 }
+
+// WithTenantID 返回指定租户的 context，用于跨租户操作（如创建租户时初始化该租户的数据）
+func WithTenantID(ctx context.Context, tenantId int64) context.Context {
+	return context.WithValue(ctx, CtxTenantIDKey, tenantId)
+}
+
+// GetTenantIDFromContext 从context.Context中获取租户编号
+// 优先取 WithTenantID 指定的租户，其次为请求租户、登录用户的租户，都没有时返回 0
+func GetTenantIDFromContext(ctx context.Context) int64 {
+	if ctx == nil {
+		return 0
+	}
+	if tenantId, ok := ctx.Value(CtxTenantIDKey).(int64); ok {
+		return tenantId
+	}
+	if ginCtx, ok := ctx.Value(CtxGinContextKey).(*gin.Context); ok {
+		return GetTenantId(ginCtx)
+	}
+	if user := GetLoginUserFromContext(ctx); user != nil {
+		return user.TenantID
+	}
+	return 0
+}
@@ -81,29 +81,32 @@ func (p *AuditPlugin) Initialize(db *gorm.DB) error {
 
 // beforeCreate 创建前的 Hook，设置 Creator 和 TenantID
 func beforeCreate(db *gorm.DB) {
-	// 1. 从 context 获取 gin.Context
+	// 1. 检查并设置 TenantID（int64 类型）
+	// 注意：只有表中有 tenant_id 字段时才设置；租户取 WithTenantID 指定的租户、请求租户或登录用户的租户
+	// 未登录的公开接口（如注册）由租户中间件解析请求租户
+	if hasField(db, "TenantID") {
+		if tenantId := pkgContext.GetTenantIDFromContext(db.Statement.Context); tenantId > 0 {
+			db.Statement.SetColumn("TenantID", tenantId)
+		}
+	}
+
+	// 2. 从 context 获取 gin.Context
 	ginCtx := extractGinContext(db.Statement.Context)
 	if ginCtx == nil {
 		return // 无 gin.Context，跳过
 	}
 
-	// 2. 获取登录用户信息
+	// 3. 获取登录用户信息
 	user := pkgContext.GetLoginUser(ginCtx)
 	if user == nil {
 		return // 未登录，跳过
 	}
 
-	// 3. 检查并设置 Creator（string 类型）
+	// 4. 检查并设置 Creator（string 类型）
 	if hasField(db, "Creator") {
 		creatorValue := strconv.FormatInt(user.UserID, 10)
 		db.Statement.SetColumn("Creator", creatorValue)
 	}
-
-	// 4. 检查并设置 TenantID（int64 类型）
-	// 注意：只有表中有 tenant_id 字段时才设置
-	if hasField(db, "TenantID") {
-		db.Statement.SetColumn("TenantID", user.TenantID)
-	}
 }
 
 // beforeUpdate 更新前的 Hook，设置 Updater
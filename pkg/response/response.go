@@ -43,11 +43,13 @@ func WriteError(c *gin.Context, code int, msg string) {
 }
 
 // WriteBizError 写入业务异常响应
+// 非业务异常同时记录到 c.Errors，由 ErrorHandler 中间件统一记录错误日志
 func WriteBizError(c *gin.Context, err error) {
 	if e, ok := err.(*errors.BizError); ok {
 		c.JSON(http.StatusOK, Error(e.Code, e.Msg))
 		return
 	}
+	_ = c.Error(err)
 	c.JSON(http.StatusOK, Error(errors.ServerErrCode, err.Error()))
 }
 
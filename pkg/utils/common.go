@@ -51,6 +51,18 @@ func IsYesterday(t time.Time) bool {
 	return t.Year() == yesterday.Year() && t.Month() == yesterday.Month() && t.Day() == yesterday.Day()
 }
 
+// TruncateString 按字符数截断字符串，避免超出数据库字段长度
+func TruncateString(s string, maxLen int) string {
+	if maxLen <= 0 || len(s) <= maxLen {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen])
+}
+
 // ToString 将各种类型转换为字符串
 func ToString(v interface{}) string {
 	switch val := v.(type) {
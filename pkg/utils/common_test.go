@@ -44,3 +44,26 @@ func TestIntSliceContains(t *testing.T) {
 		t.Errorf("IntSliceContains() should return false for non-existing element")
 	}
 }
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		maxLen int
+		want   string
+	}{
+		{"Short", "abc", 5, "abc"},
+		{"Exact", "abcde", 5, "abcde"},
+		{"Long", "abcdef", 3, "abc"},
+		{"Multibyte", "中文字符串", 2, "中文"},
+		{"NoLimit", "abc", 0, "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateString(tt.input, tt.maxLen); got != tt.want {
+				t.Errorf("TruncateString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
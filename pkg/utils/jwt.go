@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var jwtSecret = []byte("yudao-backend-go-secret") // TODO: Move to config
@@ -14,6 +15,10 @@ type Claims struct {
 	UserType int    `json:"userType"` // 0: Member, 1: Admin
 	TenantID int64  `json:"tenantId"`
 	Nickname string `json:"nickname"`
+	// ImpersonatorID 代登录的操作人编号，访问其它租户时签发的令牌才有值
+	ImpersonatorID int64 `json:"impersonatorId,omitempty"`
+	// SessionID 登录会话编号，同一次登录多次刷新的令牌保持不变
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -24,16 +29,21 @@ func GenerateToken(userID int64, duration time.Duration) (string, error) {
 
 // GenerateTokenWithInfo 生成包含完整信息的 JWT Token
 func GenerateTokenWithInfo(userID int64, userType int, tenantID int64, nickname string, duration time.Duration) (string, error) {
-	claims := Claims{
+	return GenerateTokenWithClaims(Claims{
 		UserID:   userID,
 		UserType: userType,
 		TenantID: tenantID,
 		Nickname: nickname,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "yudao-go",
-		},
+	}, duration)
+}
+
+// GenerateTokenWithClaims 按指定的用户信息生成 JWT Token，RegisteredClaims 由此处填充
+func GenerateTokenWithClaims(claims Claims, duration time.Duration) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(), // 保证同一秒内签发的令牌互不相同
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		Issuer:    "yudao-go",
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateTokenWithClaims(t *testing.T) {
+	token, err := GenerateTokenWithClaims(Claims{
+		UserID:         10,
+		UserType:       2,
+		TenantID:       3,
+		Nickname:       "admin",
+		ImpersonatorID: 1,
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 10 || claims.TenantID != 3 || claims.ImpersonatorID != 1 {
+		t.Errorf("ParseToken() = %+v", claims)
+	}
+
+	// 普通令牌没有操作人
+	token, _ = GenerateTokenWithInfo(10, 2, 3, "admin", time.Minute)
+	if claims, _ := ParseToken(token); claims == nil || claims.ImpersonatorID != 0 {
+		t.Errorf("ParseToken() = %+v, want no impersonator", claims)
+	}
+}